@@ -1,25 +1,776 @@
 package chesskimo
 
-// // AlphaBeta runs a minmaxing search with alpha-beta pruning for the given parameters.
-// // It returns the best move for a full search of a given max depth. If a depth search has to
-// // be cancelled, the result of the next best depth is returned.
-// func AlphaBeta(engine *Engine, ss *SearchSettings, dostop *uint32) SearchResult {
-// 	startTime := time.Now()
-// 	maxTime := 5 * time.Second
-// 	sr := SearchResult{Move: BitMove(0)}
+import (
+	"math"
+	"sync/atomic"
+	"time"
+)
 
-// 	sr.Depth = ss.MaxDepth
-// 	valid := alphaBeta(engine, ss.MaxDepth, -INFINITY, INFINITY, dostop, &startTime, maxTime, &sr)
-// 	if valid {
+const (
+	// MATE is the score for checkmating the opponent. The distance to the mate
+	// in plies is subtracted, so shorter mates are preferred.
+	MATE = 100000
+	// MAX_SEARCH_DEPTH limits the depth of iterative deepening.
+	MAX_SEARCH_DEPTH = 64
+	// MATE_THRESHOLD is the lowest absolute score which is considered a mate.
+	MATE_THRESHOLD = MATE - 2*MAX_SEARCH_DEPTH
 
-// 	}
+	// Move ordering scores.
+	ORDER_BEST_MOVE    = 200000
+	ORDER_CAPTURE      = 100000
+	ORDER_PROMOTION    = 90000
+	ORDER_KILLER       = 60000
+	ORDER_COUNTER_MOVE = 50000
 
-// 	return sr
-// }
+	// History scores of quiet moves are capped at HISTORY_MAX, which keeps them below
+	// the counter move. All scores are halved every HISTORY_AGING_NODES nodes and
+	// before every search, so old cutoffs lose weight against recent ones.
+	HISTORY_MAX         = 40000
+	HISTORY_AGING_NODES = 1 << 16
 
-// func alphaBeta(engine *Engine, depth, alpha, beta int, dostop *uint32, startTime *time.Time, maxTime time.Duration, result *SearchResult) bool {
-// 	mlist := MoveList{}
-// 	board := engine.board
+	// Capture history scores are capped at CAPTURE_HISTORY_MAX and shifted right by
+	// CAPTURE_HISTORY_SHIFT for move ordering. The resulting bonus is below 10, so with
+	// the default piece values it only reorders captures with equal MVV-LVA scores.
+	CAPTURE_HISTORY_MAX   = 1 << 10
+	CAPTURE_HISTORY_SHIFT = 7
 
-// 	return true
-// }
+	// Late move reductions are applied from LMR_MIN_DEPTH on, to all moves
+	// after the first LMR_FULL_DEPTH_MOVES moves. The reduction of a move is
+	// LMR_BASE + ln(depth) * ln(index) / LMR_DIVISOR plies.
+	LMR_MIN_DEPTH        = 3
+	LMR_FULL_DEPTH_MOVES = 3
+	LMR_BASE             = 0.75
+	LMR_DIVISOR          = 2.25
+
+	// Internal iterative deepening searches principal variation nodes without a best
+	// move hint from IID_MIN_DEPTH on with a depth reduced by IID_REDUCTION first.
+	// The best move of that search is then searched first.
+	IID_MIN_DEPTH = 4
+	IID_REDUCTION = 2
+
+	// Iterative deepening searches from ASPIRATION_MIN_DEPTH on with a window of
+	// ASPIRATION_WINDOW centipawns around the score of the previous iteration.
+	// If the score falls outside, the iteration is repeated with a full window.
+	ASPIRATION_WINDOW    = 50
+	ASPIRATION_MIN_DEPTH = 4
+
+	// Futility pruning skips quiet moves at nodes with a remaining depth up to
+	// FUTILITY_MAX_DEPTH if the static evaluation plus FUTILITY_MARGIN per ply of
+	// depth cannot reach alpha. Reverse futility (static null move) pruning cuts nodes if
+	// the static evaluation minus REVERSE_FUTILITY_MARGIN per ply of depth exceeds beta.
+	FUTILITY_MAX_DEPTH      = 3
+	FUTILITY_MARGIN         = 150
+	REVERSE_FUTILITY_MARGIN = 120
+
+	// The best move found by internal iterative deepening is extended by one ply from
+	// SINGULAR_MIN_DEPTH on, if all other moves score at least SINGULAR_MARGIN per ply
+	// of depth below it.
+	SINGULAR_MIN_DEPTH = 4
+	SINGULAR_MARGIN    = 20
+)
+
+// SearchParams holds the tunable parameters of the search. The zero value is not
+// useful, engines start with DefaultSearchParams.
+type SearchParams struct {
+	LMRMinDepth       int
+	LMRFullDepthMoves int
+	LMRBase           float64
+	LMRDivisor        float64
+
+	IIDMinDepth  int
+	IIDReduction int
+
+	// AspirationWindow is the half width of the aspiration window, 0 disables it.
+	AspirationWindow   int
+	AspirationMinDepth int
+
+	FutilityMaxDepth      int
+	FutilityMargin        int
+	ReverseFutilityMargin int
+
+	SingularMinDepth int
+	SingularMargin   int
+
+	// Contempt is subtracted from the score of draws by stalemate, repetition, the
+	// fifty-move rule and insufficient material for the side to move at the root, so
+	// positive values make the engine avoid draws.
+	Contempt int
+}
+
+// DefaultSearchParams is used if no other parameters are given.
+var DefaultSearchParams = SearchParams{
+	LMRMinDepth:        LMR_MIN_DEPTH,
+	LMRFullDepthMoves:  LMR_FULL_DEPTH_MOVES,
+	LMRBase:            LMR_BASE,
+	LMRDivisor:         LMR_DIVISOR,
+	IIDMinDepth:        IID_MIN_DEPTH,
+	IIDReduction:       IID_REDUCTION,
+	AspirationWindow:   ASPIRATION_WINDOW,
+	AspirationMinDepth: ASPIRATION_MIN_DEPTH,
+
+	FutilityMaxDepth:      FUTILITY_MAX_DEPTH,
+	FutilityMargin:        FUTILITY_MARGIN,
+	ReverseFutilityMargin: REVERSE_FUTILITY_MARGIN,
+
+	SingularMinDepth: SINGULAR_MIN_DEPTH,
+	SingularMargin:   SINGULAR_MARGIN,
+}
+
+// lmrTable holds the depth reduction of late moves indexed by the remaining
+// depth and the index of the move. Later moves and higher depths are reduced more.
+type lmrTable [MAX_SEARCH_DEPTH + 1][max_movelist_size]int
+
+func (p *SearchParams) lmrTable() *lmrTable {
+	t := &lmrTable{}
+	for depth := 1; depth <= MAX_SEARCH_DEPTH; depth++ {
+		for i := 1; i < max_movelist_size; i++ {
+			t[depth][i] = int(p.LMRBase + math.Log(float64(depth))*math.Log(float64(i))/p.LMRDivisor)
+		}
+	}
+	return t
+}
+
+// lmrTable returns the late move reductions for the current Params. The table is
+// only rebuilt if the LMR parameters changed since it was last built.
+func (e *Engine) lmrTable() *lmrTable {
+	key := [2]float64{e.Params.LMRBase, e.Params.LMRDivisor}
+	if e.lmr == nil || e.lmrParams != key {
+		e.lmr = e.Params.lmrTable()
+		e.lmrParams = key
+	}
+	return e.lmr
+}
+
+// CounterMoveTable maps the from and to squares of the opponent's last move
+// to the quiet move which most recently refuted it by causing a beta cutoff.
+type CounterMoveTable [128][128]BitMove
+
+// Clear removes all entries from the table.
+func (cm *CounterMoveTable) Clear() {
+	*cm = CounterMoveTable{}
+}
+
+// Get returns the stored counter move for 'prev' or 0 if there is none.
+func (cm *CounterMoveTable) Get(prev BitMove) BitMove {
+	return cm[prev.From()][prev.To()]
+}
+
+// Put stores 'm' as counter move for 'prev'.
+func (cm *CounterMoveTable) Put(prev, m BitMove) {
+	cm[prev.From()][prev.To()] = m
+}
+
+// HistoryTable scores quiet moves per color, from and to square by how often and
+// how deep they caused a beta cutoff.
+type HistoryTable [2][128][128]int
+
+// Clear removes all entries from the table.
+func (h *HistoryTable) Clear() {
+	*h = HistoryTable{}
+}
+
+// Get returns the history score of move 'm' for 'color'.
+func (h *HistoryTable) Get(color Color, m BitMove) int {
+	return h[color][m.From()][m.To()]
+}
+
+// Add rewards move 'm' of 'color' for a beta cutoff at the given depth.
+// The score is capped at HISTORY_MAX.
+func (h *HistoryTable) Add(color Color, m BitMove, depth int) {
+	entry := &h[color][m.From()][m.To()]
+	*entry += depth * depth
+	if *entry > HISTORY_MAX {
+		*entry = HISTORY_MAX
+	}
+}
+
+// Age halves all history scores.
+func (h *HistoryTable) Age() {
+	for color := range h {
+		for from := range h[color] {
+			for to := range h[color][from] {
+				h[color][from][to] /= 2
+			}
+		}
+	}
+}
+
+// CaptureHistoryTable scores captures by the type of the moving piece, the type of the
+// captured piece and the target square, by how often and how deep they caused a beta cutoff.
+type CaptureHistoryTable [7][7][128]int
+
+// Clear removes all entries from the table.
+func (ch *CaptureHistoryTable) Clear() {
+	*ch = CaptureHistoryTable{}
+}
+
+// Get returns the score of 'piece' capturing 'captured' on square 'to'.
+func (ch *CaptureHistoryTable) Get(piece, captured Piece, to Square) int {
+	return ch[piece.TypeIndex()][captured.TypeIndex()][to]
+}
+
+// Add rewards 'piece' capturing 'captured' on square 'to' for a beta cutoff at the
+// given depth. The score is capped at CAPTURE_HISTORY_MAX.
+func (ch *CaptureHistoryTable) Add(piece, captured Piece, to Square, depth int) {
+	entry := &ch[piece.TypeIndex()][captured.TypeIndex()][to]
+	*entry += depth * depth
+	if *entry > CAPTURE_HISTORY_MAX {
+		*entry = CAPTURE_HISTORY_MAX
+	}
+}
+
+// Age halves all capture history scores.
+func (ch *CaptureHistoryTable) Age() {
+	for piece := range ch {
+		for captured := range ch[piece] {
+			for to := range ch[piece][captured] {
+				ch[piece][captured][to] /= 2
+			}
+		}
+	}
+}
+
+// searcher holds the state of a single search run.
+type searcher struct {
+	engine   *Engine
+	settings *SearchSettings
+	params   *SearchParams
+	lmr      *lmrTable
+	dostop   *uint32
+	nodes    uint64
+	selDepth int
+	stopped  bool
+	bestMove BitMove
+	// rootHint is the best move of the previous iteration, which is searched first.
+	rootHint BitMove
+	deadline time.Time
+	// killers holds two quiet moves per ply which recently caused a beta cutoff.
+	killers [MAX_SEARCH_DEPTH + 1][2]BitMove
+	// pv is the triangular table of principal variations, pv[ply] holds pvLen[ply]
+	// moves of the best line found from 'ply' on.
+	pv    [MAX_SEARCH_DEPTH + 1][MAX_SEARCH_DEPTH + 1]BitMove
+	pvLen [MAX_SEARCH_DEPTH + 1]int
+	// keys holds the hashes of the positions of the current line to detect repetitions.
+	keys []uint64
+}
+
+func newSearcher(engine *Engine, ss *SearchSettings, dostop *uint32) *searcher {
+	params := engine.Params
+	return &searcher{engine: engine, settings: ss, params: &params, lmr: engine.lmrTable(), dostop: dostop}
+}
+
+// IsMateScore returns true if the score means that one side is getting mated.
+func IsMateScore(score int) bool {
+	return score >= MATE_THRESHOLD || score <= -MATE_THRESHOLD
+}
+
+// MatePliesToScore encodes a mate in 'plies' plies from the point of view of the side
+// to move. A positive number of plies means the side to move mates and is encoded as
+// MATE - plies. Zero or a negative number means the side to move is mated and is
+// encoded as -MATE - plies, so -MATE is the score of a checkmated position.
+func MatePliesToScore(plies int) int {
+	if plies > 0 {
+		return MATE - plies
+	}
+	return -MATE - plies
+}
+
+// ScoreToMatePlies decodes a score created by MatePliesToScore. If the score is not
+// a mate score, false is returned.
+func ScoreToMatePlies(score int) (plies int, isMate bool) {
+	if score >= MATE_THRESHOLD {
+		return MATE - score, true
+	} else if score <= -MATE_THRESHOLD {
+		return -MATE - score, true
+	}
+	return 0, false
+}
+
+// AlphaBetaSearch runs a negamax search with alpha-beta pruning to the maximum
+// depth given by the search settings and returns the best move found.
+func AlphaBetaSearch(engine *Engine, ss *SearchSettings, dostop *uint32) SearchResult {
+	start := time.Now()
+	s := newSearcher(engine, ss, dostop)
+	engine.history.Age()
+	engine.captureHistory.Age()
+	board := engine.board
+
+	score := s.alphaBeta(&board, ss.MaxDepth, 0, -INFINITY, INFINITY, BitMove(0), BitMove(0))
+
+	pv := s.principalVariation()
+	return SearchResult{
+		Move:     s.bestMove,
+		Score:    score,
+		Depth:    ss.MaxDepth,
+		SelDepth: s.selDepth,
+		Nodes:    s.nodes,
+		Time:     time.Since(start),
+		PV:       pv,
+		Ponder:   ponderMove(s.bestMove, pv),
+	}
+}
+
+// IterativeDeepening searches the position with increasing depth until the maximum depth
+// or the time limit of the search settings is reached. The result of the deepest completed
+// iteration is returned. As soon as a forced mate is found the search returns early,
+// because searching deeper cannot change a proven mate.
+func IterativeDeepening(engine *Engine, ss *SearchSettings, dostop *uint32) SearchResult {
+	start := time.Now()
+	s := newSearcher(engine, ss, dostop)
+	engine.history.Age()
+	engine.captureHistory.Age()
+	if ss.MoveTime > 0 {
+		s.deadline = time.Now().Add(ss.MoveTime)
+	}
+
+	maxDepth := ss.MaxDepth
+	if maxDepth <= 0 || maxDepth > MAX_SEARCH_DEPTH {
+		maxDepth = MAX_SEARCH_DEPTH
+	}
+
+	sr := SearchResult{Move: BitMove(0)}
+	for depth := 1; depth <= maxDepth; depth++ {
+		alpha, beta := -INFINITY, INFINITY
+		if s.params.AspirationWindow > 0 && depth >= s.params.AspirationMinDepth {
+			alpha, beta = sr.Score-s.params.AspirationWindow, sr.Score+s.params.AspirationWindow
+		}
+		board := engine.board
+		s.bestMove = BitMove(0)
+		score := s.alphaBeta(&board, depth, 0, alpha, beta, BitMove(0), BitMove(0))
+		if !s.stopped && (score <= alpha || score >= beta) {
+			// The score is outside of the aspiration window, search again with a full window.
+			board = engine.board
+			s.bestMove = BitMove(0)
+			score = s.alphaBeta(&board, depth, 0, -INFINITY, INFINITY, BitMove(0), BitMove(0))
+		}
+		if s.stopped {
+			// Results of incomplete iterations are discarded,
+			// unless there is no result at all yet.
+			if sr.Move == 0 {
+				sr.Move = s.bestMove
+			}
+			break
+		}
+
+		sr.Move = s.bestMove
+		sr.Score = score
+		sr.Depth = depth
+		sr.PV = s.principalVariation()
+		sr.Ponder = ponderMove(sr.Move, sr.PV)
+		s.rootHint = s.bestMove
+
+		if IsMateScore(score) {
+			break
+		}
+	}
+	sr.SelDepth = s.selDepth
+	sr.Nodes = s.nodes
+	sr.Time = time.Since(start)
+
+	return sr
+}
+
+// alphaBeta searches the position on board 'b' to the given depth. 'prev' is the
+// move that led to this position and is used to find the counter move. The move
+// 'excluded' is skipped, which is used to test if the best move is singular.
+func (s *searcher) alphaBeta(b *Board, depth, ply, alpha, beta int, prev, excluded BitMove) int {
+	if depth <= 0 {
+		return s.quiesce(b, ply, alpha, beta)
+	}
+
+	s.nodes++
+	if ply > s.selDepth {
+		s.selDepth = ply
+	}
+	s.pvLen[ply] = 0
+	if s.nodes%HISTORY_AGING_NODES == 0 {
+		s.engine.history.Age()
+		s.engine.captureHistory.Age()
+	}
+	if s.shouldStop() {
+		return 0
+	}
+	if ply > 0 && b.IsRepetition(s.keys) {
+		// The position already occurred in the current line, which can be repeated forever.
+		return s.drawScore(ply)
+	}
+
+	mlist := MoveList{}
+	b.GenerateAllLegalMoves(&mlist)
+	if mlist.Size == 0 {
+		if b.CheckInfo != CHECK_NONE {
+			// Checkmate.
+			return MatePliesToScore(-ply)
+		}
+		// Stalemate.
+		return s.drawScore(ply)
+	}
+	if ply > 0 && (b.DrawCounter >= FIFTY_MOVE_LIMIT || b.IsInsufficientMaterial()) {
+		// A checkmate on the last move still wins, so the fifty-move rule is tested afterwards.
+		return s.drawScore(ply)
+	}
+
+	inCheck := b.CheckInfo != CHECK_NONE
+	futile := false
+	if (!s.settings.DisableFutility || !s.settings.DisableReverseFutility) && ply > 0 && !inCheck &&
+		depth <= s.params.FutilityMaxDepth && !IsMateScore(alpha) && !IsMateScore(beta) {
+		eval := b.EvaluateWith(&s.engine.Eval)
+		if !s.settings.DisableReverseFutility && eval-s.params.ReverseFutilityMargin*depth >= beta {
+			// Reverse futility: the position is so good that the opponent avoids it anyway.
+			return beta
+		}
+		futile = !s.settings.DisableFutility && eval+s.params.FutilityMargin*depth <= alpha
+	}
+
+	hint := BitMove(0)
+	// singular is the hint move if it is much better than all alternatives.
+	singular := BitMove(0)
+	if ply == 0 {
+		hint = s.rootHint
+	}
+	if hint == 0 && !s.settings.DisableIID && depth >= s.params.IIDMinDepth && beta-alpha > 1 {
+		// Internal iterative deepening: find a likely best move with a shallower search.
+		hintScore := s.alphaBeta(b, depth-s.params.IIDReduction, ply, alpha, beta, prev, excluded)
+		if s.stopped {
+			return 0
+		}
+		if s.pvLen[ply] > 0 {
+			hint = s.pv[ply][0]
+		}
+		s.pvLen[ply] = 0
+
+		if hint != 0 && s.isSingular(b, hint, hintScore, depth, ply, prev) {
+			singular = hint
+		}
+		if s.stopped {
+			return 0
+		}
+	}
+
+	counter := BitMove(0)
+	if prev != 0 && !s.settings.DisableCounterMoves {
+		counter = s.engine.counterMoves.Get(prev)
+	}
+	killers := s.killers[ply]
+	scores := [max_movelist_size]int{}
+	var history *HistoryTable
+	if !s.settings.DisableHistory {
+		history = &s.engine.history
+	}
+	b.scoreMoves(&mlist, &scores, counter, killers, history, s.captureHistory(), &s.engine.Eval)
+	if hint != 0 {
+		for i := uint32(0); i < mlist.Size; i++ {
+			if mlist.Moves[i] == hint {
+				scores[i] = ORDER_BEST_MOVE
+			}
+		}
+	}
+
+	cpy := *b
+	b.PushKey(&s.keys)
+	for i := uint32(0); i < mlist.Size; i++ {
+		pickMove(&mlist, &scores, i)
+		move := mlist.Moves[i]
+		if move == excluded || (ply == 0 && s.isExcluded(move)) {
+			continue
+		}
+		quiet := b.isQuiet(move)
+
+		b.MakeLegalMove(move)
+
+		if futile && quiet && !b.InCheck(b.Player) {
+			// Quiet moves without check cannot raise the score enough to reach alpha.
+			*b = cpy
+			continue
+		}
+
+		// Late quiet moves are searched with reduced depth and a null window first. Tactical
+		// moves (captures, promotions, checks, killers) and check evasions are never reduced.
+		reduction := 0
+		if !s.settings.DisableLMR && depth >= s.params.LMRMinDepth && int(i) >= s.params.LMRFullDepthMoves &&
+			quiet && !inCheck && move != killers[0] && move != killers[1] && !b.InCheck(b.Player) {
+			reduction = s.lmr[depth][i]
+			if reduction > depth-2 {
+				reduction = depth - 2
+			}
+		}
+
+		newDepth := depth - 1
+		if move == singular {
+			// Singular extension: the only good move is searched one ply deeper.
+			newDepth++
+		}
+
+		var score int
+		if reduction > 0 {
+			score = -s.alphaBeta(b, newDepth-reduction, ply+1, -alpha-1, -alpha, move, BitMove(0))
+			if score > alpha {
+				// The reduced search failed high, so the move must be searched fully.
+				score = -s.alphaBeta(b, newDepth, ply+1, -beta, -alpha, move, BitMove(0))
+			}
+		} else {
+			score = -s.alphaBeta(b, newDepth, ply+1, -beta, -alpha, move, BitMove(0))
+		}
+		*b = cpy
+
+		if s.stopped {
+			b.PopKey(&s.keys)
+			return 0
+		}
+
+		if score > alpha {
+			alpha = score
+			s.updatePV(ply, move)
+			if ply == 0 {
+				s.bestMove = move
+			}
+			if alpha >= beta {
+				// Beta cutoff -> remember quiet refutations.
+				if quiet {
+					if !s.settings.DisableKillers {
+						s.storeKiller(ply, move)
+					}
+					if prev != 0 && !s.settings.DisableCounterMoves {
+						s.engine.counterMoves.Put(prev, move)
+					}
+					if !s.settings.DisableHistory {
+						s.engine.history.Add(b.Player, move, depth)
+					}
+				} else {
+					s.storeCapture(b, move, depth)
+				}
+				break
+			}
+		}
+	}
+	b.PopKey(&s.keys)
+
+	return alpha
+}
+
+// quiesce only searches captures and promotions until a quiet position is reached,
+// so the static evaluation is not applied in the middle of an exchange.
+func (s *searcher) quiesce(b *Board, ply, alpha, beta int) int {
+	s.nodes++
+	if ply > s.selDepth {
+		s.selDepth = ply
+	}
+	if ply <= MAX_SEARCH_DEPTH {
+		// The principal variation ends here.
+		s.pvLen[ply] = 0
+	}
+	if s.shouldStop() {
+		return 0
+	}
+
+	mlist := MoveList{}
+	b.GenerateAllLegalMoves(&mlist)
+	if mlist.Size == 0 {
+		if b.CheckInfo != CHECK_NONE {
+			return MatePliesToScore(-ply)
+		}
+		return s.drawScore(ply)
+	}
+
+	standPat := b.EvaluateWith(&s.engine.Eval)
+	if standPat >= beta {
+		return standPat
+	}
+	if standPat > alpha {
+		alpha = standPat
+	}
+
+	scores := [max_movelist_size]int{}
+	b.scoreMoves(&mlist, &scores, BitMove(0), [2]BitMove{}, nil, s.captureHistory(), &s.engine.Eval)
+
+	inCheck := b.CheckInfo != CHECK_NONE
+	cpy := *b
+	for i := uint32(0); i < mlist.Size; i++ {
+		pickMove(&mlist, &scores, i)
+		move := mlist.Moves[i]
+		if b.isQuiet(move) {
+			// Moves are sorted, so only quiet moves remain.
+			break
+		}
+		if !inCheck && !s.settings.DisableSEEPruning && !b.SEEGreaterEqual(move, 0, &s.engine.Eval) {
+			// Losing captures are unlikely to raise the score above the stand pat.
+			continue
+		}
+
+		b.MakeLegalMove(move)
+		score := -s.quiesce(b, ply+1, -beta, -alpha)
+		*b = cpy
+
+		if s.stopped {
+			return 0
+		}
+
+		if score > alpha {
+			alpha = score
+			if alpha >= beta {
+				s.storeCapture(b, move, 1)
+				break
+			}
+		}
+	}
+
+	return alpha
+}
+
+// updatePV makes 'm' followed by the principal variation of the next ply the new
+// principal variation of 'ply'.
+func (s *searcher) updatePV(ply int, m BitMove) {
+	if ply >= MAX_SEARCH_DEPTH {
+		return
+	}
+	s.pv[ply][0] = m
+	n := copy(s.pv[ply][1:], s.pv[ply+1][:s.pvLen[ply+1]])
+	s.pvLen[ply] = n + 1
+}
+
+// principalVariation returns a copy of the principal variation from the root.
+func (s *searcher) principalVariation() []BitMove {
+	pv := make([]BitMove, s.pvLen[0])
+	copy(pv, s.pv[0][:s.pvLen[0]])
+	return pv
+}
+
+// isSingular tests if all moves except 'hint' fail low against a bound below 'hintScore',
+// the score of the hint move. This is tested with a null window search at half the
+// depth, which excludes the hint move.
+func (s *searcher) isSingular(b *Board, hint BitMove, hintScore, depth, ply int, prev BitMove) bool {
+	if s.settings.DisableSingular || ply == 0 || depth < s.params.SingularMinDepth ||
+		IsMateScore(hintScore) || ply+depth >= MAX_SEARCH_DEPTH {
+		return false
+	}
+	singularBeta := hintScore - s.params.SingularMargin*depth
+	score := s.alphaBeta(b, (depth-1)/2, ply, singularBeta-1, singularBeta, prev, hint)
+	s.pvLen[ply] = 0
+	return !s.stopped && score < singularBeta
+}
+
+// isExcluded tests if the root move 'm' must not be searched.
+func (s *searcher) isExcluded(m BitMove) bool {
+	for _, ex := range s.settings.ExcludeMoves {
+		if ex == m {
+			return true
+		}
+	}
+	return false
+}
+
+// storeKiller remembers 'm' as the first killer move of the ply.
+func (s *searcher) storeKiller(ply int, m BitMove) {
+	if ply > MAX_SEARCH_DEPTH || s.killers[ply][0] == m {
+		return
+	}
+	s.killers[ply][1] = s.killers[ply][0]
+	s.killers[ply][0] = m
+}
+
+// captureHistory returns the capture history table of the engine or nil if it is disabled.
+func (s *searcher) captureHistory() *CaptureHistoryTable {
+	if s.settings.DisableCaptureHistory {
+		return nil
+	}
+	return &s.engine.captureHistory
+}
+
+// storeCapture rewards 'm' in the capture history, if it is a capture which caused
+// a beta cutoff at the given depth.
+func (s *searcher) storeCapture(b *Board, m BitMove, depth int) {
+	if captured := b.capturedPiece(m); captured != EMPTY && !s.settings.DisableCaptureHistory {
+		s.engine.captureHistory.Add(b.Squares[m.From()], captured, m.To(), depth)
+	}
+}
+
+// drawScore returns the score of a draw at 'ply' for the side to move. With contempt
+// the side to move at the root values a draw below zero, its opponent above.
+func (s *searcher) drawScore(ply int) int {
+	if ply%2 == 0 {
+		return -s.params.Contempt
+	}
+	return s.params.Contempt
+}
+
+func (s *searcher) shouldStop() bool {
+	if !s.stopped && s.nodes&1023 == 0 {
+		if s.dostop != nil && atomic.LoadUint32(s.dostop) != 0 {
+			s.stopped = true
+		} else if !s.deadline.IsZero() && time.Now().After(s.deadline) {
+			s.stopped = true
+		}
+	}
+	return s.stopped
+}
+
+// capturedPiece returns the piece captured by the move, including en passant, or EMPTY.
+func (b *Board) capturedPiece(m BitMove) Piece {
+	from, to := m.From(), m.To()
+	if !b.Squares[to].IsEmpty() {
+		return b.Squares[to]
+	}
+	if to == b.EpSquare && b.Squares[from]&PIECE_MASK == PAWN {
+		return PAWN | b.Player.Flip()
+	}
+	return EMPTY
+}
+
+// isQuiet returns true if the move is neither a capture nor a promotion.
+func (b *Board) isQuiet(m BitMove) bool {
+	from, to, promo := m.All()
+	if promo != NONE || !b.Squares[to].IsEmpty() {
+		return false
+	}
+	if to == b.EpSquare && b.Squares[from]&PIECE_MASK == PAWN {
+		return false
+	}
+	return true
+}
+
+// scoreMoves assigns an ordering score to every move in the list. Captures are ordered
+// by MVV-LVA (most valuable victim - least valuable attacker), followed by promotions,
+// the killer moves and the counter move. All other quiet moves are ordered by their
+// score in the history table. Captures with equal MVV-LVA scores are ordered by the
+// capture history. Both tables may be nil.
+func (b *Board) scoreMoves(mlist *MoveList, scores *[max_movelist_size]int, counter BitMove, killers [2]BitMove,
+	history *HistoryTable, captureHistory *CaptureHistoryTable, cfg *EvalConfig) {
+	for i := uint32(0); i < mlist.Size; i++ {
+		move := mlist.Moves[i]
+		from, to, promo := move.All()
+
+		if captured := b.capturedPiece(move); captured != EMPTY {
+			scores[i] = ORDER_CAPTURE + 10*cfg.PieceValue(captured) - cfg.PieceValue(b.Squares[from])
+			if captureHistory != nil {
+				scores[i] += captureHistory.Get(b.Squares[from], captured, to) >> CAPTURE_HISTORY_SHIFT
+			}
+		} else if promo != NONE {
+			scores[i] = ORDER_PROMOTION + cfg.PieceValue(promo)
+		} else if move == killers[0] {
+			scores[i] = ORDER_KILLER + 1
+		} else if move == killers[1] {
+			scores[i] = ORDER_KILLER
+		} else if move == counter {
+			scores[i] = ORDER_COUNTER_MOVE
+		} else if history != nil {
+			scores[i] = history.Get(b.Player, move)
+		} else {
+			scores[i] = 0
+		}
+	}
+}
+
+// pickMove finds the move with the highest score in the list starting at index 'start'
+// and swaps it to the 'start' position.
+func pickMove(mlist *MoveList, scores *[max_movelist_size]int, start uint32) {
+	best := start
+	for i := start + 1; i < mlist.Size; i++ {
+		if scores[i] > scores[best] {
+			best = i
+		}
+	}
+	if best != start {
+		mlist.Moves[start], mlist.Moves[best] = mlist.Moves[best], mlist.Moves[start]
+		scores[start], scores[best] = scores[best], scores[start]
+	}
+}
@@ -0,0 +1,485 @@
+package chesskimo
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func newTestEngine(fen string) *Engine {
+	engine := NewEngine("Chesskimo", "test", "", &UCI{}, AlphaBetaSearch)
+	err := engine.board.SetFEN(fen)
+	if err != nil {
+		panic(err)
+	}
+	return engine
+}
+
+// tacticalTest is a position with a single best move.
+type tacticalTest struct {
+	Fen  string
+	Move string
+}
+
+// tacticalSuite holds short tactics, which must still be found when a search feature
+// is enabled or tuned.
+var tacticalSuite = []tacticalTest{
+	// Mate in one.
+	{"r1bqkb1r/pppp1ppp/2n2n2/4p2Q/2B1P3/8/PPPP1PPP/RNB1K1NR w KQkq - 4 4", "h5f7"},
+	// The black queen hangs to the bishop.
+	{"rnb1kbnr/pppp1ppp/8/4p1q1/3P4/8/PPP1PPPP/RNBQKBNR w KQkq - 0 3", "c1g5"},
+	// Knight fork of king and rook.
+	{"r3k3/8/8/1N6/8/8/8/4K3 w - - 0 1", "b5c7"},
+	// Back rank mate.
+	{"6k1/5ppp/8/8/8/8/5PPP/3R2K1 w - - 0 1", "d1d8"},
+	// Black mates in three.
+	{"r1b1kb1r/pppp1ppp/5q2/4n3/3KP3/2N3PN/PPP4P/R1BQ1B1R b kq - 0 1", "f8c5"},
+}
+
+func TestCounterMovesReduceNodes(t *testing.T) {
+	fen := "r1b1kb1r/pppp1ppp/5q2/4n3/3KP3/2N3PN/PPP4P/R1BQ1B1R b kq - 0 1"
+	depth := 5
+
+	// Other heuristics are disabled to measure the effect of counter moves alone.
+	withCM := AlphaBetaSearch(newTestEngine(fen), &SearchSettings{MaxDepth: depth, DisableKillers: true, DisableLMR: true, DisableHistory: true, DisableCaptureHistory: true}, nil)
+	withoutCM := AlphaBetaSearch(newTestEngine(fen), &SearchSettings{MaxDepth: depth, DisableKillers: true, DisableLMR: true, DisableHistory: true, DisableCaptureHistory: true, DisableCounterMoves: true}, nil)
+
+	if withCM.Score != withoutCM.Score {
+		t.Fatalf("Counter moves must not change the score: %d with, %d without\n", withCM.Score, withoutCM.Score)
+	}
+	if withCM.Nodes >= withoutCM.Nodes {
+		t.Fatalf("Expected fewer nodes with counter moves, but got %d with and %d without\n", withCM.Nodes, withoutCM.Nodes)
+	}
+	t.Logf("Nodes with counter moves: %d, without: %d\n", withCM.Nodes, withoutCM.Nodes)
+}
+
+func TestAlphaBetaFindsMateInOne(t *testing.T) {
+	engine := newTestEngine("r1bqkb1r/pppp1ppp/2n2n2/4p2Q/2B1P3/8/PPPP1PPP/RNB1K1NR w KQkq - 4 4")
+	sr := AlphaBetaSearch(engine, &SearchSettings{MaxDepth: 3}, nil)
+
+	if sr.Move.MiniNotation() != "h5f7" {
+		t.Fatalf("Expected mate h5f7 but got %s\n", sr.Move.MiniNotation())
+	}
+	if sr.Score != MATE-1 {
+		t.Fatalf("Expected score %d but got %d\n", MATE-1, sr.Score)
+	}
+}
+
+func TestIterativeDeepeningStopsOnMate(t *testing.T) {
+	// Black mates in three (5 plies).
+	fen := "r1b1kb1r/pppp1ppp/5q2/4n3/3KP3/2N3PN/PPP4P/R1BQ1B1R b kq - 0 1"
+
+	sr := IterativeDeepening(newTestEngine(fen), &SearchSettings{MaxDepth: 10}, nil)
+	if sr.Score != MATE-5 {
+		t.Fatalf("Expected mate in 5 plies with score %d but got %d\n", MATE-5, sr.Score)
+	}
+	if sr.Depth != 5 {
+		t.Fatalf("Expected search to stop at depth 5 but it reached depth %d\n", sr.Depth)
+	}
+
+	// A full search to depth 10 would need many more nodes than even a fixed depth 6 search.
+	full := AlphaBetaSearch(newTestEngine(fen), &SearchSettings{MaxDepth: 6}, nil)
+	if sr.Nodes >= full.Nodes {
+		t.Fatalf("Expected mate search to need fewer nodes (%d) than a depth 6 search (%d)\n", sr.Nodes, full.Nodes)
+	}
+}
+
+func TestIterativeDeepeningRespectsMoveTime(t *testing.T) {
+	engine := newTestEngine("r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1")
+
+	start := time.Now()
+	sr := IterativeDeepening(engine, &SearchSettings{MoveTime: 200 * time.Millisecond}, nil)
+	elapsed := time.Since(start)
+
+	if sr.Move == 0 {
+		t.Fatalf("Expected a best move but got none\n")
+	}
+	if elapsed > time.Second {
+		t.Fatalf("Expected search to stop after about 200ms but it took %s\n", elapsed)
+	}
+}
+
+func TestLMRFindsTactics(t *testing.T) {
+	for i, ts := range tacticalSuite {
+		ss := SearchSettings{MaxDepth: 5}
+		lmr := AlphaBetaSearch(newTestEngine(ts.Fen), &ss, nil)
+		ss.DisableLMR = true
+		plain := AlphaBetaSearch(newTestEngine(ts.Fen), &ss, nil)
+
+		if lmr.Move != plain.Move || lmr.Move.MiniNotation() != ts.Move {
+			t.Fatalf("Test %d: expected move %s but LMR found %s and plain search %s\n",
+				i, ts.Move, lmr.Move.MiniNotation(), plain.Move.MiniNotation())
+		}
+		if lmr.Nodes > plain.Nodes {
+			t.Fatalf("Test %d: expected LMR to search at most %d nodes but it searched %d\n", i, plain.Nodes, lmr.Nodes)
+		}
+	}
+}
+
+func TestIIDReducesNodes(t *testing.T) {
+	var iidNodes, plainNodes uint64
+	for i, ts := range tacticalSuite {
+		// LMR and futility pruning are disabled to measure the effect of IID alone.
+		ss := SearchSettings{MaxDepth: 5, DisableLMR: true, DisableFutility: true, DisableReverseFutility: true}
+		iid := AlphaBetaSearch(newTestEngine(ts.Fen), &ss, nil)
+		ss.DisableIID = true
+		plain := AlphaBetaSearch(newTestEngine(ts.Fen), &ss, nil)
+
+		if iid.Move != plain.Move || iid.Score != plain.Score {
+			t.Fatalf("Test %d: IID must not change the result: %s (%d) with, %s (%d) without\n",
+				i, iid.Move.MiniNotation(), iid.Score, plain.Move.MiniNotation(), plain.Score)
+		}
+		iidNodes += iid.Nodes
+		plainNodes += plain.Nodes
+	}
+	if iidNodes >= plainNodes {
+		t.Fatalf("Expected fewer nodes with IID, but got %d with and %d without\n", iidNodes, plainNodes)
+	}
+	t.Logf("Nodes with IID: %d, without: %d\n", iidNodes, plainNodes)
+}
+
+func TestHistoryAging(t *testing.T) {
+	engine := newTestEngine("rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1")
+	b := &engine.board
+	history := &engine.history
+	old, recent := parseTestMove(t, "g1f3"), parseTestMove(t, "e2e4")
+
+	// The old move caused a deep cutoff a while ago.
+	history.Add(WHITE, old, 10)
+	history.Age()
+	// The recent move caused a cutoff at a lower depth.
+	history.Add(WHITE, recent, 8)
+
+	if history.Get(WHITE, old) != 50 || history.Get(WHITE, recent) != 64 {
+		t.Fatalf("Expected history scores 50 and 64 but got %d and %d\n", history.Get(WHITE, old), history.Get(WHITE, recent))
+	}
+
+	mlist := MoveList{}
+	b.GenerateAllLegalMoves(&mlist)
+	scores := [max_movelist_size]int{}
+	b.scoreMoves(&mlist, &scores, BitMove(0), [2]BitMove{}, history, nil, &engine.Eval)
+	pickMove(&mlist, &scores, 0)
+	pickMove(&mlist, &scores, 1)
+	if mlist.Moves[0] != recent || mlist.Moves[1] != old {
+		t.Fatalf("Expected e2e4 before g1f3 but got %s and %s\n", mlist.Moves[0].MiniNotation(), mlist.Moves[1].MiniNotation())
+	}
+
+	// Scores are capped below the counter move.
+	for i := 0; i < 1000; i++ {
+		history.Add(WHITE, old, MAX_SEARCH_DEPTH)
+	}
+	if score := history.Get(WHITE, old); score != HISTORY_MAX || score >= ORDER_COUNTER_MOVE {
+		t.Fatalf("Expected history score to be capped at %d but got %d\n", HISTORY_MAX, score)
+	}
+}
+
+func TestCaptureHistoryReducesNodes(t *testing.T) {
+	// Kiwipete has many captures with equal MVV-LVA scores.
+	fen := "r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1"
+	depth := 5
+
+	withCH := IterativeDeepening(newTestEngine(fen), &SearchSettings{MaxDepth: depth}, nil)
+	withoutCH := IterativeDeepening(newTestEngine(fen), &SearchSettings{MaxDepth: depth, DisableCaptureHistory: true}, nil)
+
+	if withCH.Score != withoutCH.Score {
+		t.Fatalf("Capture history must not change the score: %d with, %d without\n", withCH.Score, withoutCH.Score)
+	}
+	if withCH.Nodes >= withoutCH.Nodes {
+		t.Fatalf("Expected fewer nodes with capture history, but got %d with and %d without\n", withCH.Nodes, withoutCH.Nodes)
+	}
+	t.Logf("Nodes with capture history: %d, without: %d\n", withCH.Nodes, withoutCH.Nodes)
+}
+
+func TestCaptureHistoryEnPassant(t *testing.T) {
+	// Both pawn captures have the same MVV-LVA score, but e.p. leaves the target square empty.
+	engine := newTestEngine("4k3/8/2p5/3Pp3/8/8/8/4K3 w - e6 0 1")
+	b := &engine.board
+	ep := parseTestMove(t, "d5e6")
+	if captured := b.capturedPiece(ep); captured != BPAWN {
+		t.Fatalf("Expected e.p. to capture a black pawn but got %d\n", captured)
+	}
+
+	ch := CaptureHistoryTable{}
+	ch.Add(WPAWN, BPAWN, ep.To(), 16)
+	mlist := MoveList{}
+	b.GenerateAllLegalMoves(&mlist)
+	scores := [max_movelist_size]int{}
+	b.scoreMoves(&mlist, &scores, BitMove(0), [2]BitMove{}, nil, &ch, &engine.Eval)
+
+	byMove := map[string]int{}
+	for i := uint32(0); i < mlist.Size; i++ {
+		byMove[mlist.Moves[i].MiniNotation()] = scores[i]
+	}
+	if byMove["d5e6"] <= byMove["d5c6"] {
+		t.Fatalf("Expected the e.p. capture (%d) to score above d5c6 (%d)\n", byMove["d5e6"], byMove["d5c6"])
+	}
+}
+
+func TestSearchParams(t *testing.T) {
+	params := DefaultSearchParams
+	params.LMRFullDepthMoves = 2
+	params.LMRDivisor = 1.5
+	params.IIDMinDepth = 3
+	params.AspirationWindow = 25
+
+	var defaultNodes, tunedNodes uint64
+	for i, ts := range tacticalSuite {
+		ss := SearchSettings{MaxDepth: 6}
+		def := IterativeDeepening(newTestEngine(ts.Fen), &ss, nil)
+		engine := newTestEngine(ts.Fen)
+		engine.Params = params
+		tuned := IterativeDeepening(engine, &ss, nil)
+
+		if def.Move.MiniNotation() != ts.Move || tuned.Move.MiniNotation() != ts.Move {
+			t.Fatalf("Test %d: expected move %s but got %s with default and %s with modified params\n",
+				i, ts.Move, def.Move.MiniNotation(), tuned.Move.MiniNotation())
+		}
+		defaultNodes += def.Nodes
+		tunedNodes += tuned.Nodes
+	}
+	if defaultNodes == tunedNodes {
+		t.Fatalf("Expected modified params to change the node count but both searched %d nodes\n", defaultNodes)
+	}
+	t.Logf("Nodes with default params: %d, with modified params: %d\n", defaultNodes, tunedNodes)
+}
+
+func TestAspirationWindows(t *testing.T) {
+	type set struct {
+		Fen   string
+		Move  string
+		Score int
+	}
+
+	// The mates are only found from depth 4 on, so a window of 1 centipawn around the
+	// score of the previous iteration fails high or low and must be searched again.
+	testset := []set{
+		// Black mates in three, fails high.
+		set{"r1b1kb1r/pppp1ppp/5q2/4n3/3KP3/2N3PN/PPP4P/R1BQ1B1R b kq - 0 1", "f8c5", MATE - 5},
+		// White is mated in two, fails low.
+		set{"r1b1k2r/pppp1ppp/5q2/2b1n3/3KP3/2N3PN/PPP4P/R1BQ1B1R w kq - 1 2", "d4c5", -MATE + 4},
+	}
+
+	for i, ts := range testset {
+		engine := newTestEngine(ts.Fen)
+		engine.Params.AspirationWindow = 1
+		engine.Params.AspirationMinDepth = 2
+		result := IterativeDeepening(engine, &SearchSettings{MaxDepth: 6}, nil)
+
+		if result.Move.MiniNotation() != ts.Move || result.Score != ts.Score {
+			t.Fatalf("Test %d: expected move %s with score %d but got %s with score %d\n",
+				i, ts.Move, ts.Score, result.Move.MiniNotation(), result.Score)
+		}
+	}
+}
+
+func TestFutilityPruning(t *testing.T) {
+	// Promotion with check wins the rook.
+	testset := append(tacticalSuite, tacticalTest{"r5k1/1P6/8/8/8/8/6K1/8 w - - 0 1", "b7a8q"})
+
+	var prunedNodes, plainNodes uint64
+	for i, ts := range testset {
+		ss := SearchSettings{MaxDepth: 5}
+		pruned := IterativeDeepening(newTestEngine(ts.Fen), &ss, nil)
+		ss.DisableFutility, ss.DisableReverseFutility = true, true
+		plain := IterativeDeepening(newTestEngine(ts.Fen), &ss, nil)
+
+		if pruned.Move != plain.Move || pruned.Move.MiniNotation() != ts.Move {
+			t.Fatalf("Test %d: expected move %s but futility pruning found %s and plain search %s\n",
+				i, ts.Move, pruned.Move.MiniNotation(), plain.Move.MiniNotation())
+		}
+		prunedNodes += pruned.Nodes
+		plainNodes += plain.Nodes
+	}
+	if prunedNodes >= plainNodes {
+		t.Fatalf("Expected fewer nodes with futility pruning, but got %d with and %d without\n", prunedNodes, plainNodes)
+	}
+	t.Logf("Nodes with futility pruning: %d, without: %d\n", prunedNodes, plainNodes)
+}
+
+func TestReverseFutilityPruning(t *testing.T) {
+	// A quiet opening position. Replies which hang a piece leave the opponent far
+	// above beta, those nodes are cut by their static evaluation.
+	quiet := "r1bqk2r/pppp1ppp/2n2n2/2b1p3/2B1P3/3P1N2/PPP2PPP/RNBQK2R w KQkq - 0 1"
+	ss := SearchSettings{MaxDepth: 5, DisableFutility: true}
+	pruned := IterativeDeepening(newTestEngine(quiet), &ss, nil)
+	ss.DisableReverseFutility = true
+	plain := IterativeDeepening(newTestEngine(quiet), &ss, nil)
+	if pruned.Nodes >= plain.Nodes {
+		t.Fatalf("Expected fewer nodes with reverse futility pruning, but got %d with and %d without\n",
+			pruned.Nodes, plain.Nodes)
+	}
+	t.Logf("Nodes with reverse futility pruning: %d, without: %d\n", pruned.Nodes, plain.Nodes)
+
+	for i, ts := range tacticalSuite {
+		ss := SearchSettings{MaxDepth: 5, DisableFutility: true}
+		pruned := IterativeDeepening(newTestEngine(ts.Fen), &ss, nil)
+		ss.DisableReverseFutility = true
+		plain := IterativeDeepening(newTestEngine(ts.Fen), &ss, nil)
+		if pruned.Move != plain.Move {
+			t.Fatalf("Test %d: reverse futility pruning found %s but plain search %s\n",
+				i, pruned.Move.MiniNotation(), plain.Move.MiniNotation())
+		}
+	}
+}
+
+func TestSearchRepetitionDraw(t *testing.T) {
+	// White is a queen up, but the knights shuffled back to the starting position.
+	fen := "4k1n1/8/8/8/8/8/8/3QK1N1 w - - 0 1"
+	engine := newTestEngine(fen)
+	ss := SearchSettings{MaxDepth: 3}
+	s := newSearcher(engine, &ss, nil)
+	board := engine.board
+
+	fresh := newSearcher(engine, &ss, nil)
+	if score := fresh.alphaBeta(&board, 3, 0, -INFINITY, INFINITY, BitMove(0), BitMove(0)); score < VALUE_QUEEN/2 {
+		t.Fatalf("Expected white to be winning without repetition but got %d\n", score)
+	}
+
+	for _, m := range []string{"g1f3", "g8f6", "f3g1", "f6g8"} {
+		board.PushKey(&s.keys)
+		board.MakeLegalMove(parseTestMove(t, m))
+	}
+	if score := s.alphaBeta(&board, 3, 4, -INFINITY, INFINITY, BitMove(0), BitMove(0)); score != s.drawScore(4) {
+		t.Fatalf("Expected the repeated position to be a draw but got %d\n", score)
+	}
+
+	// One more ply leads into the subtree, which must leave the stack as it was.
+	board.PushKey(&s.keys)
+	board.MakeLegalMove(parseTestMove(t, "d1d2"))
+	s.alphaBeta(&board, 3, 5, -INFINITY, INFINITY, BitMove(0), BitMove(0))
+	if len(s.keys) != 5 {
+		t.Fatalf("Expected 5 keys after the search but got %d\n", len(s.keys))
+	}
+}
+
+func TestSearchContemptDraws(t *testing.T) {
+	type set struct {
+		Fen         string
+		DrawCounter uint16
+	}
+
+	testset := []set{
+		// Insufficient material.
+		set{"4k3/8/8/8/8/8/8/4K1N1 w - - 0 1", 0},
+		// White is a queen up, but the fifty-move rule applies.
+		set{"4k3/8/8/8/8/8/8/3QK3 w - - 0 1", FIFTY_MOVE_LIMIT},
+	}
+
+	for i, ts := range testset {
+		engine := newTestEngine(ts.Fen)
+		engine.Params.Contempt = 30
+		board := engine.board
+		board.DrawCounter = ts.DrawCounter
+		s := newSearcher(engine, &SearchSettings{MaxDepth: 2}, nil)
+
+		for _, ply := range []int{1, 2} {
+			if score := s.alphaBeta(&board, 2, ply, -INFINITY, INFINITY, BitMove(0), BitMove(0)); score != s.drawScore(ply) {
+				t.Fatalf("Test %d: expected draw score %d at ply %d but got %d\n", i, s.drawScore(ply), ply, score)
+			}
+		}
+	}
+}
+
+func TestLMRTableCache(t *testing.T) {
+	engine := newTestEngine("rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1")
+	table := engine.lmrTable()
+	if engine.lmrTable() != table {
+		t.Fatalf("Expected the LMR table to be reused\n")
+	}
+
+	engine.Params.Contempt = 10
+	if engine.lmrTable() != table {
+		t.Fatalf("Expected the LMR table to be reused if other parameters change\n")
+	}
+
+	engine.Params.LMRDivisor *= 2
+	changed := engine.lmrTable()
+	if changed == table || *changed != *engine.Params.lmrTable() {
+		t.Fatalf("Expected the LMR table to be rebuilt for new LMR parameters\n")
+	}
+}
+
+func TestSingularExtensions(t *testing.T) {
+	for i, ts := range tacticalSuite {
+		ss := SearchSettings{MaxDepth: 5}
+		singular := IterativeDeepening(newTestEngine(ts.Fen), &ss, nil)
+		ss.DisableSingular = true
+		plain := IterativeDeepening(newTestEngine(ts.Fen), &ss, nil)
+
+		if singular.Move != plain.Move || singular.Move.MiniNotation() != ts.Move {
+			t.Fatalf("Test %d: expected move %s but singular extensions found %s and plain search %s\n",
+				i, ts.Move, singular.Move.MiniNotation(), plain.Move.MiniNotation())
+		}
+	}
+
+	// Black mates in four with a queen sacrifice. The forcing replies are extended,
+	// so the mate is found one iteration earlier.
+	fen := "6k1/1p3pp1/p1b1p2p/q3r1b1/P7/1P5P/1NQ1RPP1/1B4K1 b - - 0 1"
+	ss := SearchSettings{MaxDepth: 6}
+	singular := IterativeDeepening(newTestEngine(fen), &ss, nil)
+	ss.DisableSingular = true
+	plain := IterativeDeepening(newTestEngine(fen), &ss, nil)
+	if singular.Move.MiniNotation() != "a5e1" || !IsMateScore(singular.Score) {
+		t.Fatalf("Expected mate with a5e1 but got %s (%d)\n", singular.Move.MiniNotation(), singular.Score)
+	}
+	if IsMateScore(plain.Score) {
+		t.Fatalf("Expected no mate without singular extensions at depth %d but got %s (%d)\n",
+			ss.MaxDepth, plain.Move.MiniNotation(), plain.Score)
+	}
+}
+
+func TestSearchStats(t *testing.T) {
+	fen := "r1bqk2r/pppp1ppp/2n2n2/2b1p3/2B1P3/3P1N2/PPP2PPP/RNBQK2R w KQkq - 0 1"
+
+	// Iterative deepening visits all shallower depths again, so the nodes grow with the depth.
+	prev := uint64(0)
+	for depth := 1; depth <= 5; depth++ {
+		sr := IterativeDeepening(newTestEngine(fen), &SearchSettings{MaxDepth: depth}, nil)
+		if sr.Nodes <= prev {
+			t.Fatalf("Expected more than %d nodes at depth %d but got %d\n", prev, depth, sr.Nodes)
+		}
+		if sr.Depth != depth || sr.SelDepth < depth {
+			t.Fatalf("Expected depth %d and a higher seldepth but got %d and %d\n", depth, sr.Depth, sr.SelDepth)
+		}
+		if sr.Time <= 0 {
+			t.Fatalf("Expected the elapsed time at depth %d\n", depth)
+		}
+		prev = sr.Nodes
+	}
+
+	sr := AlphaBetaSearch(newTestEngine(fen), &SearchSettings{MaxDepth: 3}, nil)
+	if sr.Nodes == 0 || sr.Depth != 3 || sr.SelDepth < 3 || sr.Time <= 0 {
+		t.Fatalf("Expected the statistics of the search but got %+v\n", sr)
+	}
+}
+
+func TestMatePlies(t *testing.T) {
+	for plies := -2 * MAX_SEARCH_DEPTH; plies <= 2*MAX_SEARCH_DEPTH; plies++ {
+		score := MatePliesToScore(plies)
+		if !IsMateScore(score) {
+			t.Fatalf("Expected %d plies to be a mate score but got %d\n", plies, score)
+		}
+		decoded, ok := ScoreToMatePlies(score)
+		if !ok || decoded != plies {
+			t.Fatalf("Expected %d plies after the round trip but got %d (%v)\n", plies, decoded, ok)
+		}
+
+		// UCI counts moves instead of plies, negative if the engine gets mated.
+		moves := (plies + 1) / 2
+		if plies <= 0 {
+			moves = plies / 2
+		}
+		if expected := fmt.Sprintf("mate %d", moves); uciScore(score) != expected {
+			t.Fatalf("Expected %d plies to be formatted as %q but got %q\n", plies, expected, uciScore(score))
+		}
+	}
+
+	for _, score := range []int{0, 250, -MATE_THRESHOLD + 1, MATE_THRESHOLD - 1} {
+		if _, ok := ScoreToMatePlies(score); ok {
+			t.Fatalf("Expected score %d not to be a mate score\n", score)
+		}
+	}
+	if MatePliesToScore(0) != -MATE || MatePliesToScore(1) != MATE-1 {
+		t.Fatalf("Expected checkmate to score %d and mate in one ply %d\n", -MATE, MATE-1)
+	}
+}
@@ -0,0 +1,152 @@
+package chesskimo
+
+import (
+	"fmt"
+)
+
+// MoveQuality classifies a move by the centipawns it loses compared to the best move.
+type MoveQuality int
+
+const (
+	MOVE_BEST MoveQuality = iota
+	MOVE_GOOD
+	MOVE_INACCURACY
+	MOVE_MISTAKE
+	MOVE_BLUNDER
+)
+
+const (
+	// Minimum centipawn loss for each move quality.
+	LOSS_INACCURACY = 50
+	LOSS_MISTAKE    = 100
+	LOSS_BLUNDER    = 300
+
+	// CLASSIFY_DEPTH is the search depth used to classify moves.
+	CLASSIFY_DEPTH = 4
+	// SCORE_MOVE_DEPTH is the search depth used to score single moves.
+	SCORE_MOVE_DEPTH = 3
+)
+
+func (q MoveQuality) String() string {
+	switch q {
+	case MOVE_BEST:
+		return "best"
+	case MOVE_GOOD:
+		return "good"
+	case MOVE_INACCURACY:
+		return "inaccuracy"
+	case MOVE_MISTAKE:
+		return "mistake"
+	default:
+		return "blunder"
+	}
+}
+
+// ClassifyMove rates the legal move 'm' in the position on board 'b'. The position is searched
+// with the engine's search function to find the best score, then 'm' is made and the reply
+// is searched. The difference of both scores is the centipawn loss of 'm'.
+// The engine's own board is left unchanged.
+func (e *Engine) ClassifyMove(b *Board, m BitMove) MoveQuality {
+	saved := e.board
+	defer func() { e.board = saved }()
+
+	e.board = *b
+	best := e.search(e, &SearchSettings{MaxDepth: CLASSIFY_DEPTH}, nil)
+	if best.Move == m {
+		return MOVE_BEST
+	}
+
+	e.board.MakeLegalMove(m)
+	reply := e.search(e, &SearchSettings{MaxDepth: CLASSIFY_DEPTH - 1}, nil)
+	played := -reply.Score
+
+	return classifyLoss(best.Score - played)
+}
+
+// ScoreMove returns the score of the legal move 'm' in the position on board 'b' in centipawns
+// from the point of view of the side making the move. The reply to 'm' is searched with a
+// shallow depth, so all legal moves can be scored quickly, e.g. to color-code them in a UI.
+// The engine's own board is left unchanged.
+func (e *Engine) ScoreMove(b *Board, m BitMove) int {
+	saved := e.board
+	defer func() { e.board = saved }()
+
+	e.board = *b
+	e.board.MakeLegalMove(m)
+	reply := e.search(e, &SearchSettings{MaxDepth: SCORE_MOVE_DEPTH - 1}, nil)
+
+	// Mate distances are counted from the position before 'm'.
+	score := -reply.Score
+	if plies, ok := ScoreToMatePlies(score); ok {
+		// A mated reply scores -MATE, which turns into MATE with zero plies.
+		if score > 0 {
+			return MatePliesToScore(plies + 1)
+		}
+		return MatePliesToScore(plies - 1)
+	}
+	return score
+}
+
+func classifyLoss(loss int) MoveQuality {
+	switch {
+	case loss <= 0:
+		return MOVE_BEST
+	case loss < LOSS_INACCURACY:
+		return MOVE_GOOD
+	case loss < LOSS_MISTAKE:
+		return MOVE_INACCURACY
+	case loss < LOSS_BLUNDER:
+		return MOVE_MISTAKE
+	default:
+		return MOVE_BLUNDER
+	}
+}
+
+// AnalyzeMultiPV searches the engine's position for the best 'n' moves. After every
+// search the best move found is excluded from the next root search. The results are
+// ordered from best to worst and every result is written as UCI info line to the
+// engine's output.
+func (e *Engine) AnalyzeMultiPV(ss SearchSettings, n int) []SearchResult {
+	results := []SearchResult{}
+	excluded := append([]BitMove{}, ss.ExcludeMoves...)
+
+	for k := 1; k <= n; k++ {
+		ss.ExcludeMoves = excluded
+		sr := e.search(e, &ss, nil)
+		if sr.Move == 0 {
+			// No moves are left.
+			break
+		}
+		results = append(results, sr)
+		excluded = append(excluded, sr.Move)
+
+		fmt.Fprintf(e.Output, "info multipv %d depth %d seldepth %d score %s nodes %d time %d pv %s\n",
+			k, sr.Depth, sr.SelDepth, uciScore(sr.Score), sr.Nodes, sr.Time.Milliseconds(), pvString(sr.PV))
+	}
+
+	return results
+}
+
+// uciScore formats a score for UCI info lines, either in centipawns
+// or as moves until mate, which are negative if the engine is getting mated.
+func uciScore(score int) string {
+	if plies, ok := ScoreToMatePlies(score); ok {
+		if plies > 0 {
+			return fmt.Sprintf("mate %d", (plies+1)/2)
+		}
+		return fmt.Sprintf("mate %d", plies/2)
+	}
+	return fmt.Sprintf("cp %d", score)
+}
+
+// pvString returns the moves of a principal variation in coordinate notation.
+func pvString(pv []BitMove) string {
+	str := ""
+	for i, m := range pv {
+		if i > 0 {
+			str += " "
+		}
+		str += m.MiniNotation()
+	}
+	return str
+}
@@ -0,0 +1,137 @@
+package chesskimo
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestClassifyMove(t *testing.T) {
+	type set struct {
+		Move    string
+		Quality MoveQuality
+	}
+
+	// The black queen on g5 hangs to the bishop on c1.
+	fen := "rnb1kbnr/pppp1ppp/8/4p1q1/3P4/8/PPP1PPPP/RNBQKBNR w KQkq - 0 3"
+	testset := []set{
+		set{"c1g5", MOVE_BEST},
+		// Missing the queen capture.
+		set{"a2a3", MOVE_BLUNDER},
+	}
+
+	engine := newTestEngine(fen)
+	board := engine.board
+	for i, ts := range testset {
+		quality := engine.ClassifyMove(&board, parseTestMove(t, ts.Move))
+		if quality != ts.Quality {
+			t.Fatalf("Test %d: expected move %s to be classified as %s but got %s\n", i, ts.Move, ts.Quality, quality)
+		}
+		if engine.board != board {
+			t.Fatalf("Test %d: the engine board was changed\n", i)
+		}
+	}
+}
+
+func TestScoreMove(t *testing.T) {
+	testset := []string{
+		// The black queen on g5 hangs to the bishop on c1.
+		"rnb1kbnr/pppp1ppp/8/4p1q1/3P4/8/PPP1PPPP/RNBQKBNR w KQkq - 0 3",
+		// Mate in one.
+		"r1bqkb1r/pppp1ppp/2n2n2/4p2Q/2B1P3/8/PPPP1PPP/RNB1K1NR w KQkq - 4 4",
+	}
+
+	for i, fen := range testset {
+		engine := newTestEngine(fen)
+		board := engine.board
+		best := engine.search(engine, &SearchSettings{MaxDepth: SCORE_MOVE_DEPTH}, nil)
+
+		mlist := MoveList{}
+		board.GenerateAllLegalMoves(&mlist)
+		max := -INFINITY
+		for k := uint32(0); k < mlist.Size; k++ {
+			if score := engine.ScoreMove(&board, mlist.Moves[k]); score > max {
+				max = score
+			}
+		}
+		if engine.board != board {
+			t.Fatalf("Test %d: the engine board was changed\n", i)
+		}
+
+		if score := engine.ScoreMove(&board, best.Move); score != max || score != best.Score {
+			t.Fatalf("Test %d: expected best move %s to score the maximum %d (search: %d) but got %d\n",
+				i, best.Move.MiniNotation(), max, best.Score, score)
+		}
+	}
+}
+
+func TestClassifyLoss(t *testing.T) {
+	testset := map[int]MoveQuality{
+		-10:  MOVE_BEST,
+		0:    MOVE_BEST,
+		20:   MOVE_GOOD,
+		50:   MOVE_INACCURACY,
+		150:  MOVE_MISTAKE,
+		300:  MOVE_BLUNDER,
+		9000: MOVE_BLUNDER,
+	}
+	for loss, quality := range testset {
+		if classifyLoss(loss) != quality {
+			t.Fatalf("Expected loss %d to be classified as %s but got %s\n", loss, quality, classifyLoss(loss))
+		}
+	}
+}
+
+func TestAnalyzeMultiPV(t *testing.T) {
+	// Both knights can take the hanging queen.
+	engine := newTestEngine("4k3/8/8/3q4/8/2N1N3/8/4K3 w - - 0 1")
+
+	out := bytes.Buffer{}
+	engine.Output = &out
+	results := engine.AnalyzeMultiPV(SearchSettings{MaxDepth: 3}, 3)
+
+	if len(results) != 3 {
+		t.Fatalf("Expected 3 results but got %d\n", len(results))
+	}
+	captures := map[string]bool{"c3d5": true, "e3d5": true}
+	for k := 0; k < 2; k++ {
+		move := results[k].Move.MiniNotation()
+		if !captures[move] {
+			t.Fatalf("Expected a queen capture as line %d but got %s\n", k+1, move)
+		}
+		delete(captures, move)
+		if results[k].Score < VALUE_QUEEN-VALUE_KNIGHT {
+			t.Fatalf("Expected line %d to win the queen but it scores %d\n", k+1, results[k].Score)
+		}
+		if len(results[k].PV) == 0 || results[k].PV[0] != results[k].Move {
+			t.Fatalf("Expected principal variation of line %d to start with %s\n", k+1, move)
+		}
+	}
+	if results[2].Score > results[1].Score-VALUE_ROOK {
+		t.Fatalf("Expected the third line (%d) to be much worse than the second (%d)\n", results[2].Score, results[1].Score)
+	}
+
+	for k := 1; k <= 3; k++ {
+		line := fmt.Sprintf("info multipv %d depth 3 seldepth %d score cp %d nodes %d", k, results[k-1].SelDepth, results[k-1].Score, results[k-1].Nodes)
+		if !strings.Contains(out.String(), line) {
+			t.Fatalf("Expected output line %q in\n%s\n", line, out.String())
+		}
+	}
+}
+
+func TestUciScore(t *testing.T) {
+	testset := map[int]string{
+		35:        "cp 35",
+		-120:      "cp -120",
+		MATE - 1:  "mate 1",
+		MATE - 5:  "mate 3",
+		-MATE + 2: "mate -1",
+		-MATE + 4: "mate -2",
+	}
+	for score, str := range testset {
+		if uciScore(score) != str {
+			t.Fatalf("Expected score %d to be formatted as %q but got %q\n", score, str, uciScore(score))
+		}
+	}
+}
@@ -1,5 +1,9 @@
 package chesskimo
 
+import (
+	"math/bits"
+)
+
 // Piece, Color, Square and Info are basically the same type (aliases)
 // but for clear declarations we use different type names.
 type Piece uint8
@@ -185,6 +189,16 @@ func (p Piece) HasColor(color Color) bool {
 //	return (p & piece) != 0
 //}
 
+// TypeIndex maps the piece type to an index between 0 and 6:
+// none, pawn, knight, bishop, rook, queen, king. The color is ignored.
+func (p Piece) TypeIndex() int {
+	ptype := p & PIECE_MASK
+	if ptype == NONE {
+		return 0
+	}
+	return bits.TrailingZeros8(uint8(ptype))
+}
+
 func (p Piece) Overlaps(pieces Piece) bool {
 	return (p & pieces) != 0
 }
@@ -202,8 +216,8 @@ func (sq Square) OnBoard() bool {
 }
 
 func (sq Square) SquareColor() Color {
-	// Dark squares have an even index, light squares have an odd one.
-	return sq & 1
+	// Dark squares have an even sum of rank and file, light squares have an odd one.
+	return (sq.Rank() + sq.File()) & 1
 }
 
 func (sq Square) Rank() Square {
@@ -228,10 +242,34 @@ func (sq Square) ToInfoIndex() Square {
 	return sq + 8
 }
 
+// Add returns the square reached by stepping in direction 'dir' from 'sq', e.g. UP or
+// KNIGHT_DIRS[i]. The result may be off the board, which must be tested with OnBoard.
+// Steps over the left or right edge of the board are also detected by OnBoard.
+func (sq Square) Add(dir int8) Square {
+	return Square(int8(sq) + dir)
+}
+
 func (sq Square) Diff(sq2 Square) Square {
 	return Square(0x77 + (int8(sq) - int8(sq2)))
 }
 
+// Distance returns the Chebyshev distance between two squares, which is
+// the number of king steps needed to get from one to the other.
+func (sq Square) Distance(sq2 Square) int {
+	rankDist := int(sq.Rank()) - int(sq2.Rank())
+	if rankDist < 0 {
+		rankDist = -rankDist
+	}
+	fileDist := int(sq.File()) - int(sq2.File())
+	if fileDist < 0 {
+		fileDist = -fileDist
+	}
+	if rankDist > fileDist {
+		return rankDist
+	}
+	return fileDist
+}
+
 func (sq Square) CreatesEnPassent(sq2 Square) bool {
 	fromRank, toRank := sq.Rank(), sq2.Rank()
 
@@ -243,6 +281,15 @@ func (sq Square) CreatesEnPassent(sq2 Square) bool {
 	return (fromRank - toRank) == 2
 }
 
+// AllSquares returns the 64 squares of the board as 0x88 indexes from a1 to h8.
+func AllSquares() []Square {
+	squares := make([]Square, 64)
+	for i := range squares {
+		squares[i] = Square(i).To0x88()
+	}
+	return squares
+}
+
 func (sq Square) To0x88() Square {
 	return sq + (sq & ^Square(7))
 }
@@ -0,0 +1,64 @@
+package chesskimo
+
+import (
+	"testing"
+)
+
+func TestSquareAdd(t *testing.T) {
+	type set struct {
+		From    Square
+		Dir     int8
+		To      Square
+		OnBoard bool
+	}
+
+	testset := []set{
+		set{0x00, UP, 0x10, true},
+		set{0x34, UP_RIGHT, 0x45, true},
+		set{0x34, KNIGHT_DIRS[0], 0x53, true},
+		// Over the right and left edge.
+		set{0x07, RIGHT, 0x08, false},
+		set{0x40, LEFT, 0x3F, false},
+		set{0x17, UP_RIGHT, 0x28, false},
+		// Over the bottom edge, which wraps around.
+		set{0x00, LEFT, 0xFF, false},
+		set{0x03, DOWN, 0xF3, false},
+		set{0x11, DOWN + DOWN_LEFT, 0xF0, false},
+		// Over the top edge.
+		set{0x74, UP, 0x84, false},
+		set{0x77, UP_RIGHT, 0x88, false},
+	}
+
+	for i, ts := range testset {
+		to := ts.From.Add(ts.Dir)
+		if to != ts.To || to.OnBoard() != ts.OnBoard {
+			t.Fatalf("Test %d: expected %#x (on board: %v) but got %#x (on board: %v)\n", i, ts.To, ts.OnBoard, to, to.OnBoard())
+		}
+	}
+}
+
+func TestAllSquares(t *testing.T) {
+	squares := AllSquares()
+	if len(squares) != 64 {
+		t.Fatalf("Expected 64 squares but got %d\n", len(squares))
+	}
+
+	seen := map[Square]bool{}
+	for _, sq := range squares {
+		if !sq.OnBoard() {
+			t.Fatalf("Square %#x is not on the board\n", sq)
+		}
+		if seen[sq] {
+			t.Fatalf("Square %#x was returned twice\n", sq)
+		}
+		seen[sq] = true
+	}
+	for i := 0; i < 128; i++ {
+		if Square(i).OnBoard() != seen[Square(i)] {
+			t.Fatalf("Square %#x is on the board but was not returned\n", i)
+		}
+	}
+	if squares[0] != 0x00 || squares[63] != 0x77 {
+		t.Fatalf("Expected squares from a1 to h8 but got %#x to %#x\n", squares[0], squares[63])
+	}
+}
@@ -0,0 +1,81 @@
+package chesskimo
+
+import (
+	"math/bits"
+)
+
+// PopCount returns the number of set bits in a 64 bit set.
+func PopCount(bb uint64) int {
+	return bits.OnesCount64(bb)
+}
+
+// LSB returns the index of the least significant set bit in a 64 bit set,
+// which is the 8x8 index of the lowest square. If no bit is set 64 is returned.
+func LSB(bb uint64) int {
+	return bits.TrailingZeros64(bb)
+}
+
+// AttackBitboard returns all squares attacked by pieces of 'color' as 64 bit set.
+// Bit n corresponds to the MinBoard index n (a1=0 .. h8=63), see Lookup0x88.
+func (b *Board) AttackBitboard(color Color) uint64 {
+	bb := uint64(0)
+
+	// Pawns.
+	for i := uint8(0); i < b.Pawns[color].Size; i++ {
+		from := b.Pawns[color].Pieces[i]
+		for d := 0; d < 2; d++ {
+			to := from.Add(PAWN_CAPTURE_DIRS[color][d])
+			if to.OnBoard() {
+				bb |= 1 << to.To8x8()
+			}
+		}
+	}
+
+	// Knights.
+	for i := uint8(0); i < b.Knights[color].Size; i++ {
+		from := b.Knights[color].Pieces[i]
+		for d := 0; d < 8; d++ {
+			to := from.Add(KNIGHT_DIRS[d])
+			if to.OnBoard() {
+				bb |= 1 << to.To8x8()
+			}
+		}
+	}
+
+	// King.
+	if from := b.Kings[color]; from.OnBoard() {
+		for d := 0; d < 8; d++ {
+			to := from.Add(KING_DIRS[d])
+			if to.OnBoard() {
+				bb |= 1 << to.To8x8()
+			}
+		}
+	}
+
+	// Sliders.
+	bb |= b.slidingAttacks(&b.Bishops[color], DIAGONAL_DIRS)
+	bb |= b.slidingAttacks(&b.Rooks[color], ORTHOGONAL_DIRS)
+	bb |= b.slidingAttacks(&b.Queens[color], DIAGONAL_DIRS)
+	bb |= b.slidingAttacks(&b.Queens[color], ORTHOGONAL_DIRS)
+
+	return bb
+}
+
+// slidingAttacks returns all squares attacked by the sliders in the piece list
+// along the given directions. Rays stop at the first occupied square.
+func (b *Board) slidingAttacks(plist *PieceList, dirs [4]int8) uint64 {
+	bb := uint64(0)
+	for i := uint8(0); i < plist.Size; i++ {
+		from := plist.Pieces[i]
+		for d := 0; d < 4; d++ {
+			dir := dirs[d]
+			for to := from.Add(dir); to.OnBoard(); to = to.Add(dir) {
+				bb |= 1 << to.To8x8()
+				if !b.Squares[to].IsEmpty() {
+					break
+				}
+			}
+		}
+	}
+	return bb
+}
@@ -0,0 +1,41 @@
+package chesskimo
+
+import (
+	"testing"
+)
+
+func TestAttackBitboard(t *testing.T) {
+	fens := []string{
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+		"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1",
+		"r3k2r/Pppp1ppp/1b3nbN/nP6/BBP1P3/q4N2/Pp1P2PP/R2Q1RK1 w kq - 0 1",
+		"8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 0",
+	}
+
+	board := NewBoard()
+	for _, fen := range fens {
+		if err := board.SetFEN(fen); err != nil {
+			t.Fatalf(err.Error())
+		}
+
+		for color := BLACK; color <= WHITE; color++ {
+			bb := board.AttackBitboard(color)
+			for idx, sq := range Lookup0x88 {
+				attacked := bb&(1<<uint(idx)) != 0
+				// IsSquareAttacked tests for attacks by the opponent of the given color.
+				if attacked != board.IsSquareAttacked(sq, OTB, color.Flip()) {
+					t.Fatalf("Square %s attacked by color %d should be %v for FEN %s\n", PrintBoardIndex[sq], color, !attacked, fen)
+				}
+			}
+		}
+	}
+}
+
+func TestBitHelpers(t *testing.T) {
+	if PopCount(0) != 0 || PopCount(0xFF00) != 8 || PopCount(1<<63|1) != 2 {
+		t.Fatalf("PopCount returned wrong results\n")
+	}
+	if LSB(0) != 64 || LSB(1) != 0 || LSB(0xFF00) != 8 || LSB(1<<63) != 63 {
+		t.Fatalf("LSB returned wrong results\n")
+	}
+}
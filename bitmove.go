@@ -1,15 +1,21 @@
 package chesskimo
 
+import (
+	"strings"
+)
+
 // BitMove is structured as follows:
 //
-// MSB                      LSB
-// ... |17 (4 bits) 14||13 (7 bits) 7||6 (7 bits) 0|
-// ... |-----prom-----||-----to------||----from----|
+// MSB                                          LSB
+// ... |22 (5 bits) 18||17 (4 bits) 14||13 (7 bits) 7||6 (7 bits) 0|
+// ... |-----drop-----||-----prom-----||-----to------||----from----|
 // A few bits are 'wasted' here. Squares could be encoded with 6 bits and
 // the promotion piece type could be encoded by two bits. Doing it this way
 // however allows for easier usage and better performance in this engine.
 // The from and to squares are actual 0x88 indexes (0-127) and the promotion
 // field contains 1 bit for each possible type: knight, bishop, rook, queen.
+// The drop field is only used in Crazyhouse and contains 1 bit for each type which
+// can be dropped: pawn, knight, bishop, rook, queen. A drop has equal from and to squares.
 //
 // TODO this type should be enhanced by an additional value field in the future
 // which allows better move ordering in the move search.
@@ -22,12 +28,23 @@ const (
 	MOVE_TO_MASK         = 0x00003F80
 	MOVE_PROMOTION_SHIFT = -2 + 7 + 7 + 0 // We skip 2 bits in the shift because the 2 lowest piece bits are 0 and ignored.
 	MOVE_PROMOTION_MASK  = 0x0003C000
+	MOVE_DROP_SHIFT      = -1 + 4 + 7 + 7 + 0 // We skip 1 bit in the shift because the lowest piece bit is 0 and ignored.
+	MOVE_DROP_MASK       = 0x007C0000
 )
 
+// NewBitMove creates a move from the given squares and promotion piece type. The promotion
+// piece may be given with or without color. Only the bare type is stored, because the color
+// bit lies outside of the promotion mask.
 func NewBitMove(from, to Square, promo Piece) BitMove {
 	return (BitMove(promo)<<MOVE_PROMOTION_SHIFT)&MOVE_PROMOTION_MASK | BitMove(to)<<MOVE_TO_SHIFT | BitMove(from)
 }
 
+// NewDropMove creates a Crazyhouse move, which puts a piece of type 'piece' from the hand
+// on the empty square 'to'. The piece may be given with or without color.
+func NewDropMove(piece Piece, to Square) BitMove {
+	return (BitMove(piece)<<MOVE_DROP_SHIFT)&MOVE_DROP_MASK | BitMove(to)<<MOVE_TO_SHIFT | BitMove(to)
+}
+
 func (m *BitMove) SetFeature(mask, shift BitMove, bits Square) {
 	*m &= ^mask                  // Erase bits in the mask's region.
 	bits <<= shift               // Move bits to the right place.
@@ -49,22 +66,85 @@ func (m BitMove) PromotedPiece() Piece {
 	return Piece(p)
 }
 
-func (m BitMove) All() (Square, Square, Piece) {
+// DroppedPiece returns the bare type of the dropped piece, or NONE if the move is no drop.
+func (m BitMove) DroppedPiece() Piece {
+	return Piece((m & MOVE_DROP_MASK) >> MOVE_DROP_SHIFT)
+}
+
+// IsDrop tests if the move puts a piece from the hand on the board.
+func (m BitMove) IsDrop() bool {
+	return m&MOVE_DROP_MASK != 0
+}
+
+// All returns the from and to squares and the bare promotion piece type (without color),
+// which is NONE if the move is not a promotion.
+func (m BitMove) All() (from, to Square, promo Piece) {
 	return m.From(), m.To(), m.PromotedPiece()
 }
 
 func (m BitMove) MiniNotation() string {
+	if m.IsDrop() {
+		// Drops are written like P@e4, the piece is always upper case.
+		return PrintMap[m.DroppedPiece()|WHITE] + "@" + PrintBoardIndex[m.To()]
+	}
 	from := m.From()
 	to := m.To()
 	promo := m.PromotedPiece()
 	str := PrintBoardIndex[from] + PrintBoardIndex[to]
 	if promo > 0 {
-		str += PrintMap[promo]
+		// Promotions are always printed in lower case.
+		str += PrintMap[promo|BLACK]
 	}
 
 	return str
 }
 
+// ParseMiniNotation parses a move in coordinate notation (e.g. e2e4, e7e8q) as used by UCI.
+// Crazyhouse drops are written like P@e4. The move is not tested for legality.
+func ParseMiniNotation(move string) (BitMove, error) {
+	if len(move) < 4 || len(move) > 5 {
+		return BitMove(0), ErrInvalidMoveNotation
+	}
+
+	if move[1] == '@' {
+		piece, ok := FENMap[rune(strings.ToUpper(move[0:1])[0])]
+		to, err := parseFENSquare(move[2:])
+		if !ok || piece == EMPTY || piece&PIECE_MASK == KING || len(move) != 4 || err != nil {
+			return BitMove(0), ErrInvalidMoveNotation
+		}
+		return NewDropMove(piece, to.To0x88()), nil
+	}
+
+	from, err := parseFENSquare(move[0:2])
+	if err != nil {
+		return BitMove(0), ErrInvalidMoveNotation
+	}
+
+	to, err := parseFENSquare(move[2:4])
+	if err != nil {
+		return BitMove(0), ErrInvalidMoveNotation
+	}
+
+	promo := NONE
+	if len(move) == 5 {
+		switch strings.ToLower(move[4:5]) {
+		case "q":
+			promo = QUEEN
+		case "r":
+			promo = ROOK
+		case "b":
+			promo = BISHOP
+		case "n":
+			promo = KNIGHT
+		default:
+			// Impossible promotion.
+			return BitMove(0), ErrInvalidMoveNotation
+		}
+	}
+
+	return NewBitMove(from.To0x88(), to.To0x88(), promo), nil
+}
+
 //func (m *BitMove) String() string {
 //	// TODO - this should be somewhat performant in the end
 //	// byte buffers?!
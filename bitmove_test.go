@@ -0,0 +1,71 @@
+package chesskimo
+
+import (
+	"testing"
+)
+
+func TestPromotionRoundTrip(t *testing.T) {
+	fens := []string{
+		// White promotes by push and by capture.
+		"r2qkb1r/pp2p1Pp/1np1bn2/3p4/3P4/8/PPP2PP1/RNBQKBNR w KQkq - 0 9",
+		// Black promotes by push and by capture.
+		"r2qkbnr/pp2p1Pp/1np1b3/4P3/1PpP1P2/8/P6p/RNBQKBN1 b Qkq b3 0 12",
+	}
+
+	symbols := map[Piece]byte{KNIGHT: 'n', BISHOP: 'b', ROOK: 'r', QUEEN: 'q'}
+
+	board := NewBoard()
+	mlist := MoveList{}
+	for _, fen := range fens {
+		if err := board.SetFEN(fen); err != nil {
+			t.Fatalf(err.Error())
+		}
+		mlist.Clear()
+		board.GenerateAllLegalMoves(&mlist)
+
+		promotions := 0
+		for i := uint32(0); i < mlist.Size; i++ {
+			move := mlist.Moves[i]
+			_, _, promo := move.All()
+			if promo == NONE {
+				continue
+			}
+			promotions++
+
+			if promo&COLOR_ONLY_MASK != 0 || promo < KNIGHT || promo > QUEEN {
+				t.Fatalf("Expected bare promotion type but got %d for move %s\n", promo, move.MiniNotation())
+			}
+
+			str := move.MiniNotation()
+			if len(str) != 5 || str[4] != symbols[promo] {
+				t.Fatalf("Unexpected notation %s for promotion to %d\n", str, promo)
+			}
+
+			parsed, err := ParseMiniNotation(str)
+			if err != nil {
+				t.Fatalf("Cannot parse move %s: %s\n", str, err.Error())
+			}
+			if parsed != move {
+				t.Fatalf("Parsed move %s (%d) differs from generated move (%d)\n", str, parsed, move)
+			}
+		}
+
+		if promotions == 0 {
+			t.Fatalf("Expected promotion moves for FEN %s\n", fen)
+		}
+	}
+}
+
+func TestNewBitMoveStripsColor(t *testing.T) {
+	for _, promo := range []Piece{KNIGHT, BISHOP, ROOK, QUEEN} {
+		bare := NewBitMove(0x64, 0x74, promo)
+		white := NewBitMove(0x64, 0x74, promo|WHITE)
+		if bare != white {
+			t.Fatalf("Expected equal moves for promotion to %d with and without color\n", promo)
+		}
+		from, to, p := white.All()
+		if from != 0x64 || to != 0x74 || p != promo {
+			t.Fatalf("Expected e7e8 with promotion %d but got %s%s %d\n", promo, PrintBoardIndex[from], PrintBoardIndex[to], p)
+		}
+	}
+}
@@ -1,7 +1,9 @@
 package chesskimo
 
 import (
+	"errors"
 	"fmt"
+	"sort"
 	"strconv"
 )
 
@@ -27,6 +29,7 @@ type Board struct {
 	CastleLong  [2]bool
 	MoveNumber  uint16
 	DrawCounter uint16
+	Hash        uint64
 	CheckInfo   Square
 	EpSquare    Square
 	Player      Color
@@ -37,8 +40,43 @@ type Board struct {
 	Bishops     [2]PieceList
 	Knights     [2]PieceList
 	Pawns       [2]PieceList
+	// Crazyhouse enables the variant where captured pieces go to the hand of the
+	// capturing side and can be dropped later, see GenerateDropMoves.
+	Crazyhouse bool
+	// Hand holds the number of pieces of every type, indexed by Piece.TypeIndex,
+	// which a color may drop. Captured promoted pieces are not demoted to pawns yet.
+	Hand [2][7]int
+	// infoDirty is set if any square of the info board was marked since it was cleared.
+	infoDirty bool
 }
 
+const (
+	// MAX_PERFT_DEPTH is the highest depth accepted by PerftSafe. Beyond it the
+	// node count of typical positions does not fit into an uint64 anymore.
+	MAX_PERFT_DEPTH = 13
+)
+
+var (
+	// ErrPerftDepthInvalid signals a perft depth which is negative or too high.
+	ErrPerftDepthInvalid = errors.New("Perft depth is invalid")
+	// ErrSquareOffBoard signals a square which is not on the board.
+	ErrSquareOffBoard = errors.New("Square is off the board")
+	// ErrPieceInvalid signals a value which is not a piece of any color.
+	ErrPieceInvalid = errors.New("Piece is invalid")
+	// ErrSecondKing signals an attempt to place a second king of one color.
+	ErrSecondKing = errors.New("Only one king per color is allowed")
+	// ErrKingMissing signals a position without a king of one color.
+	ErrKingMissing = errors.New("Each color needs a king")
+	// ErrColorInvalid signals a value which is neither black nor white.
+	ErrColorInvalid = errors.New("Color is invalid")
+	// ErrPawnOnBackRank signals an attempt to place a pawn on the first or last rank.
+	ErrPawnOnBackRank = errors.New("Pawns are not allowed on the first or last rank")
+	// ErrKingCapture signals a move which would capture a king.
+	ErrKingCapture = errors.New("Move would capture a king")
+	// ErrNoPieceToMove signals a move which does not start on a piece of the side to move.
+	ErrNoPieceToMove = errors.New("No piece of the side to move on the from square")
+)
+
 const (
 	CHECK_NONE         = OTB
 	CHECK_DOUBLE_CHECK = 0x0F // Is also off the board but used as double check value.
@@ -174,7 +212,12 @@ func (b *Board) SetFEN(fenstr string) error {
 	if err != nil {
 		return err
 	}
+	b.setMinBoard(&mb)
+	return nil
+}
 
+// setMinBoard sets the position given by 'mb' and resets all derived state.
+func (b *Board) setMinBoard(mb *MinBoard) {
 	for color := BLACK; color <= WHITE; color++ {
 		b.Sliders[color].Clear()
 		b.Queens[color].Clear()
@@ -197,6 +240,8 @@ func (b *Board) SetFEN(fenstr string) error {
 	b.CastleShort = mb.CastleShort
 	b.CastleLong = mb.CastleLong
 	b.Player = mb.Color
+	// A FEN does not describe the hands, so a new position starts without pieces to drop.
+	b.Hand = [2][7]int{}
 	for idx, piece := range mb.Squares {
 		sq := Lookup0x88[idx]
 		b.Squares[sq] = piece
@@ -235,14 +280,19 @@ func (b *Board) SetFEN(fenstr string) error {
 		}
 	}
 
+	b.Hash = b.ComputeHash()
+
 	// Set info board and find possible checks.
 	b.DetectChecksAndPins(b.Player)
-
-	return nil
 }
 
 func (b *Board) clearMetaInfo() {
 	b.CheckInfo = CHECK_NONE
+	if !b.infoDirty {
+		// Most positions have neither checks nor pins, so there is nothing to clear.
+		return
+	}
+	b.infoDirty = false
 	// Manually unrolled loop. Ugly but MUCH faster than looping over the index lookup
 	// or counting up the right side board indexes with (i + 1) | 0x8.
 	b.Squares[0x8] = INFO_NONE
@@ -315,21 +365,55 @@ func (b *Board) clearMetaInfo() {
 
 //}
 
+// MakeLegalMoveChecked applies the move to the board like MakeLegalMove, but returns an
+// error instead of panicking if the move is obviously broken: it must start on a piece of
+// the side to move and must not capture a king. The board is unchanged on error. This
+// is meant for untrusted input, the search uses the faster MakeLegalMove.
+func (b *Board) MakeLegalMoveChecked(m BitMove) error {
+	from, to := m.From(), m.To()
+	if !from.OnBoard() || !to.OnBoard() {
+		return ErrSquareOffBoard
+	}
+	if m.IsDrop() {
+		if !b.Crazyhouse || b.Hand[b.Player][m.DroppedPiece().TypeIndex()] == 0 || !b.Squares[to].IsEmpty() {
+			return ErrNoPieceToMove
+		}
+		b.MakeLegalMove(m)
+		return nil
+	}
+	if piece := b.Squares[from]; piece.IsEmpty() || piece.PieceColor() != b.Player {
+		return ErrNoPieceToMove
+	}
+	if tpiece := b.Squares[to]; !tpiece.IsEmpty() && tpiece.Contains(KING) {
+		return ErrKingCapture
+	}
+	b.MakeLegalMove(m)
+	return nil
+}
+
 // MakeLegalMove expects a legal move and applies it to the board.
+// The move is not validated, untrusted moves should be made with MakeLegalMoveChecked.
 func (b *Board) MakeLegalMove(m BitMove) {
+	if m.IsDrop() {
+		b.makeDrop(m)
+		return
+	}
 	from, to, promo := m.All()
 	oppColor := b.Player.Flip()
 	// Detect piece type and target piece
 	ptype := b.Squares[from] & PIECE_MASK
 	tpiece := b.Squares[to]
 
+	// Side to move, castling rights and e.p. square are hashed again after the move.
+	b.Hash ^= b.stateHash()
+
 	// Test if it is a capture.
 	if !tpiece.IsEmpty() {
-		if tpiece.Contains(KING) {
-			panic("\n" + b.InfoBoardString() + "\n" + b.String() + "\nfor move:" + m.MiniNotation() + "\n")
-		}
 		// Remove captured piece from the board.
 		b.removePiece(to)
+		if b.Crazyhouse {
+			b.Hand[b.Player][tpiece.TypeIndex()]++
+		}
 
 		// If capture captures a rook disable that side for castling.
 		// TODO this could be realized differently:
@@ -342,8 +426,12 @@ func (b *Board) MakeLegalMove(m BitMove) {
 	} else if ptype == PAWN && to == b.EpSquare { // Is it an e.p. capture?
 		capSq := Square(int8(to) + PAWN_PUSH_DIRS[oppColor])
 		b.removePiece(capSq)
+		if b.Crazyhouse {
+			b.Hand[b.Player][PAWN.TypeIndex()]++
+		}
 	}
 	// Now make the actual move on the board.
+	b.Hash ^= zobristPiece(b.Squares[from], from) ^ zobristPiece(b.Squares[from], to)
 	b.Squares[to], b.Squares[from] = b.Squares[from], EMPTY
 	// Remove any possible e.p. squares.
 	b.EpSquare = OTB
@@ -356,6 +444,7 @@ func (b *Board) MakeLegalMove(m BitMove) {
 		}
 		if promo != NONE {
 			b.Pawns[b.Player].Remove(from)
+			b.Hash ^= zobristPiece(PAWN|b.Player, to)
 			b.addPiece(to, promo|b.Player)
 		} else {
 			b.Pawns[b.Player].Move(from, to)
@@ -387,12 +476,14 @@ func (b *Board) MakeLegalMove(m BitMove) {
 		if shortCastle {
 			rookFrom := CASTLING_ROOK_SHORT[b.Player]
 			rookTo := CASTLING_PATH_SHORT[b.Player][0]
+			b.Hash ^= zobristPiece(ROOK|b.Player, rookFrom) ^ zobristPiece(ROOK|b.Player, rookTo)
 			b.Squares[rookTo], b.Squares[rookFrom] = ROOK|b.Player, EMPTY
 			b.Rooks[b.Player].Move(rookFrom, rookTo)
 			b.Sliders[b.Player].Move(rookFrom, rookTo)
 		} else if longCastle {
 			rookFrom := CASTLING_ROOK_LONG[b.Player]
 			rookTo := CASTLING_PATH_LONG[b.Player][0]
+			b.Hash ^= zobristPiece(ROOK|b.Player, rookFrom) ^ zobristPiece(ROOK|b.Player, rookTo)
 			b.Squares[rookTo], b.Squares[rookFrom] = ROOK|b.Player, EMPTY
 			b.Rooks[b.Player].Move(rookFrom, rookTo)
 			b.Sliders[b.Player].Move(rookFrom, rookTo)
@@ -403,15 +494,222 @@ func (b *Board) MakeLegalMove(m BitMove) {
 		panic("Board.MakeLegalMove: " + fmt.Sprintf("%v", m))
 	}
 
+	// Captures and pawn moves reset the counter for the fifty-move rule. The type was
+	// detected before the move, so promotions and e.p. captures count as pawn moves.
+	if ptype == PAWN || !tpiece.IsEmpty() {
+		b.DrawCounter = 0
+	} else {
+		b.DrawCounter++
+	}
+
+	// The full move number is increased after every move of black.
+	if b.Player == BLACK {
+		b.MoveNumber++
+	}
+	b.Player = b.Player.Flip()
+	b.Hash ^= b.stateHash()
+}
+
+// makeDrop puts the piece of the drop move 'm' from the hand of the side to move on the board.
+func (b *Board) makeDrop(m BitMove) {
+	piece := m.DroppedPiece() | b.Player
+
+	b.Hash ^= b.stateHash()
+	b.Hand[b.Player][piece.TypeIndex()]--
+	b.addPiece(m.To(), piece)
+	b.EpSquare = OTB
+	b.DrawCounter++
+	if b.Player == BLACK {
+		b.MoveNumber++
+	}
+	b.Player = b.Player.Flip()
+	b.Hash ^= b.stateHash()
+}
+
+// Undo holds the state of the board before a move, which cannot be derived from
+// the move and the board after it.
+type Undo struct {
+	Captured    Piece
+	CastleShort [2]bool
+	CastleLong  [2]bool
+	EpSquare    Square
+	CheckInfo   Square
+	DrawCounter uint16
+	MoveNumber  uint16
+	Hash        uint64
+}
+
+// MakeMoveWithUndo applies the legal move 'm' like MakeLegalMove and returns the
+// information needed by UnmakeMove to take it back.
+func (b *Board) MakeMoveWithUndo(m BitMove) Undo {
+	u := Undo{
+		Captured:    b.Squares[m.To()],
+		CastleShort: b.CastleShort,
+		CastleLong:  b.CastleLong,
+		EpSquare:    b.EpSquare,
+		CheckInfo:   b.CheckInfo,
+		DrawCounter: b.DrawCounter,
+		MoveNumber:  b.MoveNumber,
+		Hash:        b.Hash,
+	}
+	b.MakeLegalMove(m)
+	return u
+}
+
+// UnmakeMove takes back the move 'm', which must be the last move made with
+// MakeMoveWithUndo. The position is restored, but the order of the pieces in the
+// piece lists may differ, so moves can be generated in a different order.
+func (b *Board) UnmakeMove(m BitMove, u Undo) {
+	from, to, promo := m.All()
 	b.Player = b.Player.Flip()
-	b.MoveNumber++
-	// TODO half draw counter
+	piece := b.Squares[to]
+
+	if m.IsDrop() {
+		b.removePiece(to)
+		b.Hand[b.Player][piece.TypeIndex()]++
+	} else if promo != NONE {
+		b.removePiece(to)
+		b.addPiece(from, PAWN|b.Player)
+	} else {
+		b.Squares[from], b.Squares[to] = piece, EMPTY
+		switch piece & PIECE_MASK {
+		case PAWN:
+			b.Pawns[b.Player].Move(to, from)
+		case KNIGHT:
+			b.Knights[b.Player].Move(to, from)
+		case BISHOP:
+			b.Bishops[b.Player].Move(to, from)
+			b.Sliders[b.Player].Move(to, from)
+		case ROOK:
+			b.Rooks[b.Player].Move(to, from)
+			b.Sliders[b.Player].Move(to, from)
+		case QUEEN:
+			b.Queens[b.Player].Move(to, from)
+			b.Sliders[b.Player].Move(to, from)
+		case KING:
+			b.Kings[b.Player] = from
+			// Castling teleported the rook, which is moved back to its corner.
+			rookFrom, rookTo := OTB, OTB
+			if from == CASTLING_DETECT_SHORT[b.Player][0] && to == CASTLING_DETECT_SHORT[b.Player][1] {
+				rookFrom, rookTo = CASTLING_ROOK_SHORT[b.Player], CASTLING_PATH_SHORT[b.Player][0]
+			} else if from == CASTLING_DETECT_LONG[b.Player][0] && to == CASTLING_DETECT_LONG[b.Player][1] {
+				rookFrom, rookTo = CASTLING_ROOK_LONG[b.Player], CASTLING_PATH_LONG[b.Player][0]
+			}
+			if rookFrom != OTB {
+				b.Squares[rookFrom], b.Squares[rookTo] = ROOK|b.Player, EMPTY
+				b.Rooks[b.Player].Move(rookTo, rookFrom)
+				b.Sliders[b.Player].Move(rookTo, rookFrom)
+			}
+		}
+	}
+
+	if !u.Captured.IsEmpty() {
+		b.addPiece(to, u.Captured)
+		if b.Crazyhouse {
+			b.Hand[b.Player][u.Captured.TypeIndex()]--
+		}
+	} else if piece&PIECE_MASK == PAWN && to == u.EpSquare && !m.IsDrop() {
+		b.addPiece(Square(int8(to)+PAWN_PUSH_DIRS[b.Player.Flip()]), PAWN|b.Player.Flip())
+		if b.Crazyhouse {
+			b.Hand[b.Player][PAWN.TypeIndex()]--
+		}
+	}
+
+	// The hash was changed by adding and removing pieces, it is restored as well.
+	b.CastleShort = u.CastleShort
+	b.CastleLong = u.CastleLong
+	b.EpSquare = u.EpSquare
+	b.CheckInfo = u.CheckInfo
+	b.DrawCounter = u.DrawCounter
+	b.MoveNumber = u.MoveNumber
+	b.Hash = u.Hash
 }
 
 // TODO (improvement) -> introduce movePiece function..
 
+// PlacePiece puts 'piece' on 'sq' and replaces any piece which was there before.
+// Edits which lead to impossible positions are rejected. Castling rights are dropped
+// if the king or rook left its home square and the e.p. square is cleared. Call
+// DetectChecksAndPins afterwards before generating moves.
+func (b *Board) PlacePiece(sq Square, piece Piece) error {
+	if !sq.OnBoard() {
+		return ErrSquareOffBoard
+	}
+	if _, ok := PrintMap[piece]; !ok || piece == EMPTY {
+		return ErrPieceInvalid
+	}
+	ptype, color := piece&PIECE_MASK, piece.PieceColor()
+	if ptype == PAWN && (sq.Rank() == 0 || sq.Rank() == 7) {
+		return ErrPawnOnBackRank
+	}
+	if ptype == KING && b.Kings[color] != OTB && b.Kings[color] != sq {
+		return ErrSecondKing
+	}
+
+	b.Hash ^= b.stateHash()
+	b.clearSquare(sq)
+	if ptype == KING {
+		b.Squares[sq] = piece
+		b.Kings[color] = sq
+		b.Hash ^= zobristPiece(piece, sq)
+	} else {
+		b.addPiece(sq, piece)
+	}
+	b.afterEdit()
+
+	return nil
+}
+
+// ClearSquare removes the piece on 'sq' if there is any. The same rules
+// as for PlacePiece apply.
+func (b *Board) ClearSquare(sq Square) error {
+	if !sq.OnBoard() {
+		return ErrSquareOffBoard
+	}
+
+	b.Hash ^= b.stateHash()
+	b.clearSquare(sq)
+	b.afterEdit()
+
+	return nil
+}
+
+func (b *Board) clearSquare(sq Square) {
+	piece := b.Squares[sq]
+	if piece.IsEmpty() {
+		return
+	}
+	if piece&PIECE_MASK == KING {
+		b.Squares[sq] = EMPTY
+		b.Kings[piece.PieceColor()] = OTB
+		b.Hash ^= zobristPiece(piece, sq)
+		return
+	}
+	b.removePiece(sq)
+}
+
+// afterEdit drops castling rights which are not possible anymore, clears the
+// e.p. square and finishes the hash update.
+func (b *Board) afterEdit() {
+	for color := BLACK; color <= WHITE; color++ {
+		if b.Kings[color] != CASTLING_DETECT_SHORT[color][0] {
+			b.CastleShort[color] = false
+			b.CastleLong[color] = false
+		}
+		if b.Squares[CASTLING_ROOK_SHORT[color]] != ROOK|color {
+			b.CastleShort[color] = false
+		}
+		if b.Squares[CASTLING_ROOK_LONG[color]] != ROOK|color {
+			b.CastleLong[color] = false
+		}
+	}
+	b.EpSquare = OTB
+	b.Hash ^= b.stateHash()
+}
+
 func (b *Board) addPiece(sq Square, piece Piece) {
 	b.Squares[sq] = piece
+	b.Hash ^= zobristPiece(piece, sq)
 	ptype := piece & PIECE_MASK
 	color := piece.PieceColor()
 
@@ -441,6 +739,7 @@ func (b *Board) removePiece(sq Square) {
 	color := piece.PieceColor()
 
 	b.Squares[sq] = EMPTY
+	b.Hash ^= zobristPiece(piece, sq)
 	switch ptype {
 	case PAWN:
 		b.Pawns[color].Remove(sq)
@@ -572,6 +871,7 @@ func (b *Board) DetectChecksAndPins(color Color) {
 			b.CheckInfo = knightSq
 			checkCounter++
 			b.Squares[knightSq.ToInfoIndex()].Set(INFO_MASK_CHECK)
+			b.infoDirty = true
 			break
 		}
 	}
@@ -605,6 +905,7 @@ func (b *Board) DetectChecksAndPins(color Color) {
 				b.CheckInfo = pawnSq
 				checkCounter++
 				b.Squares[pawnSq.ToInfoIndex()].Set(INFO_MASK_CHECK)
+				b.infoDirty = true
 				goto EXIT_PAWN_CHECK // Acts as double break.. nothing harmful really... :)
 			}
 		}
@@ -700,6 +1001,7 @@ func (b *Board) DetectSliderChecksAndPins(color Color, pmarker *Info, ccount int
 				// be marked in the info board, so move generation will skip
 				// illegal moves.
 				//				b.Squares[sliderSq.ToInfoIndex()] |= info
+				b.infoDirty = true
 				for stepSq := sliderSq; stepSq != kingSq; stepSq = Square(int8(stepSq) - diffdir) {
 					b.Squares[stepSq.ToInfoIndex()].Set(info)
 				}
@@ -727,6 +1029,8 @@ func (b *Board) DetectSliderChecksAndPins(color Color, pmarker *Info, ccount int
 	return checkCounter
 }
 
+// GenerateAllLegalMoves appends all legal moves for the side to move to the caller's
+// list. No list is allocated, so the same list can be reused after calling Reset.
 func (b *Board) GenerateAllLegalMoves(mlist *MoveList) {
 	// Detect checks and pins.
 	b.DetectChecksAndPins(b.Player)
@@ -745,6 +1049,340 @@ func (b *Board) GenerateAllLegalMoves(mlist *MoveList) {
 	b.GenerateBishopMoves(mlist, b.Player)
 	b.GenerateRookMoves(mlist, b.Player)
 	b.GeneratePawnMoves(mlist, b.Player)
+	if b.Crazyhouse {
+		b.GenerateDropMoves(mlist, b.Player)
+	}
+}
+
+// GenerateDropMoves appends the legal Crazyhouse drops of 'color' to the list. Pawns are
+// never dropped on the first or last rank. Like the other generators it relies on the
+// checks detected by DetectChecksAndPins: in check a piece can only be dropped between
+// the king and a checking slider.
+func (b *Board) GenerateDropMoves(mlist *MoveList, color Color) {
+	if b.CheckInfo == CHECK_DOUBLE_CHECK {
+		return
+	}
+	isCheck := b.CheckInfo.OnBoard()
+
+	for _, ptype := range [5]Piece{PAWN, KNIGHT, BISHOP, ROOK, QUEEN} {
+		if b.Hand[color][ptype.TypeIndex()] == 0 {
+			continue
+		}
+		for _, to := range Lookup0x88 {
+			if !b.Squares[to].IsEmpty() || (isCheck && !b.Squares[to.ToInfoIndex()].IsSet(INFO_MASK_CHECK)) {
+				continue
+			}
+			if ptype == PAWN && (to.Rank() == 0 || to.Rank() == 7) {
+				continue
+			}
+			mlist.Put(NewDropMove(ptype, to))
+		}
+	}
+}
+
+// GenerateLegalMovesFor appends all legal moves of 'color' to the caller's list, as if
+// it was the side to move. For the side not to move the e.p. square is ignored, the
+// position is treated like after a null move. If the side to move is in check, the other
+// side could capture the king, these captures are not generated. The board itself is
+// not changed.
+func (b *Board) GenerateLegalMovesFor(mlist *MoveList, color Color) {
+	if color == b.Player {
+		cpy := *b
+		cpy.GenerateAllLegalMoves(mlist)
+		return
+	}
+	cpy := b.NullMovePosition()
+	start := mlist.Size
+	cpy.GenerateAllLegalMoves(mlist)
+
+	// Remove the king captures and keep the order of the other moves.
+	king := b.Kings[b.Player]
+	n := start
+	for i := start; i < mlist.Size; i++ {
+		if mlist.Moves[i].To() != king {
+			mlist.Moves[n] = mlist.Moves[i]
+			n++
+		}
+	}
+	mlist.Size = n
+}
+
+// GeneratePseudoLegalForBenchmark appends the moves of 'color' without detecting checks
+// and pins. King moves are not tested for attacked squares and castling only needs the
+// rights and an empty path. En passant captures are still validated by the pawn move
+// generator. The moves may leave the own king in check, so this is only meant to
+// measure the cost of the legality checks in benchmarks and never for playing.
+//
+// The check info and the info board of 'b' are cleared as a side effect. Call
+// DetectChecksAndPins before generating legal moves on the same board again.
+func (b *Board) GeneratePseudoLegalForBenchmark(mlist *MoveList, color Color) {
+	b.generatePseudoLegal(mlist, color)
+}
+
+// generatePseudoLegal appends the moves of 'color' without testing if they leave the
+// own king in check. The info board is cleared.
+func (b *Board) generatePseudoLegal(mlist *MoveList, color Color) {
+	// Without marks on the info board the generators neither see checks nor pins.
+	b.clearMetaInfo()
+
+	from := b.Kings[color]
+	for _, dir := range KING_DIRS {
+		if to := from.Add(dir); to.OnBoard() && !b.Squares[to].HasColor(color) {
+			mlist.Put(NewBitMove(from, to, NONE))
+		}
+	}
+	path := CASTLING_PATH_SHORT[color]
+	if b.CastleShort[color] && b.Squares[path[0]].IsEmpty() && b.Squares[path[1]].IsEmpty() {
+		mlist.Put(NewBitMove(from, path[1], NONE))
+	}
+	long := CASTLING_PATH_LONG[color]
+	if b.CastleLong[color] && b.Squares[long[0]].IsEmpty() && b.Squares[long[1]].IsEmpty() && b.Squares[long[2]].IsEmpty() {
+		mlist.Put(NewBitMove(from, long[1], NONE))
+	}
+
+	b.GenerateKnightMoves(mlist, color)
+	b.GenerateQueenMoves(mlist, color)
+	b.GenerateBishopMoves(mlist, color)
+	b.GenerateRookMoves(mlist, color)
+	b.GeneratePawnMoves(mlist, color)
+}
+
+// InCheck tests if the king of 'color' is attacked.
+func (b *Board) InCheck(color Color) bool {
+	return b.IsSquareAttacked(b.Kings[color], OTB, color)
+}
+
+// CheckState decodes CheckInfo for the side to move. 'checker' is the square of the
+// checking piece in case of a single check and OTB otherwise. The board is not changed,
+// so after MakeLegalMove, which leaves CheckInfo as is, DetectChecksAndPins must be
+// called first.
+func (b *Board) CheckState() (inCheck bool, doubleCheck bool, checker Square) {
+	switch {
+	case b.CheckInfo == CHECK_NONE:
+		return false, false, OTB
+	case b.CheckInfo == CHECK_DOUBLE_CHECK:
+		return true, true, OTB
+	case b.CheckInfo.OnBoard():
+		return true, false, b.CheckInfo
+	}
+	// CHECK_CHECKMATE does not tell the checking piece.
+	return true, false, OTB
+}
+
+// Checkers returns the squares of all pieces giving check to the king of the side
+// to move. There are two squares in case of a double check and none without check.
+func (b *Board) Checkers() []Square {
+	return b.attackers(b.Kings[b.Player], b.Player.Flip())
+}
+
+// IsDefended tests if the piece on 'sq' is defended by another piece of 'color'.
+func (b *Board) IsDefended(sq Square, color Color) bool {
+	return b.DefenderCount(sq, color) > 0
+}
+
+// DefenderCount returns the number of pieces of 'color' which defend 'sq',
+// without taking pins or pieces behind the defenders into account.
+func (b *Board) DefenderCount(sq Square, color Color) int {
+	return len(b.attackers(sq, color))
+}
+
+// IsAttackedByType tests if 'sq' is attacked by a piece of 'color' and type 'ptype'.
+// Several types may be combined, e.g. BISHOP|ROOK|QUEEN asks for an attack by any slider.
+func (b *Board) IsAttackedByType(sq Square, color Color, ptype Piece) bool {
+	for _, from := range b.attackers(sq, color) {
+		if b.Squares[from].Overlaps(ptype & PIECE_MASK) {
+			return true
+		}
+	}
+	return false
+}
+
+// attackers returns the squares of all pieces of 'color' which attack 'sq'.
+func (b *Board) attackers(sq Square, color Color) []Square {
+	squares := []Square{}
+
+	pawn := PAWN | color
+	for d := 0; d < 2; d++ {
+		from := sq.Add(PAWN_CAPTURE_DIRS[color.Flip()][d])
+		if from.OnBoard() && b.Squares[from] == pawn {
+			squares = append(squares, from)
+		}
+	}
+	for i := uint8(0); i < b.Knights[color].Size; i++ {
+		from := b.Knights[color].Pieces[i]
+		if SQUARE_DIFFS[from.Diff(sq)].Contains(KNIGHT) {
+			squares = append(squares, from)
+		}
+	}
+	for i := uint8(0); i < b.Sliders[color].Size; i++ {
+		from := b.Sliders[color].Pieces[i]
+		diff := sq.Diff(from)
+		if SQUARE_DIFFS[diff].Contains(b.Squares[from]&PIECE_MASK) && b.isPathClear(sq, from, DIFF_DIRS[diff]) {
+			squares = append(squares, from)
+		}
+	}
+	if kingSq := b.Kings[color]; kingSq.OnBoard() && kingSq != sq && SQUARE_DIFFS[kingSq.Diff(sq)].Contains(KING) {
+		squares = append(squares, kingSq)
+	}
+
+	return squares
+}
+
+// GivesCheck tests if the given legal move checks the opponent's king.
+// This includes discovered checks and the rook's check after castling.
+func (b *Board) GivesCheck(m BitMove) bool {
+	cpy := *b
+	cpy.MakeLegalMove(m)
+	return cpy.InCheck(cpy.Player)
+}
+
+// WouldLeaveKingInCheck tests if the king of the side to move is attacked after the
+// given move. The move only has to be pseudo legal, which makes this useful to validate
+// user input before committing it. The board is not changed.
+func (b *Board) WouldLeaveKingInCheck(m BitMove) bool {
+	mover := b.Player
+	cpy := *b
+	cpy.MakeLegalMove(m)
+	return cpy.InCheck(mover)
+}
+
+// Equals tests if both boards hold the same position: pieces, side to move, castling
+// rights and en passant square. The move counters are ignored.
+func (b *Board) Equals(other *Board) bool {
+	for _, sq := range Lookup0x88 {
+		if b.Squares[sq] != other.Squares[sq] {
+			return false
+		}
+	}
+	return b.Player == other.Player && b.CastleShort == other.CastleShort &&
+		b.CastleLong == other.CastleLong && b.EpSquare == other.EpSquare && b.Hand == other.Hand
+}
+
+// NullMovePosition returns a copy of the board where the opponent is to move, as if
+// the side to move had passed. The en passant square is cleared, the move counters
+// are unchanged. The result is meaningless if the side to move is in check, because
+// the opponent could capture the king.
+func (b *Board) NullMovePosition() Board {
+	cpy := *b
+	cpy.Hash ^= cpy.stateHash()
+	cpy.Player = cpy.Player.Flip()
+	cpy.EpSquare = OTB
+	cpy.Hash ^= cpy.stateHash()
+	return cpy
+}
+
+// TogglePlayer gives the move to the opponent in place, which is meant to set up test
+// positions. The en passant square is cleared and the checks and pins of the new side to
+// move are detected, the move counters are unchanged. Contrary to NullMovePosition the
+// board itself is changed.
+func (b *Board) TogglePlayer() {
+	b.Hash ^= b.stateHash()
+	b.Player = b.Player.Flip()
+	b.EpSquare = OTB
+	b.Hash ^= b.stateHash()
+	b.DetectChecksAndPins(b.Player)
+}
+
+// CanCastle tests if 'color' still has the right to castle to the king side or the queen
+// side. It does not test if castling is possible in the current position, e.g. if the
+// squares between king and rook are empty.
+func (b *Board) CanCastle(color Color, kingside bool) bool {
+	if kingside {
+		return b.CastleShort[color]
+	}
+	return b.CastleLong[color]
+}
+
+// EnPassantTarget returns the square a pawn can capture en passant on, if the last
+// move was a double pawn push.
+func (b *Board) EnPassantTarget() (Square, bool) {
+	return b.EpSquare, b.EpSquare != OTB
+}
+
+// EnPassantCapturers returns the squares of all pawns which can legally capture en passant.
+// It returns nil if there is no e.p. target or the player is in double check.
+func (b *Board) EnPassantCapturers() []Square {
+	epSq, ok := b.EnPassantTarget()
+	if !ok {
+		return nil
+	}
+
+	mlist := MoveList{}
+	b.DetectChecksAndPins(b.Player)
+	if b.CheckInfo == CHECK_DOUBLE_CHECK {
+		return nil
+	}
+	b.GeneratePawnMoves(&mlist, b.Player)
+
+	capturers := []Square{}
+	for i := uint32(0); i < mlist.Size; i++ {
+		if mlist.Moves[i].To() == epSq {
+			capturers = append(capturers, mlist.Moves[i].From())
+		}
+	}
+	return capturers
+}
+
+// PieceCount returns the number of pieces of the given type and color on the board,
+// e.g. PieceCount(WROOK). It reads the piece lists and does not scan the board.
+func (b *Board) PieceCount(piece Piece) int {
+	color := piece.PieceColor()
+	switch piece & PIECE_MASK {
+	case PAWN:
+		return int(b.Pawns[color].Size)
+	case KNIGHT:
+		return int(b.Knights[color].Size)
+	case BISHOP:
+		return int(b.Bishops[color].Size)
+	case ROOK:
+		return int(b.Rooks[color].Size)
+	case QUEEN:
+		return int(b.Queens[color].Size)
+	case KING:
+		if b.Kings[color] != OTB {
+			return 1
+		}
+	}
+	return 0
+}
+
+// MaterialSummary returns the number of pieces on the board for every piece type
+// and color, e.g. to show the remaining and captured material.
+func (b *Board) MaterialSummary() map[Piece]int {
+	summary := map[Piece]int{}
+	for color := BLACK; color <= WHITE; color++ {
+		for _, ptype := range []Piece{PAWN, KNIGHT, BISHOP, ROOK, QUEEN, KING} {
+			summary[ptype|color] = b.PieceCount(ptype | color)
+		}
+	}
+	return summary
+}
+
+// OccupiedSquares returns the algebraic names of all squares occupied by 'color', e.g. "e4".
+// The names are taken from the piece lists, starting with the king and ending with the pawns.
+func (b *Board) OccupiedSquares(color Color) []string {
+	squares := []string{}
+	if b.Kings[color] != OTB {
+		squares = append(squares, PrintBoardIndex[b.Kings[color]])
+	}
+	for _, list := range []*PieceList{&b.Queens[color], &b.Rooks[color], &b.Bishops[color], &b.Knights[color], &b.Pawns[color]} {
+		for i := uint8(0); i < list.Size; i++ {
+			squares = append(squares, PrintBoardIndex[list.Pieces[i]])
+		}
+	}
+	return squares
+}
+
+// IsLegalMove tests if the given move is legal in the current position.
+func (b *Board) IsLegalMove(m BitMove) bool {
+	mlist := MoveList{}
+	b.GenerateAllLegalMoves(&mlist)
+	for i := uint32(0); i < mlist.Size; i++ {
+		if mlist.Moves[i] == m {
+			return true
+		}
+	}
+	return false
 }
 
 // GeneratePawnMoves generates all legal pawn moves for the given color
@@ -950,80 +1588,144 @@ func (b *Board) GenerateKnightMoves(mlist *MoveList, color Color) {
 // GenerateKingMoves generates all legal king moves for the given color
 // and stores them in the given MoveList.
 func (b *Board) GenerateKingMoves(mlist *MoveList, color Color) {
-	from, to := b.Kings[color], OTB
-	tpiece := EMPTY
-	var move BitMove
-
-	// Define all possible target squares for the king to move to and add all legal normal moves.
-	targets := [8]bool{}
+	from := b.Kings[color]
+
+	// Collect all destinations first, so the attacked ones are found in a single
+	// scan of the enemy pieces.
+	squares := [8]Square{}
+	n := 0
+	for _, dir := range KING_DIRS {
+		to := from.Add(dir)
+		if !to.OnBoard() || b.Squares[to].HasColor(color) || b.Squares[to.ToInfoIndex()].IsSet(INFO_MASK_FORBIDDEN_ESCAPE) {
+			continue
+		}
+		squares[n] = to
+		n++
+	}
 
-	//	for i, dir := range KING_DIRS {
-	for i := 0; i < 8; i++ {
-		dir := KING_DIRS[i]
-		to = Square(int8(from) + dir)
-		if to.OnBoard() {
-			tpiece = b.Squares[to]
-			if tpiece.HasColor(color) {
-				continue
-			}
-			if b.IsSquareAttacked(to, OTB, color) {
-				continue
-			}
-			targets[i] = true
-			if !b.Squares[to.ToInfoIndex()].IsSet(INFO_MASK_FORBIDDEN_ESCAPE) {
-				if !tpiece.HasColor(color) {
-					// Add a normal or capture move.
-					move = NewBitMove(from, to, NONE)
-					mlist.Put(move)
-				}
-			}
+	// Add all legal normal moves and captures of the king.
+	attacked := b.attackedSquares(squares[:n], color)
+	for i := 0; i < n; i++ {
+		if attacked&(1<<uint(i)) == 0 {
+			mlist.Put(NewBitMove(from, squares[i], NONE))
 		}
 	}
 
+	b.generateCastlingMoves(mlist, color)
+}
+
+// generateCastlingMoves generates all legal castling moves for the given color and
+// stores them in the given MoveList. The castling rights must still be given, the
+// squares between king and rook must be empty and the king must neither be in check
+// nor cross or land on an attacked square. Checks must have been detected before.
+func (b *Board) generateCastlingMoves(mlist *MoveList, color Color) {
 	if b.CheckInfo != CHECK_NONE {
 		// Cannot castle when in check.
 		return
 	}
+	from := b.Kings[color]
 
-	// a. Try castle king-side
-	// Is it still allowed?
-	if b.CastleShort[color] {
-		sq1 := CASTLING_PATH_SHORT[color][0]
-		sq2 := CASTLING_PATH_SHORT[color][1]
-
-		// Test if the squares on short castling path are empty.
-		sq1Piece := b.Squares[sq1]
-		sq2Piece := b.Squares[sq2]
-		if sq1Piece.IsEmpty() && sq2Piece.IsEmpty() {
-			// Test if both squares are not attacked.
-			if targets[0] && !b.IsSquareAttacked(sq2, OTB, color) {
-				// Finally.. castling king-side is possible.
-				move = NewBitMove(from, sq2, NONE)
-				mlist.Put(move)
+	// a. Is castling king-side still allowed and are the squares on the path empty?
+	short := CASTLING_PATH_SHORT[color]
+	canShort := b.CastleShort[color] && b.Squares[short[0]].IsEmpty() && b.Squares[short[1]].IsEmpty()
+
+	// b. The same for castling queen-side.
+	long := CASTLING_PATH_LONG[color]
+	canLong := b.CastleLong[color] && b.Squares[long[0]].IsEmpty() && b.Squares[long[1]].IsEmpty() && b.Squares[long[2]].IsEmpty()
+
+	if !canShort && !canLong {
+		return
+	}
+
+	// The squares the king crosses and lands on must not be attacked. The king never
+	// reaches long[2] (b1/b8), so it only has to be empty and may be attacked.
+	attacked := b.attackedSquares([]Square{short[0], short[1], long[0], long[1]}, color)
+	if canShort && attacked&0x3 == 0 {
+		mlist.Put(NewBitMove(from, short[1], NONE))
+	}
+	if canLong && attacked&0xC == 0 {
+		mlist.Put(NewBitMove(from, long[1], NONE))
+	}
+}
+
+// attackedSquares tests up to 16 squares at once like IsSquareAttacked and returns a
+// mask with bit i set, if squares[i] is attacked by the opponent of 'color'. Every
+// enemy piece is visited only once instead of once per square.
+func (b *Board) attackedSquares(squares []Square, color Color) uint16 {
+	oppColor := color.Flip()
+	all := uint16(1)<<uint(len(squares)) - 1
+	attacked := uint16(0)
+
+	// 1. Pawns and the king are found by looking at the squares around each square.
+	oppPawn := PAWN | oppColor
+	oppKingSq := b.Kings[oppColor]
+	for i, sq := range squares {
+		for _, dir := range PAWN_CAPTURE_DIRS[color] {
+			if pawnSq := sq.Add(dir); pawnSq.OnBoard() && b.Squares[pawnSq] == oppPawn {
+				attacked |= 1 << uint(i)
 			}
 		}
+		if SQUARE_DIFFS[oppKingSq.Diff(sq)].Contains(KING) {
+			attacked |= 1 << uint(i)
+		}
 	}
 
-	// b. Try castle queen-side
-	// Is it still allowed?
-	if b.CastleLong[color] {
-		sq1 := CASTLING_PATH_LONG[color][0]
-		sq2 := CASTLING_PATH_LONG[color][1]
-		sq3 := CASTLING_PATH_LONG[color][2]
-
-		// Test if the squares on short castling path are empty.
-		sq1Piece := b.Squares[sq1]
-		sq2Piece := b.Squares[sq2]
-		sq3Piece := b.Squares[sq3]
-		if sq1Piece.IsEmpty() && sq2Piece.IsEmpty() && sq3Piece.IsEmpty() {
-			// Test if both squares are not attacked.
-			if targets[1] && !b.IsSquareAttacked(sq2, OTB, color) {
-				// Finally.. castling queen-side is possible.
-				move = NewBitMove(from, sq2, NONE)
-				mlist.Put(move)
+	// 2. Knights.
+	for k := uint8(0); k < b.Knights[oppColor].Size && attacked != all; k++ {
+		knightSq := b.Knights[oppColor].Pieces[k]
+		for i, sq := range squares {
+			if SQUARE_DIFFS[knightSq.Diff(sq)].Contains(KNIGHT) {
+				attacked |= 1 << uint(i)
 			}
 		}
 	}
+
+	// 3. Sliders. The lines through the squares are collected, so sliders on none
+	// of them are skipped at once.
+	var files, ranks, diagonals, antiDiagonals uint16
+	for _, sq := range squares {
+		file, rank := sq.File(), sq.Rank()
+		files |= 1 << file
+		ranks |= 1 << rank
+		diagonals |= 1 << (7 + file - rank)
+		antiDiagonals |= 1 << (file + rank)
+	}
+	for k := uint8(0); k < b.Sliders[oppColor].Size && attacked != all; k++ {
+		sliderSq := b.Sliders[oppColor].Pieces[k]
+		ptype := b.Squares[sliderSq] & PIECE_MASK
+		file, rank := sliderSq.File(), sliderSq.Rank()
+		onLine := false
+		if ptype.Overlaps(ROOK | QUEEN) {
+			onLine = files&(1<<file) != 0 || ranks&(1<<rank) != 0
+		}
+		if ptype.Overlaps(BISHOP|QUEEN) && !onLine {
+			onLine = diagonals&(1<<(7+file-rank)) != 0 || antiDiagonals&(1<<(file+rank)) != 0
+		}
+		if !onLine {
+			continue
+		}
+		// Squares which are already known to be attacked are skipped.
+		for i, sq := range squares {
+			if attacked&(1<<uint(i)) != 0 {
+				continue
+			}
+			diff := sq.Diff(sliderSq)
+			if !SQUARE_DIFFS[diff].Contains(ptype) {
+				continue
+			}
+			// Step from sq towards the slider, the first piece decides like in IsSqAttackedBySlider.
+			diffdir := DIFF_DIRS[diff]
+			stepSq := sq.Add(diffdir)
+			for b.Squares[stepSq].IsEmpty() {
+				stepSq = stepSq.Add(diffdir)
+			}
+			if piece := b.Squares[stepSq]; !piece.HasColor(color) && piece.Contains(ptype) {
+				attacked |= 1 << uint(i)
+			}
+		}
+	}
+
+	return attacked
 }
 
 // GenerateBishopMoves generates all legal bishop moves for the given color
@@ -1193,6 +1895,26 @@ func (b *Board) GenerateSlidingMoves(mlist *MoveList, color Color, ptype Piece,
 	}
 }
 
+// PerftSafe runs Perft after validating the depth, which must be between 0 and MAX_PERFT_DEPTH.
+func (b *Board) PerftSafe(depth int) (uint64, error) {
+	if depth < 0 || depth > MAX_PERFT_DEPTH {
+		return 0, fmt.Errorf("%w: %d", ErrPerftDepthInvalid, depth)
+	}
+	return b.Perft(depth), nil
+}
+
+// PerftFromFEN sets up the position given by 'fen' and runs PerftSafe on it.
+func PerftFromFEN(fen string, depth int) (uint64, error) {
+	b := NewBoard()
+	if err := b.SetFEN(fen); err != nil {
+		return 0, err
+	}
+	return b.PerftSafe(depth)
+}
+
+// Perft counts all leaf nodes of the move tree to the given depth. Depths below 1 return 1.
+// The depth is not limited, see PerftSafe. The node count is an uint64 which silently wraps
+// around on overflow, e.g. from depth 14 on for the starting position.
 func (b *Board) Perft(depth int) uint64 {
 	mlist := MoveList{}
 	cpy := *b
@@ -1221,23 +1943,54 @@ func (b *Board) Perft(depth int) uint64 {
 
 func (b *Board) PerftDivide(depth int) map[string]uint64 {
 	mlist := MoveList{}
-	cpy := *b
 	results := map[string]uint64{}
 
 	b.GenerateAllLegalMoves(&mlist)
 
 	for i := uint32(0); i < mlist.Size; i++ {
-		move := &mlist.Moves[i]
-		b.MakeLegalMove(*move)
-		n := b.Perft(depth - 1)
-		results[move.MiniNotation()] += n
-
-		*b = cpy //TODO --> better move undo
+		move := mlist.Moves[i]
+		undo := b.MakeMoveWithUndo(move)
+		results[move.MiniNotation()] += b.Perft(depth - 1)
+		b.UnmakeMove(move, undo)
 	}
 
 	return results
 }
 
+// DivideEntry holds the perft node count below a single root move.
+type DivideEntry struct {
+	Move  string
+	Nodes uint64
+}
+
+// PerftDivideSorted runs PerftDivide and returns the node counts sorted by move notation,
+// which is the usual divide output format of other engines.
+func (b *Board) PerftDivideSorted(depth int) []DivideEntry {
+	results := b.PerftDivide(depth)
+	entries := make([]DivideEntry, 0, len(results))
+	for move, nodes := range results {
+		entries = append(entries, DivideEntry{Move: move, Nodes: nodes})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Move < entries[j].Move
+	})
+	return entries
+}
+
+// PerftDrill plays the moves of 'movePath' in coordinate notation and returns the perft
+// divide of the reached position. This narrows down the subtree of a wrong perft count
+// one move at a time. The board itself is not changed.
+func (b *Board) PerftDrill(depth int, movePath []string) (map[string]uint64, error) {
+	if depth < 1 || depth > MAX_PERFT_DEPTH {
+		return nil, fmt.Errorf("%w: %d", ErrPerftDepthInvalid, depth)
+	}
+	cpy := *b
+	if err := cpy.ApplyUCIMoves(movePath); err != nil {
+		return nil, err
+	}
+	return cpy.PerftDivide(depth), nil
+}
+
 func (b *Board) InfoBoardString() string {
 	str := "  +-----------------+\n"
 	for r := 7; r >= 0; r-- {
@@ -1258,24 +2011,40 @@ func (b *Board) InfoBoardString() string {
 }
 
 func (b *Board) String() string {
+	return b.StringFrom(WHITE)
+}
+
+// StringFrom renders the board from the point of view of 'color'. For BLACK
+// the ranks and files are reversed, so black's pieces are at the bottom.
+func (b *Board) StringFrom(color Color) string {
+	files := "    a b c d e f g h\n"
+	if color == BLACK {
+		files = "    h g f e d c b a\n"
+	}
+
 	str := "  +-----------------+\n"
-	for r := 7; r >= 0; r-- {
+	for i := 0; i < 8; i++ {
+		r := 7 - i
+		if color == BLACK {
+			r = i
+		}
 		str += strconv.Itoa(r+1) + " | "
-		for f := 0; f < 8; f++ {
+		for j := 0; j < 8; j++ {
+			f := j
+			if color == BLACK {
+				f = 7 - j
+			}
 			idx := 16*r + f
 			if Piece(idx) == b.EpSquare {
 				str += ", "
 			} else {
 				str += PrintMap[b.Squares[idx]] + " "
 			}
-
-			if f == 7 {
-				str += "|\n"
-			}
 		}
+		str += "|\n"
 	}
 	str += "  +-----------------+\n"
-	str += "    a b c d e f g h\n"
+	str += files
 
 	return str
 }
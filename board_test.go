@@ -1,6 +1,9 @@
 package chesskimo
 
 import (
+	"math/rand"
+	"sort"
+	"strings"
 	"testing"
 	"time"
 )
@@ -40,6 +43,110 @@ func BenchmarkPerft(b *testing.B) {
 	}
 }
 
+// benchmarkPositions are used by the move generation benchmarks.
+var benchmarkPositions = []struct {
+	Name string
+	Fen  string
+}{
+	{"start", "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"},
+	{"kiwipete", "r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1"},
+	{"pins", "r3k2r/Pppp1ppp/1b3nbN/nP6/BBP1P3/q4N2/Pp1P2PP/R2Q1RK1 w kq - 0 1"},
+	{"check", "rnbqkbnr/ppp2ppp/3p4/1B2p3/4P3/8/PPPP1PPP/RNBQK1NR b KQkq - 1 3"},
+	{"endgame", "8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1"},
+}
+
+// BenchmarkGenerateAllLegalMoves measures the move generation of single positions,
+// including the detection of checks and pins. It should not allocate.
+func BenchmarkGenerateAllLegalMoves(b *testing.B) {
+	for _, ts := range benchmarkPositions {
+		board := NewBoard()
+		if err := board.SetFEN(ts.Fen); err != nil {
+			b.Fatalf(err.Error())
+		}
+		b.Run(ts.Name, func(b *testing.B) {
+			b.ReportAllocs()
+			mlist := MoveList{}
+			for n := 0; n < b.N; n++ {
+				mlist.Reset()
+				board.GenerateAllLegalMoves(&mlist)
+			}
+		})
+	}
+}
+
+// kingMovesUnbatched generates the king moves of 'color' by testing every destination
+// with IsSquareAttacked, which is how GenerateKingMoves worked before the batching.
+func kingMovesUnbatched(b *Board, mlist *MoveList, color Color) {
+	from := b.Kings[color]
+	for _, dir := range KING_DIRS {
+		to := from.Add(dir)
+		if !to.OnBoard() || b.Squares[to].HasColor(color) || b.IsSquareAttacked(to, OTB, color) {
+			continue
+		}
+		if !b.Squares[to.ToInfoIndex()].IsSet(INFO_MASK_FORBIDDEN_ESCAPE) {
+			mlist.Put(NewBitMove(from, to, NONE))
+		}
+	}
+	if b.CheckInfo != CHECK_NONE {
+		return
+	}
+	short, long := CASTLING_PATH_SHORT[color], CASTLING_PATH_LONG[color]
+	if b.CastleShort[color] && b.Squares[short[0]].IsEmpty() && b.Squares[short[1]].IsEmpty() &&
+		!b.IsSquareAttacked(short[0], OTB, color) && !b.IsSquareAttacked(short[1], OTB, color) {
+		mlist.Put(NewBitMove(from, short[1], NONE))
+	}
+	if b.CastleLong[color] && b.Squares[long[0]].IsEmpty() && b.Squares[long[1]].IsEmpty() && b.Squares[long[2]].IsEmpty() &&
+		!b.IsSquareAttacked(long[0], OTB, color) && !b.IsSquareAttacked(long[1], OTB, color) {
+		mlist.Put(NewBitMove(from, long[1], NONE))
+	}
+}
+
+// BenchmarkGenerateKingMoves compares the batched attack detection of GenerateKingMoves
+// with testing every destination on its own, in a position with many enemy sliders.
+// Most of them are not on a line through the squares around the king.
+func BenchmarkGenerateKingMoves(b *testing.B) {
+	board := NewBoard()
+	if err := board.SetFEN("1r5k/8/b7/q7/b7/6K1/8/1r6 w - - 0 1"); err != nil {
+		b.Fatalf(err.Error())
+	}
+	board.DetectChecksAndPins(board.Player)
+
+	b.Run("batched", func(b *testing.B) {
+		mlist := MoveList{}
+		for n := 0; n < b.N; n++ {
+			mlist.Reset()
+			board.GenerateKingMoves(&mlist, board.Player)
+		}
+	})
+	b.Run("unbatched", func(b *testing.B) {
+		mlist := MoveList{}
+		for n := 0; n < b.N; n++ {
+			mlist.Reset()
+			kingMovesUnbatched(&board, &mlist, board.Player)
+		}
+	})
+}
+
+// BenchmarkGeneratePseudoLegal measures move generation without legality checks. The
+// difference to BenchmarkGenerateAllLegalMoves is the cost of detecting checks and pins
+// and testing the king's target squares.
+func BenchmarkGeneratePseudoLegal(b *testing.B) {
+	for _, ts := range benchmarkPositions {
+		board := NewBoard()
+		if err := board.SetFEN(ts.Fen); err != nil {
+			b.Fatalf(err.Error())
+		}
+		b.Run(ts.Name, func(b *testing.B) {
+			b.ReportAllocs()
+			mlist := MoveList{}
+			for n := 0; n < b.N; n++ {
+				mlist.Reset()
+				board.GeneratePseudoLegalForBenchmark(&mlist, board.Player)
+			}
+		})
+	}
+}
+
 //
 //
 //
@@ -300,3 +407,1071 @@ func TestGeneratePawnMoves(t *testing.T) {
 		}
 	}
 }
+
+func TestNullMovePosition(t *testing.T) {
+	type set struct {
+		Fen         string
+		OpponentFen string
+	}
+
+	testset := []set{
+		set{"rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1", "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR w KQkq - 0 1"},
+		set{"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1", "r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R b KQkq - 0 1"},
+		set{"8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 b - - 3 40", "8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 3 40"},
+	}
+
+	board := NewBoard()
+	opponent := NewBoard()
+	for i, ts := range testset {
+		if err := board.SetFEN(ts.Fen); err != nil {
+			t.Fatalf(err.Error())
+		}
+		if err := opponent.SetFEN(ts.OpponentFen); err != nil {
+			t.Fatalf(err.Error())
+		}
+		original := board
+
+		null := board.NullMovePosition()
+		if board != original {
+			t.Fatalf("Test %d: the original board was changed\n", i)
+		}
+		if null.Player != board.Player.Flip() || null.EpSquare != OTB {
+			t.Fatalf("Test %d: expected player %d without en passant square\n", i, board.Player.Flip())
+		}
+		if null.MoveNumber != board.MoveNumber || null.DrawCounter != board.DrawCounter {
+			t.Fatalf("Test %d: expected unchanged move counters\n", i)
+		}
+
+		nullMoves, opponentMoves := MoveList{}, MoveList{}
+		null.GenerateAllLegalMoves(&nullMoves)
+		opponent.GenerateAllLegalMoves(&opponentMoves)
+		if nullMoves.String() != opponentMoves.String() {
+			t.Fatalf("Test %d: expected opponent moves %s but got %s\n", i, &opponentMoves, &nullMoves)
+		}
+	}
+}
+
+func TestTogglePlayer(t *testing.T) {
+	board := NewBoard()
+	board.TogglePlayer()
+	if board.Player != BLACK || board.Hash != board.ComputeHash() {
+		t.Fatalf("Expected black to move with hash %x but got %x\n", board.ComputeHash(), board.Hash)
+	}
+
+	black := NewBoard()
+	if err := black.SetFEN("rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR b KQkq - 0 1"); err != nil {
+		t.Fatalf(err.Error())
+	}
+	mlist, expected := MoveList{}, MoveList{}
+	board.GenerateAllLegalMoves(&mlist)
+	black.GenerateAllLegalMoves(&expected)
+	if mlist.Size != 20 || mlist.String() != expected.String() {
+		t.Fatalf("Expected black moves %s but got %s\n", &expected, &mlist)
+	}
+
+	// The e.p. square is cleared.
+	if err := board.SetFEN("rnbqkbnr/ppp1pppp/8/8/3pP3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1"); err != nil {
+		t.Fatalf(err.Error())
+	}
+	board.TogglePlayer()
+	if board.Player != WHITE || board.EpSquare != OTB || board.Hash != board.ComputeHash() {
+		t.Fatalf("Expected white to move without e.p. square\n")
+	}
+
+	// The checks of the new side to move are detected right away.
+	if err := board.SetFEN("4k3/8/8/8/8/8/8/4RK2 w - - 0 1"); err != nil {
+		t.Fatalf(err.Error())
+	}
+	board.TogglePlayer()
+	if board.CheckInfo == CHECK_NONE {
+		t.Fatalf("Expected black to be in check\n")
+	}
+}
+
+func TestDrawCounter(t *testing.T) {
+	type set struct {
+		Move        string
+		DrawCounter uint16
+	}
+
+	// Starts at a draw counter of 5.
+	testset := []set{
+		set{"g1f3", 6}, // quiet move
+		set{"d7d5", 0}, // pawn move
+		set{"f3e5", 1}, // quiet move
+		set{"b8c6", 2}, // quiet move
+		set{"e5c6", 0}, // capture
+		set{"b7c6", 0}, // pawn capture
+		set{"e1e2", 1}, // king move
+		set{"c8g4", 2}, // quiet move
+		set{"e2e1", 3}, // king move
+		set{"g4e2", 4}, // quiet move
+		set{"f1e2", 0}, // capture
+	}
+
+	board := NewBoard()
+	if err := board.SetFEN("rnbqkbnr/pppppppp/8/8/8/8/PPPP1PPP/RNBQKBNR w KQkq - 5 10"); err != nil {
+		t.Fatalf(err.Error())
+	}
+	for i, ts := range testset {
+		m, err := ParseMiniNotation(ts.Move)
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		board.MakeLegalMove(m)
+		if board.DrawCounter != ts.DrawCounter {
+			t.Fatalf("Move %d (%s): expected draw counter %d but got %d\n", i, ts.Move, ts.DrawCounter, board.DrawCounter)
+		}
+	}
+}
+
+func TestDrawCounterPawnSpecials(t *testing.T) {
+	type set struct {
+		FEN  string
+		Move string
+	}
+
+	testset := []set{
+		set{"4k3/P7/8/8/8/8/8/4K3 w - - 17 40", "a7a8q"},   // promotion
+		set{"1n2k3/P7/8/8/8/8/8/4K3 w - - 17 40", "a7b8n"}, // capture promotion
+		set{"4k3/8/8/3pP3/8/8/8/4K3 w - d6 17 40", "e5d6"}, // e.p. capture
+		set{"4k3/8/8/8/8/8/6p1/4K3 b - - 23 40", "g2g1r"},  // black promotion
+	}
+
+	for _, ts := range testset {
+		board := NewBoard()
+		if err := board.SetFEN(ts.FEN); err != nil {
+			t.Fatalf(err.Error())
+		}
+		m, err := ParseMiniNotation(ts.Move)
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		if !board.IsLegalMove(m) {
+			t.Fatalf("%s: move %s is illegal\n", ts.FEN, ts.Move)
+		}
+		board.MakeLegalMove(m)
+		if board.DrawCounter != 0 {
+			t.Fatalf("%s: expected draw counter 0 after %s but got %d\n", ts.FEN, ts.Move, board.DrawCounter)
+		}
+	}
+}
+
+func TestPieceCount(t *testing.T) {
+	board := NewBoard()
+
+	expected := map[Piece]int{
+		PAWN:   8,
+		KNIGHT: 2,
+		BISHOP: 2,
+		ROOK:   2,
+		QUEEN:  1,
+		KING:   1,
+	}
+	summary := board.MaterialSummary()
+	if len(summary) != 2*len(expected) {
+		t.Fatalf("Expected %d entries in material summary but got %d\n", 2*len(expected), len(summary))
+	}
+	for color := BLACK; color <= WHITE; color++ {
+		for ptype, n := range expected {
+			piece := ptype | color
+			if board.PieceCount(piece) != n || summary[piece] != n {
+				t.Fatalf("Expected %d pieces %s but got %d (summary %d)\n", n, PrintMap[piece], board.PieceCount(piece), summary[piece])
+			}
+		}
+	}
+
+	// White is up a knight against two pawns and has promoted a queen.
+	if err := board.SetFEN("4k3/pppppp2/8/8/8/8/PPPPPPPP/1NQQK3 w - - 0 1"); err != nil {
+		t.Fatalf(err.Error())
+	}
+	if board.PieceCount(WQUEEN) != 2 || board.PieceCount(BQUEEN) != 0 || board.PieceCount(BPAWN) != 6 || board.PieceCount(WKNIGHT) != 1 {
+		t.Fatalf("Wrong piece counts for position %s\n", board.FEN())
+	}
+}
+
+func TestOccupiedSquares(t *testing.T) {
+	board := NewBoard()
+
+	squares := board.OccupiedSquares(WHITE)
+	if len(squares) != 16 {
+		t.Fatalf("Expected 16 white squares but got %d: %v\n", len(squares), squares)
+	}
+	seen := map[string]bool{}
+	for _, sq := range squares {
+		if len(sq) != 2 || sq[0] < 'a' || sq[0] > 'h' || (sq[1] != '1' && sq[1] != '2') || seen[sq] {
+			t.Fatalf("Expected white to occupy ranks 1 and 2 exactly but got %v\n", squares)
+		}
+		seen[sq] = true
+	}
+
+	if err := board.SetFEN("4k3/8/8/8/4P3/8/8/4K2R b K - 0 1"); err != nil {
+		t.Fatalf(err.Error())
+	}
+	if squares := strings.Join(board.OccupiedSquares(WHITE), " "); squares != "e1 h1 e4" {
+		t.Fatalf("Expected white on e1 h1 e4 but got %s\n", squares)
+	}
+	if squares := strings.Join(board.OccupiedSquares(BLACK), " "); squares != "e8" {
+		t.Fatalf("Expected black on e8 but got %s\n", squares)
+	}
+}
+
+func TestGenerateDropMoves(t *testing.T) {
+	type set struct {
+		Fen   string
+		Hand  []Piece
+		Drops int
+	}
+
+	testset := []set{
+		// Pawns are not dropped on the first and last rank.
+		set{"4k3/8/8/8/8/8/8/4K3 w - - 0 1", []Piece{PAWN}, 48},
+		set{"4k3/8/8/8/8/8/8/4K3 b - - 0 1", []Piece{PAWN, KNIGHT}, 48 + 62},
+		// The rook check can only be blocked on b1, c1 and d1.
+		set{"4k3/8/8/8/8/8/8/r3K3 w - - 0 1", []Piece{PAWN, KNIGHT}, 3},
+		// A knight check cannot be blocked.
+		set{"4k3/8/8/8/8/3n4/8/4K3 w - - 0 1", []Piece{QUEEN}, 0},
+		// Nothing helps against a double check.
+		set{"4k3/8/8/8/8/3n4/8/r3K3 w - - 0 1", []Piece{QUEEN}, 0},
+		// Without pieces in the hand there are no drops.
+		set{"4k3/8/8/8/8/8/8/4K3 w - - 0 1", []Piece{}, 0},
+	}
+
+	for i, ts := range testset {
+		board := NewBoard()
+		if err := board.SetFEN(ts.Fen); err != nil {
+			t.Fatalf(err.Error())
+		}
+		board.Crazyhouse = true
+		for _, ptype := range ts.Hand {
+			board.Hand[board.Player][ptype.TypeIndex()]++
+		}
+
+		mlist := MoveList{}
+		board.GenerateAllLegalMoves(&mlist)
+		drops := 0
+		for k := uint32(0); k < mlist.Size; k++ {
+			m := mlist.Moves[k]
+			if !m.IsDrop() {
+				continue
+			}
+			drops++
+			if m.DroppedPiece() == PAWN && (m.To().Rank() == 0 || m.To().Rank() == 7) {
+				t.Fatalf("Test %d: pawn dropped on the back rank with %s\n", i, m.MiniNotation())
+			}
+			cpy := board
+			cpy.MakeLegalMove(m)
+			if cpy.InCheck(board.Player) {
+				t.Fatalf("Test %d: drop %s leaves the king in check\n", i, m.MiniNotation())
+			}
+		}
+		if drops != ts.Drops {
+			t.Fatalf("Test %d: expected %d drops but got %d\n", i, ts.Drops, drops)
+		}
+	}
+
+	// Captured pieces go to the hand and can be dropped again.
+	board := NewBoard()
+	if err := board.SetFEN("4k3/8/8/3p4/4P3/8/8/4K3 w - - 0 1"); err != nil {
+		t.Fatalf(err.Error())
+	}
+	board.Crazyhouse = true
+	capture := parseTestMove(t, "e4d5")
+	board.MakeLegalMove(capture)
+	if board.Hand[WHITE][PAWN.TypeIndex()] != 1 {
+		t.Fatalf("Expected a pawn in the hand of white but got %v\n", board.Hand[WHITE])
+	}
+	board.MakeLegalMove(parseTestMove(t, "e8e7"))
+	drop, err := ParseMiniNotation("P@e6")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if err := board.MakeLegalMoveChecked(drop); err != nil {
+		t.Fatalf(err.Error())
+	}
+	if board.Squares[drop.To()] != WPAWN || board.Hand[WHITE][PAWN.TypeIndex()] != 0 || board.Hash != board.ComputeHash() {
+		t.Fatalf("Expected the pawn on e6 and an empty hand but got %s %v\n", board.FEN(), board.Hand[WHITE])
+	}
+	if err := board.MakeLegalMoveChecked(drop); err != ErrNoPieceToMove {
+		t.Fatalf("Expected ErrNoPieceToMove for a drop without piece in the hand but got %v\n", err)
+	}
+
+	board = NewBoard()
+	if err := board.SetFEN("4k3/8/8/3p4/4P3/8/8/4K3 w - - 0 1"); err != nil {
+		t.Fatalf(err.Error())
+	}
+	board.Crazyhouse = true
+	reference := board
+	undo := board.MakeMoveWithUndo(capture)
+	board.UnmakeMove(capture, undo)
+	if !board.Equals(&reference) || board.Hash != reference.Hash {
+		t.Fatalf("Expected the hand to be restored but got %v\n", board.Hand)
+	}
+	// Setting a new position empties the hands.
+	board.MakeLegalMove(capture)
+	if err := board.SetFEN("rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"); err != nil {
+		t.Fatalf(err.Error())
+	}
+	mlist := MoveList{}
+	board.GenerateAllLegalMoves(&mlist)
+	if mlist.Size != 20 || board.Hand != [2][7]int{} || board.Hash != board.ComputeHash() {
+		t.Fatalf("Expected 20 moves and empty hands but got %d moves and %v\n", mlist.Size, board.Hand)
+	}
+}
+
+func TestDropNotation(t *testing.T) {
+	for _, str := range []string{"P@e4", "N@a1", "B@h8", "R@d5", "Q@c3"} {
+		m, err := ParseMiniNotation(str)
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		if !m.IsDrop() || m.From() != m.To() || m.PromotedPiece() != NONE || m.MiniNotation() != str {
+			t.Fatalf("Expected drop %s but got %s\n", str, m.MiniNotation())
+		}
+	}
+	if m, err := ParseMiniNotation("n@f3"); err != nil || m.DroppedPiece() != KNIGHT || m.MiniNotation() != "N@f3" {
+		t.Fatalf("Expected lower case drops to be accepted but got %v\n", err)
+	}
+	for _, str := range []string{"K@e4", "P@e9", " @e4", "P@e4q", "X@e4"} {
+		if _, err := ParseMiniNotation(str); err != ErrInvalidMoveNotation {
+			t.Fatalf("Expected ErrInvalidMoveNotation for %q but got %v\n", str, err)
+		}
+	}
+	if m := NewBitMove(0x14, 0x34, NONE); m.IsDrop() || m.DroppedPiece() != NONE {
+		t.Fatalf("Expected e2e4 not to be a drop\n")
+	}
+}
+
+func TestPlacePiece(t *testing.T) {
+	board := NewBoard()
+
+	// Replace the queen by a knight, remove pawns and the h1 rook and move the king.
+	edits := []struct {
+		Square Square
+		Piece  Piece
+	}{
+		{0x03, WKNIGHT},
+		{0x14, EMPTY},
+		{0x64, EMPTY},
+		{0x07, EMPTY},
+		{0x04, EMPTY},
+		{0x05, WKING},
+		{0x44, BPAWN},
+		{0x33, WQUEEN},
+	}
+	for i, edit := range edits {
+		var err error
+		if edit.Piece == EMPTY {
+			err = board.ClearSquare(edit.Square)
+		} else {
+			err = board.PlacePiece(edit.Square, edit.Piece)
+		}
+		if err != nil {
+			t.Fatalf("Edit %d: %s\n", i, err.Error())
+		}
+		checkPieceLists(t, &board)
+	}
+
+	fen := "rnbqkbnr/pppp1ppp/8/4p3/3Q4/8/PPPP1PPP/RNBN1KN1 w kq - 0 1"
+	if board.FEN() != fen {
+		t.Fatalf("Expected FEN %s after edits but got %s\n", fen, board.FEN())
+	}
+
+	// Rejected edits do not change the board.
+	invalid := []struct {
+		Square Square
+		Piece  Piece
+		Err    error
+	}{
+		{0x08, WQUEEN, ErrSquareOffBoard},
+		{0x24, EMPTY, ErrPieceInvalid},
+		{0x24, KING | PAWN, ErrPieceInvalid},
+		{0x24, WKING, ErrSecondKing},
+		{0x74, WPAWN, ErrPawnOnBackRank},
+		{0x02, BPAWN, ErrPawnOnBackRank},
+	}
+	for i, edit := range invalid {
+		if err := board.PlacePiece(edit.Square, edit.Piece); err != edit.Err {
+			t.Fatalf("Invalid edit %d: expected error %v but got %v\n", i, edit.Err, err)
+		}
+		if board.FEN() != fen {
+			t.Fatalf("Invalid edit %d changed the board to %s\n", i, board.FEN())
+		}
+	}
+	if err := board.ClearSquare(0x7F); err != ErrSquareOffBoard {
+		t.Fatalf("Expected error %v but got %v\n", ErrSquareOffBoard, err)
+	}
+
+	// The edited position is playable.
+	board.DetectChecksAndPins(board.Player)
+	if nodes := board.Perft(2); nodes == 0 {
+		t.Fatalf("Expected legal moves in edited position\n")
+	}
+}
+
+// checkPieceLists verifies that the piece lists, kings and hash match the board.
+func checkPieceLists(t *testing.T, b *Board) {
+	counts := map[Piece]int{}
+	for _, sq := range Lookup0x88 {
+		piece := b.Squares[sq]
+		if piece.IsEmpty() {
+			continue
+		}
+		counts[piece]++
+		if piece&PIECE_MASK == KING && b.Kings[piece.PieceColor()] != sq {
+			t.Fatalf("King on %s is not registered\n", PrintBoardIndex[sq])
+		}
+	}
+	for piece, n := range b.MaterialSummary() {
+		if counts[piece] != n {
+			t.Fatalf("Piece list of %s has %d entries but the board has %d pieces\n", PrintMap[piece], n, counts[piece])
+		}
+	}
+	for color := BLACK; color <= WHITE; color++ {
+		if int(b.Sliders[color].Size) != b.PieceCount(BISHOP|color)+b.PieceCount(ROOK|color)+b.PieceCount(QUEEN|color) {
+			t.Fatalf("Slider list of color %d is inconsistent\n", color)
+		}
+	}
+	if b.Hash != b.ComputeHash() {
+		t.Fatalf("Hash is inconsistent after edit\n")
+	}
+}
+
+func TestStringFrom(t *testing.T) {
+	board := NewBoard()
+	if err := board.SetFEN("r3k3/1p6/8/8/8/8/6P1/4K2R w K - 0 1"); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	white := strings.Split(board.StringFrom(WHITE), "\n")
+	black := strings.Split(board.StringFrom(BLACK), "\n")
+
+	if board.String() != board.StringFrom(WHITE) {
+		t.Fatalf("Expected String to render from white's point of view\n")
+	}
+	if white[1] != "8 | r . . . k . . . |" || white[8] != "1 | . . . . K . . R |" {
+		t.Fatalf("Wrong white view:\n%s\n", board.StringFrom(WHITE))
+	}
+	if white[10] != "    a b c d e f g h" || black[10] != "    h g f e d c b a" {
+		t.Fatalf("Wrong file labels\n")
+	}
+
+	// Every rank of the black view is the reversed rank of the white view.
+	for i := 1; i <= 8; i++ {
+		w, b := white[i], black[9-i]
+		if w[:4] != b[:4] {
+			t.Fatalf("Expected rank label %s but got %s\n", w[:4], b[:4])
+		}
+		squares := strings.Fields(w[4 : len(w)-1])
+		mirrored := strings.Fields(b[4 : len(b)-1])
+		for j := range squares {
+			if squares[j] != mirrored[7-j] {
+				t.Fatalf("Rank %s is not mirrored: %s vs %s\n", w[:1], w, b)
+			}
+		}
+	}
+}
+
+func TestEnPassantCapturers(t *testing.T) {
+	type set struct {
+		Fen       string
+		Target    string
+		Capturers []string
+	}
+
+	testset := []set{
+		set{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", "", nil},
+		// Two pawns can capture en passant.
+		set{"4k3/8/8/3PpP2/8/8/8/4K3 w - e6 0 1", "e6", []string{"d5", "f5"}},
+		set{"4k3/8/8/8/1pP5/8/8/4K3 b - c3 0 1", "c3", []string{"b4"}},
+		// The capture would expose the king to the rook on the fifth rank.
+		set{"8/8/8/K2Pp2r/8/8/8/4k3 w - e6 0 1", "e6", []string{}},
+		// Only the king can move out of a double check.
+		set{"4k3/8/8/3Pp3/8/8/8/r3K2r w - e6 0 1", "e6", nil},
+	}
+
+	board := NewBoard()
+	for i, ts := range testset {
+		if err := board.SetFEN(ts.Fen); err != nil {
+			t.Fatalf(err.Error())
+		}
+
+		target, ok := board.EnPassantTarget()
+		if ok != (ts.Target != "") || (ok && PrintBoardIndex[target] != ts.Target) {
+			t.Fatalf("Test %d: expected e.p. target %q but got %s (%v)\n", i, ts.Target, PrintBoardIndex[target], ok)
+		}
+
+		capturers := board.EnPassantCapturers()
+		if len(capturers) != len(ts.Capturers) || (capturers == nil) != (ts.Capturers == nil) {
+			t.Fatalf("Test %d: expected capturers %v but got %v\n", i, ts.Capturers, capturers)
+		}
+		for j, sq := range capturers {
+			if PrintBoardIndex[sq] != ts.Capturers[j] {
+				t.Fatalf("Test %d: expected capturers %v but got %s at %d\n", i, ts.Capturers, PrintBoardIndex[sq], j)
+			}
+		}
+	}
+}
+
+func TestCheckers(t *testing.T) {
+	type set struct {
+		FEN      string
+		Checkers []string
+	}
+
+	testset := []set{
+		set{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", []string{}},
+		set{"4k3/8/8/8/1b6/8/8/4K3 w - - 0 1", []string{"b4"}},
+		set{"4k3/8/8/8/8/8/3p4/4K3 w - - 0 1", []string{"d2"}},
+		// Double checks by knight and rook or bishop.
+		set{"4r1k1/8/8/8/8/3n4/8/4K3 w - - 0 1", []string{"d3", "e8"}},
+		set{"4k3/8/5N2/1B6/8/8/8/4K3 b - - 0 1", []string{"f6", "b5"}},
+	}
+
+	board := NewBoard()
+	for i, ts := range testset {
+		if err := board.SetFEN(ts.FEN); err != nil {
+			t.Fatalf(err.Error())
+		}
+		checkers := board.Checkers()
+		if len(checkers) != len(ts.Checkers) {
+			t.Fatalf("Test %d: expected checkers %v but got %d\n", i, ts.Checkers, len(checkers))
+		}
+		for _, want := range ts.Checkers {
+			found := false
+			for _, sq := range checkers {
+				found = found || PrintBoardIndex[sq] == want
+			}
+			if !found {
+				t.Fatalf("Test %d: expected checker on %s\n", i, want)
+			}
+		}
+	}
+}
+
+func TestCheckState(t *testing.T) {
+	type set struct {
+		FEN         string
+		InCheck     bool
+		DoubleCheck bool
+		Checker     string
+	}
+
+	testset := []set{
+		set{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", false, false, ""},
+		set{"4k3/8/8/8/1b6/8/8/4K3 w - - 0 1", true, false, "b4"},
+		set{"4k3/8/8/8/8/8/3p4/4K3 w - - 0 1", true, false, "d2"},
+		set{"4k3/8/8/8/8/3n4/8/4K3 w - - 0 1", true, false, "d3"},
+		set{"4r1k1/8/8/8/8/3n4/8/4K3 w - - 0 1", true, true, ""},
+		set{"4k3/8/5N2/1B6/8/8/8/4K3 b - - 0 1", true, true, ""},
+	}
+
+	board := NewBoard()
+	for i, ts := range testset {
+		if err := board.SetFEN(ts.FEN); err != nil {
+			t.Fatalf(err.Error())
+		}
+		inCheck, doubleCheck, checker := board.CheckState()
+		if inCheck != ts.InCheck || doubleCheck != ts.DoubleCheck {
+			t.Fatalf("Test %d: expected check %v and double check %v but got %v and %v\n", i, ts.InCheck, ts.DoubleCheck, inCheck, doubleCheck)
+		}
+		if (ts.Checker == "" && checker != OTB) || (ts.Checker != "" && PrintBoardIndex[checker] != ts.Checker) {
+			t.Fatalf("Test %d: expected checker %q but got %q\n", i, ts.Checker, PrintBoardIndex[checker])
+		}
+	}
+
+	// The check given by the last move is decoded once it was detected.
+	if err := board.SetFEN("4k3/8/8/8/8/8/8/R3K3 w - - 0 1"); err != nil {
+		t.Fatalf(err.Error())
+	}
+	board.MakeLegalMove(parseTestMove(t, "a1a8"))
+	board.DetectChecksAndPins(board.Player)
+	if inCheck, _, checker := board.CheckState(); !inCheck || PrintBoardIndex[checker] != "a8" {
+		t.Fatalf("Expected a check by the rook on a8 after a1a8\n")
+	}
+}
+
+func TestDefenderCount(t *testing.T) {
+	type set struct {
+		Square    string
+		Color     Color
+		Defenders int
+	}
+
+	// A white pawn chain b2-c3-d4 and a black one h7-g6-f5. The rook on d1
+	// additionally defends d4.
+	board := NewBoard()
+	if err := board.SetFEN("1k6/7p/6p1/5p2/3P4/2P5/1P6/3RK3 w - - 0 1"); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	testset := []set{
+		set{"b2", WHITE, 0},
+		set{"c3", WHITE, 1},
+		set{"d4", WHITE, 2},
+		set{"h7", BLACK, 0},
+		set{"g6", BLACK, 1},
+		set{"f5", BLACK, 1},
+		// Empty squares can be defended, too.
+		set{"e2", WHITE, 1},
+		set{"d3", WHITE, 1},
+	}
+
+	for _, ts := range testset {
+		sq, err := parseFENSquare(ts.Square)
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		sq = sq.To0x88()
+		if count := board.DefenderCount(sq, ts.Color); count != ts.Defenders {
+			t.Fatalf("Expected %d defenders of %s but got %d\n", ts.Defenders, ts.Square, count)
+		}
+		if board.IsDefended(sq, ts.Color) != (ts.Defenders > 0) {
+			t.Fatalf("Expected %s to be defended: %v\n", ts.Square, ts.Defenders > 0)
+		}
+	}
+}
+
+func TestGenerateCastlingMoves(t *testing.T) {
+	type set struct {
+		FEN   string
+		Moves []string
+	}
+
+	testset := []set{
+		// Castling to both sides is allowed.
+		set{"r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1", []string{"e1g1", "e1c1"}},
+		set{"r3k2r/8/8/8/8/8/8/R3K2R b KQkq - 0 1", []string{"e8g8", "e8c8"}},
+		// Rights are missing.
+		set{"r3k2r/8/8/8/8/8/8/R3K2R w Kq - 0 1", []string{"e1g1"}},
+		// Blocked by pieces on g1 and b1.
+		set{"r3k2r/8/8/8/8/8/8/RN2K1NR w KQkq - 0 1", []string{}},
+		// The transit square f1 is attacked, b1 may be attacked on the long side.
+		set{"r3k2r/8/8/8/8/7b/8/R3K2R w KQkq - 0 1", []string{"e1c1"}},
+		set{"r3k2r/8/8/8/8/n7/8/R3K2R w KQkq - 0 1", []string{"e1g1", "e1c1"}},
+		set{"1r2k2r/8/8/8/8/8/8/R3K2R w KQk - 0 1", []string{"e1g1", "e1c1"}},
+		// The target square c1 is attacked.
+		set{"2r1k2r/8/8/8/8/8/8/R3K2R w KQk - 0 1", []string{"e1g1"}},
+		// In check.
+		set{"r3k2r/8/8/8/8/8/8/R3K1rR w KQkq - 0 1", []string{}},
+		set{"r3k2r/8/8/8/7b/8/8/R3K2R w KQkq - 0 1", []string{}},
+	}
+
+	for i, ts := range testset {
+		board := NewBoard()
+		if err := board.SetFEN(ts.FEN); err != nil {
+			t.Fatalf(err.Error())
+		}
+		board.DetectChecksAndPins(board.Player)
+		mlist := MoveList{}
+		board.generateCastlingMoves(&mlist, board.Player)
+
+		if int(mlist.Size) != len(ts.Moves) {
+			t.Fatalf("Test %d: expected castling moves %v but got %s\n", i, ts.Moves, mlist.String())
+		}
+		for k, m := range ts.Moves {
+			if mlist.Moves[k].MiniNotation() != m {
+				t.Fatalf("Test %d: expected castling moves %v but got %s\n", i, ts.Moves, mlist.String())
+			}
+		}
+	}
+}
+
+func TestQueensideCastlingAttackedSquares(t *testing.T) {
+	type set struct {
+		FEN      string
+		Castling string
+		Legal    bool
+	}
+
+	testset := []set{
+		// b1 and b8 are attacked by bishops, which does not prevent castling.
+		set{"r3k2r/8/8/8/4b3/8/8/R3K2R w KQkq - 0 1", "e1c1", true},
+		set{"r3k2r/8/8/4B3/8/8/8/R3K2R b KQkq - 0 1", "e8c8", true},
+		// d1 and d8 are attacked by bishops, so the king would cross an attacked square.
+		set{"r3k2r/8/8/8/6b1/8/8/R3K2R w KQkq - 0 1", "e1c1", false},
+		set{"r3k2r/8/8/6B1/8/8/8/R3K2R b KQkq - 0 1", "e8c8", false},
+	}
+
+	for i, ts := range testset {
+		board := NewBoard()
+		if err := board.SetFEN(ts.FEN); err != nil {
+			t.Fatalf(err.Error())
+		}
+		mlist := MoveList{}
+		board.GenerateAllLegalMoves(&mlist)
+		found := false
+		for k := uint32(0); k < mlist.Size; k++ {
+			if mlist.Moves[k].MiniNotation() == ts.Castling {
+				found = true
+			}
+		}
+		if found != ts.Legal {
+			t.Fatalf("Test %d: expected %s to be legal=%v in %s\n", i, ts.Castling, ts.Legal, ts.FEN)
+		}
+	}
+}
+
+func TestGeneratePseudoLegalForBenchmark(t *testing.T) {
+	// The knight on d2 is pinned by the queen on b4.
+	board := NewBoard()
+	if err := board.SetFEN("4k3/8/8/8/1q6/8/3N4/4K2R w K - 0 1"); err != nil {
+		t.Fatalf(err.Error())
+	}
+	legal := MoveList{}
+	board.GenerateAllLegalMoves(&legal)
+	pseudo := MoveList{}
+	board.GeneratePseudoLegalForBenchmark(&pseudo, board.Player)
+
+	moves := map[string]bool{}
+	for i := uint32(0); i < pseudo.Size; i++ {
+		moves[pseudo.Moves[i].MiniNotation()] = true
+	}
+	for i := uint32(0); i < legal.Size; i++ {
+		if !moves[legal.Moves[i].MiniNotation()] {
+			t.Fatalf("Expected legal move %s among the pseudo-legal moves %s\n", legal.Moves[i].MiniNotation(), pseudo.String())
+		}
+	}
+	// Moves of the pinned knight are not filtered.
+	if !moves["d2f3"] || pseudo.Size <= legal.Size {
+		t.Fatalf("Expected the pinned knight move d2f3 in %s\n", pseudo.String())
+	}
+
+	// The pin is cleared from the info board until it is detected again.
+	board.DetectChecksAndPins(board.Player)
+	again := MoveList{}
+	board.GenerateAllLegalMoves(&again)
+	if again.Size != legal.Size {
+		t.Fatalf("Expected %d legal moves but got %s\n", legal.Size, again.String())
+	}
+}
+
+func TestKnightBlocksSliderCheck(t *testing.T) {
+	type set struct {
+		FEN   string
+		Moves []string
+	}
+
+	testset := []set{
+		// The knight can block the rook's check on e2 or e4.
+		set{"4r2k/8/8/8/8/2N5/8/4K3 w - - 0 1", []string{"c3e2", "c3e4", "e1d1", "e1d2", "e1f1", "e1f2"}},
+		// The knight is pinned by the bishop and must not block.
+		set{"4r2k/8/8/8/1b6/8/3N4/4K3 w - - 0 1", []string{"e1d1", "e1f1", "e1f2"}},
+		// The knight cannot reach the line of the check, only the king can move.
+		set{"4r2k/8/8/8/8/8/N7/4K3 w - - 0 1", []string{"e1d1", "e1d2", "e1f1", "e1f2"}},
+		// Diagonal check by a bishop, which the knight blocks on c3 or d2.
+		set{"7k/8/8/8/1b6/8/8/1N2K3 w - - 0 1", []string{"b1c3", "b1d2", "e1d1", "e1e2", "e1f1", "e1f2"}},
+	}
+
+	for i, ts := range testset {
+		board := NewBoard()
+		if err := board.SetFEN(ts.FEN); err != nil {
+			t.Fatalf(err.Error())
+		}
+		mlist := MoveList{}
+		board.GenerateAllLegalMoves(&mlist)
+		moves := []string{}
+		for k := uint32(0); k < mlist.Size; k++ {
+			moves = append(moves, mlist.Moves[k].MiniNotation())
+		}
+		sort.Strings(moves)
+		if strings.Join(moves, " ") != strings.Join(ts.Moves, " ") {
+			t.Fatalf("Test %d: expected moves %v but got %v\n", i, ts.Moves, moves)
+		}
+	}
+}
+
+func TestMakeLegalMoveChecked(t *testing.T) {
+	type set struct {
+		Move string
+		Err  error
+	}
+
+	// The white king is in check by the queen, which is only possible after a broken move.
+	fen := "4k3/8/8/8/8/8/4q3/R3K3 b Q - 0 1"
+	testset := []set{
+		set{"e2e1", ErrKingCapture},
+		set{"a1a2", ErrNoPieceToMove},
+		set{"d4d5", ErrNoPieceToMove},
+		set{"e2a2", nil},
+	}
+
+	for _, ts := range testset {
+		board := NewBoard()
+		if err := board.SetFEN(fen); err != nil {
+			t.Fatalf(err.Error())
+		}
+		before := board
+		err := board.MakeLegalMoveChecked(parseTestMove(t, ts.Move))
+		if err != ts.Err {
+			t.Fatalf("Move %s: expected error %v but got %v\n", ts.Move, ts.Err, err)
+		}
+		if err != nil && board != before {
+			t.Fatalf("Move %s: the board was changed despite the error\n", ts.Move)
+		}
+		if err == nil && board == before {
+			t.Fatalf("Move %s: the move was not made\n", ts.Move)
+		}
+	}
+}
+
+func TestIsAttackedByType(t *testing.T) {
+	type set struct {
+		Square   string
+		Color    Color
+		Type     Piece
+		Attacked bool
+	}
+
+	// d5 is attacked by the pawn on e4, the knight on f6 and the bishop on a8.
+	// The rook on d1 is blocked by the pawn on d3.
+	board := NewBoard()
+	if err := board.SetFEN("b6k/8/5N2/8/4P3/3P4/8/3RK3 w - - 0 1"); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	testset := []set{
+		set{"d5", WHITE, PAWN, true},
+		set{"d5", WHITE, KNIGHT, true},
+		set{"d5", WHITE, BISHOP | ROOK | QUEEN, false},
+		set{"d5", BLACK, BISHOP | ROOK | QUEEN, true},
+		set{"d5", BLACK, BISHOP, true},
+		set{"d5", BLACK, ROOK | QUEEN, false},
+		set{"d5", BLACK, PAWN | KNIGHT, false},
+		set{"d2", WHITE, ROOK, true},
+		set{"d4", WHITE, ROOK, false},
+		set{"e5", WHITE, PAWN, false},
+		set{"f5", WHITE, PAWN, true},
+		set{"g8", WHITE, KNIGHT, true},
+		set{"g8", WHITE, PAWN | BISHOP | ROOK | QUEEN | KING, false},
+		set{"g7", BLACK, KING, true},
+	}
+
+	for _, ts := range testset {
+		sq, err := parseFENSquare(ts.Square)
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		if board.IsAttackedByType(sq.To0x88(), ts.Color, ts.Type) != ts.Attacked {
+			t.Fatalf("Expected attack on %s by type %d of color %d: %v\n", ts.Square, ts.Type, ts.Color, ts.Attacked)
+		}
+	}
+}
+
+func TestGenerateLegalMovesFor(t *testing.T) {
+	type set struct {
+		Fen     string
+		Flipped string
+	}
+
+	testset := []set{
+		set{"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1",
+			"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R b KQkq - 0 1"},
+		// The e.p. square only belongs to the side to move.
+		set{"rnbqkbnr/ppp1p1pp/8/3pPp2/8/8/PPPP1PPP/RNBQKBNR w KQkq f6 0 3",
+			"rnbqkbnr/ppp1p1pp/8/3pPp2/8/8/PPPP1PPP/RNBQKBNR b KQkq - 0 3"},
+		// The black knight is pinned by the bishop.
+		set{"4k3/8/2n5/1B6/8/8/8/4K3 w - - 0 1", "4k3/8/2n5/1B6/8/8/8/4K3 b - - 0 1"},
+	}
+
+	for i, ts := range testset {
+		board := NewBoard()
+		if err := board.SetFEN(ts.Fen); err != nil {
+			t.Fatalf(err.Error())
+		}
+		own := MoveList{}
+		board.GenerateAllLegalMoves(&own)
+		checkInfo := board.CheckInfo
+
+		mlist := MoveList{}
+		board.GenerateLegalMovesFor(&mlist, board.Player.Flip())
+
+		flipped := NewBoard()
+		if err := flipped.SetFEN(ts.Flipped); err != nil {
+			t.Fatalf(err.Error())
+		}
+		expected := MoveList{}
+		flipped.GenerateAllLegalMoves(&expected)
+
+		moves := map[BitMove]bool{}
+		for k := uint32(0); k < mlist.Size; k++ {
+			moves[mlist.Moves[k]] = true
+		}
+		if mlist.Size != expected.Size {
+			t.Fatalf("Test %d: expected moves %s but got %s\n", i, expected.String(), mlist.String())
+		}
+		for k := uint32(0); k < expected.Size; k++ {
+			if !moves[expected.Moves[k]] {
+				t.Fatalf("Test %d: expected moves %s but got %s\n", i, expected.String(), mlist.String())
+			}
+		}
+
+		// The side to move and its moves are unchanged.
+		again := MoveList{}
+		board.GenerateAllLegalMoves(&again)
+		if board.FEN() != ts.Fen || board.CheckInfo != checkInfo || again.Size != own.Size {
+			t.Fatalf("Test %d: expected the board to be unchanged but got %s\n", i, board.FEN())
+		}
+	}
+
+	// The king in check cannot be captured by the side not to move.
+	board := NewBoard()
+	if err := board.SetFEN("4k3/8/8/8/8/8/8/4R1K1 b - - 0 1"); err != nil {
+		t.Fatalf(err.Error())
+	}
+	mlist := MoveList{}
+	board.GenerateLegalMovesFor(&mlist, WHITE)
+	capture := parseTestMove(t, "e1e8")
+	for k := uint32(0); k < mlist.Size; k++ {
+		if mlist.Moves[k] == capture {
+			t.Fatalf("Expected no king capture but got %s\n", mlist.String())
+		}
+	}
+	if mlist.Size == 0 {
+		t.Fatalf("Expected the other moves of white\n")
+	}
+}
+
+func TestCastlingRightsLoss(t *testing.T) {
+	type set struct {
+		Fen    string
+		Moves  []string
+		Rights string
+	}
+
+	testset := []set{
+		// A knight captures the rook on h8.
+		set{"r3k2r/8/6N1/8/8/8/8/R3K2R w KQkq - 0 1", []string{"g6h8"}, "KQq"},
+		// A knight captures the rook on a1.
+		set{"r3k2r/8/8/8/8/1n6/8/R3K2R b KQkq - 0 1", []string{"b3a1"}, "Kkq"},
+		// A pawn captures the rook on a8 and promotes.
+		set{"r3k2r/1P6/8/8/8/8/8/R3K2R w KQkq - 0 1", []string{"b7a8q"}, "KQk"},
+		// A pawn captures the rook on h1 and underpromotes.
+		set{"r3k2r/8/8/8/8/8/6p1/R3K2R b KQkq - 0 1", []string{"g2h1n"}, "Qkq"},
+		// The rooks move and return, the rights stay lost.
+		set{"r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1", []string{"h1h2", "a8a7", "h2h1", "a7a8"}, "Qk"},
+		// The king moves and returns.
+		set{"r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1", []string{"e1f1", "e8d8", "f1e1", "d8e8"}, "-"},
+	}
+
+	for i, ts := range testset {
+		board := NewBoard()
+		if err := board.SetFEN(ts.Fen); err != nil {
+			t.Fatalf(err.Error())
+		}
+		for _, m := range ts.Moves {
+			if err := board.MakeLegalMoveChecked(parseTestMove(t, m)); err != nil {
+				t.Fatalf("Test %d: move %s failed: %s\n", i, m, err.Error())
+			}
+		}
+		if rights := strings.Fields(board.FEN())[2]; rights != ts.Rights {
+			t.Fatalf("Test %d: expected castling rights %s but got %s\n", i, ts.Rights, rights)
+		}
+		if board.Hash != board.ComputeHash() {
+			t.Fatalf("Test %d: expected hash %x but got %x\n", i, board.ComputeHash(), board.Hash)
+		}
+	}
+}
+
+func TestUnmakeMove(t *testing.T) {
+	fens := []string{
+		"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1",
+		"r3k2r/Pppp1ppp/1b3nbN/nP6/BBP1P3/q4N2/Pp1P2PP/R2Q1RK1 w kq - 0 1",
+		"n1n5/PPPk4/8/8/8/8/4Kppp/5N1N b - - 0 1",
+		"rnbqkbnr/ppp1p1pp/8/3pPp2/8/8/PPPP1PPP/RNBQKBNR w KQkq f6 0 3",
+	}
+
+	// Every legal move of random games is made and taken back.
+	rnd := rand.New(rand.NewSource(1))
+	for _, fen := range fens {
+		board := NewBoard()
+		if err := board.SetFEN(fen); err != nil {
+			t.Fatalf(err.Error())
+		}
+		for ply := 0; ply < 30; ply++ {
+			mlist := MoveList{}
+			board.GenerateAllLegalMoves(&mlist)
+			if mlist.Size == 0 {
+				break
+			}
+			before := board.FEN()
+			cpy := board
+			for i := uint32(0); i < mlist.Size; i++ {
+				m := mlist.Moves[i]
+				undo := board.MakeMoveWithUndo(m)
+				board.UnmakeMove(m, undo)
+				if !board.Equals(&cpy) || board.FEN() != before || board.Hash != cpy.Hash || board.CheckInfo != cpy.CheckInfo {
+					t.Fatalf("Expected %s after unmaking %s but got %s\n", before, m.MiniNotation(), board.FEN())
+				}
+				checkPieceLists(t, &board)
+			}
+			board.MakeLegalMove(mlist.Moves[rnd.Intn(int(mlist.Size))])
+		}
+	}
+}
+
+func TestGenerateKingMovesBatched(t *testing.T) {
+	fens := []string{
+		"1q1r1b1k/r1q2b2/8/8/8/8/8/R3K2R w KQ - 0 1",
+		"1r5k/8/b7/q7/b7/6K1/8/1r6 w - - 0 1",
+		"r3k2r/pppq1ppp/2npbn2/2b1p3/2B1P3/2NPBN2/PPPQ1PPP/R3K2R w KQkq - 4 8",
+		"r3k2r/8/8/8/8/7b/8/R3K2R w KQkq - 0 1",
+	}
+	boards := []Board{}
+	for _, fen := range fens {
+		board := NewBoard()
+		if err := board.SetFEN(fen); err != nil {
+			t.Fatalf(err.Error())
+		}
+		boards = append(boards, board)
+	}
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		boards = append(boards, RandomLegalPosition(rng, 2+i%31))
+	}
+
+	for _, board := range boards {
+		board.DetectChecksAndPins(board.Player)
+		batched := MoveList{}
+		board.GenerateKingMoves(&batched, board.Player)
+		unbatched := MoveList{}
+		kingMovesUnbatched(&board, &unbatched, board.Player)
+		if batched.String() != unbatched.String() {
+			t.Fatalf("Expected king moves %s but got %s in %s\n", unbatched.String(), batched.String(), board.FEN())
+		}
+	}
+}
+
+func TestWouldLeaveKingInCheck(t *testing.T) {
+	type set struct {
+		FEN    string
+		Move   string
+		Result bool
+	}
+
+	testset := []set{
+		// The knight on d2 is pinned by the bishop on b4.
+		set{"4k3/8/8/8/1b6/8/3N4/4K3 w - - 0 1", "d2f3", true},
+		set{"4k3/8/8/8/1b6/8/3N4/4K3 w - - 0 1", "e1f1", false},
+		// The king walks into the rook's file or captures the undefended rook.
+		set{"4k3/8/8/8/8/8/8/3rK3 w - - 0 1", "e1d2", true},
+		set{"4k3/8/8/8/8/8/8/3rK3 w - - 0 1", "e1d1", false},
+		// Capturing en passant exposes the king on the fifth rank.
+		set{"4k3/8/8/K2pP2r/8/8/8/8 w - d6 0 1", "e5d6", true},
+		set{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", "e2e4", false},
+	}
+
+	board := NewBoard()
+	for i, ts := range testset {
+		if err := board.SetFEN(ts.FEN); err != nil {
+			t.Fatalf(err.Error())
+		}
+		before := board
+		if result := board.WouldLeaveKingInCheck(parseTestMove(t, ts.Move)); result != ts.Result {
+			t.Fatalf("Test %d: expected %v for %s but got %v\n", i, ts.Result, ts.Move, result)
+		}
+		if !board.Equals(&before) {
+			t.Fatalf("Test %d: board was changed\n", i)
+		}
+	}
+}
@@ -1,8 +1,10 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"math/rand"
+	"os"
 	"time"
 
 	"github.com/dbriemann/chesskimo"
@@ -10,14 +12,58 @@ import (
 
 var version = "undefined"
 
+var (
+	fen    = flag.String("fen", "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", "position for perft")
+	depth  = flag.Int("depth", 0, "run perft to this depth on the position given by -fen instead of the UCI engine")
+	divide = flag.Bool("divide", false, "print the perft node count of every root move")
+)
+
 func main() {
+	flag.Parse()
 	rand.Seed(time.Now().UnixNano())
 	fmt.Println("Chesskimo", version)
 
+	if *depth > 0 {
+		if err := perft(*fen, *depth, *divide); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	uci := &chesskimo.UCI{}
-	engine := chesskimo.NewEngine("Chesskimo "+version+" 2018", "David Linus Briemann", uci, chesskimo.SimpleMCSearch)
-	// engine := chesskimo.NewEngine("Chesskimo "+version+" 2019", "David Linus Briemann", uci, chesskimo.AlphaBetaSearch)
+	// engine := chesskimo.NewEngine("Chesskimo", "David Linus Briemann", version, uci, chesskimo.SimpleMCSearch)
+	engine := chesskimo.NewEngine("Chesskimo", "David Linus Briemann", version, uci, chesskimo.IterativeDeepening)
 
 	// Input/output runs until exit.
 	engine.Run()
 }
+
+// perft prints the number of leaf nodes of the move tree of 'fen' to the given depth,
+// with 'divide' split up by root moves.
+func perft(fen string, depth int, divide bool) error {
+	start := time.Now()
+	if !divide {
+		nodes, err := chesskimo.PerftFromFEN(fen, depth)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("nodes %d time %s\n", nodes, time.Since(start))
+		return nil
+	}
+
+	board := chesskimo.NewBoard()
+	if err := board.SetFEN(fen); err != nil {
+		return err
+	}
+	if depth > chesskimo.MAX_PERFT_DEPTH {
+		return fmt.Errorf("%w: %d", chesskimo.ErrPerftDepthInvalid, depth)
+	}
+	total := uint64(0)
+	for _, entry := range board.PerftDivideSorted(depth) {
+		fmt.Printf("%s: %d\n", entry.Move, entry.Nodes)
+		total += entry.Nodes
+	}
+	fmt.Printf("nodes %d time %s\n", total, time.Since(start))
+	return nil
+}
@@ -0,0 +1,119 @@
+package chesskimo
+
+import (
+	"strings"
+)
+
+// ECOLine is an opening of the Encyclopaedia of Chess Openings with its defining moves
+// in coordinate notation, starting from the default starting position.
+type ECOLine struct {
+	ECO   string
+	Name  string
+	Moves string
+}
+
+// ECOLines is a compact table of common openings. Lines may extend each other, the
+// longest line matching a game is the most specific classification.
+var ECOLines = []ECOLine{
+	{"A01", "Nimzo-Larsen Attack", "b2b3"},
+	{"A02", "Bird's Opening", "f2f4"},
+	{"A04", "Reti Opening", "g1f3"},
+	{"A10", "English Opening", "c2c4"},
+	{"A40", "Queen's Pawn Game", "d2d4"},
+	{"A45", "Indian Defense", "d2d4 g8f6"},
+	{"A50", "Indian Defense", "d2d4 g8f6 c2c4"},
+	{"A56", "Benoni Defense", "d2d4 g8f6 c2c4 c7c5"},
+	{"A80", "Dutch Defense", "d2d4 f7f5"},
+	{"B00", "King's Pawn Opening", "e2e4"},
+	{"B01", "Scandinavian Defense", "e2e4 d7d5"},
+	{"B02", "Alekhine's Defense", "e2e4 g8f6"},
+	{"B06", "Modern Defense", "e2e4 g7g6"},
+	{"B07", "Pirc Defense", "e2e4 d7d6 d2d4 g8f6"},
+	{"B10", "Caro-Kann Defense", "e2e4 c7c6"},
+	{"B12", "Caro-Kann Defense: Advance Variation", "e2e4 c7c6 d2d4 d7d5 e4e5"},
+	{"B20", "Sicilian Defense", "e2e4 c7c5"},
+	{"B22", "Sicilian Defense: Alapin Variation", "e2e4 c7c5 c2c3"},
+	{"B23", "Sicilian Defense: Closed", "e2e4 c7c5 b1c3"},
+	{"B27", "Sicilian Defense", "e2e4 c7c5 g1f3"},
+	{"B30", "Sicilian Defense", "e2e4 c7c5 g1f3 b8c6"},
+	{"B33", "Sicilian Defense: Open", "e2e4 c7c5 g1f3 b8c6 d2d4 c5d4 f3d4 g8f6"},
+	{"B40", "Sicilian Defense", "e2e4 c7c5 g1f3 e7e6"},
+	{"B50", "Sicilian Defense", "e2e4 c7c5 g1f3 d7d6"},
+	{"B54", "Sicilian Defense: Open", "e2e4 c7c5 g1f3 d7d6 d2d4 c5d4 f3d4"},
+	{"B70", "Sicilian Defense: Dragon Variation", "e2e4 c7c5 g1f3 d7d6 d2d4 c5d4 f3d4 g8f6 b1c3 g7g6"},
+	{"B90", "Sicilian Defense: Najdorf Variation", "e2e4 c7c5 g1f3 d7d6 d2d4 c5d4 f3d4 g8f6 b1c3 a7a6"},
+	{"C00", "French Defense", "e2e4 e7e6"},
+	{"C02", "French Defense: Advance Variation", "e2e4 e7e6 d2d4 d7d5 e4e5"},
+	{"C03", "French Defense: Tarrasch Variation", "e2e4 e7e6 d2d4 d7d5 b1d2"},
+	{"C11", "French Defense: Classical Variation", "e2e4 e7e6 d2d4 d7d5 b1c3 g8f6"},
+	{"C15", "French Defense: Winawer Variation", "e2e4 e7e6 d2d4 d7d5 b1c3 f8b4"},
+	{"C20", "King's Pawn Game", "e2e4 e7e5"},
+	{"C23", "Bishop's Opening", "e2e4 e7e5 f1c4"},
+	{"C25", "Vienna Game", "e2e4 e7e5 b1c3"},
+	{"C30", "King's Gambit", "e2e4 e7e5 f2f4"},
+	{"C33", "King's Gambit Accepted", "e2e4 e7e5 f2f4 e5f4"},
+	{"C40", "King's Knight Opening", "e2e4 e7e5 g1f3"},
+	{"C41", "Philidor Defense", "e2e4 e7e5 g1f3 d7d6"},
+	{"C42", "Petrov's Defense", "e2e4 e7e5 g1f3 g8f6"},
+	{"C44", "King's Knight Opening: Normal Variation", "e2e4 e7e5 g1f3 b8c6"},
+	{"C44", "Scotch Game", "e2e4 e7e5 g1f3 b8c6 d2d4"},
+	{"C45", "Scotch Game", "e2e4 e7e5 g1f3 b8c6 d2d4 e5d4 f3d4"},
+	{"C46", "Three Knights Opening", "e2e4 e7e5 g1f3 b8c6 b1c3"},
+	{"C47", "Four Knights Game", "e2e4 e7e5 g1f3 b8c6 b1c3 g8f6"},
+	{"C50", "Italian Game", "e2e4 e7e5 g1f3 b8c6 f1c4"},
+	{"C50", "Giuoco Piano", "e2e4 e7e5 g1f3 b8c6 f1c4 f8c5"},
+	{"C51", "Evans Gambit", "e2e4 e7e5 g1f3 b8c6 f1c4 f8c5 b2b4"},
+	{"C53", "Giuoco Piano: Main Line", "e2e4 e7e5 g1f3 b8c6 f1c4 f8c5 c2c3"},
+	{"C55", "Two Knights Defense", "e2e4 e7e5 g1f3 b8c6 f1c4 g8f6"},
+	{"C60", "Ruy Lopez", "e2e4 e7e5 g1f3 b8c6 f1b5"},
+	{"C65", "Ruy Lopez: Berlin Defense", "e2e4 e7e5 g1f3 b8c6 f1b5 g8f6"},
+	{"C68", "Ruy Lopez: Exchange Variation", "e2e4 e7e5 g1f3 b8c6 f1b5 a7a6 b5c6"},
+	{"C70", "Ruy Lopez: Morphy Defense", "e2e4 e7e5 g1f3 b8c6 f1b5 a7a6 b5a4"},
+	{"C78", "Ruy Lopez: Morphy Defense", "e2e4 e7e5 g1f3 b8c6 f1b5 a7a6 b5a4 g8f6 e1g1"},
+	{"C80", "Ruy Lopez: Open Variation", "e2e4 e7e5 g1f3 b8c6 f1b5 a7a6 b5a4 g8f6 e1g1 f6e4"},
+	{"C84", "Ruy Lopez: Closed", "e2e4 e7e5 g1f3 b8c6 f1b5 a7a6 b5a4 g8f6 e1g1 f8e7"},
+	{"C88", "Ruy Lopez: Closed", "e2e4 e7e5 g1f3 b8c6 f1b5 a7a6 b5a4 g8f6 e1g1 f8e7 f1e1 b7b5 a4b3"},
+	{"D00", "Queen's Pawn Game", "d2d4 d7d5"},
+	{"D02", "Queen's Pawn Game", "d2d4 d7d5 g1f3"},
+	{"D06", "Queen's Gambit", "d2d4 d7d5 c2c4"},
+	{"D07", "Queen's Gambit Declined: Chigorin Defense", "d2d4 d7d5 c2c4 b8c6"},
+	{"D10", "Slav Defense", "d2d4 d7d5 c2c4 c7c6"},
+	{"D20", "Queen's Gambit Accepted", "d2d4 d7d5 c2c4 d5c4"},
+	{"D30", "Queen's Gambit Declined", "d2d4 d7d5 c2c4 e7e6"},
+	{"D80", "Grunfeld Defense", "d2d4 g8f6 c2c4 g7g6 b1c3 d7d5"},
+	{"E00", "Indian Defense", "d2d4 g8f6 c2c4 e7e6"},
+	{"E12", "Queen's Indian Defense", "d2d4 g8f6 c2c4 e7e6 g1f3 b7b6"},
+	{"E20", "Nimzo-Indian Defense", "d2d4 g8f6 c2c4 e7e6 b1c3 f8b4"},
+	{"E60", "King's Indian Defense", "d2d4 g8f6 c2c4 g7g6"},
+	{"E61", "King's Indian Defense", "d2d4 g8f6 c2c4 g7g6 b1c3"},
+}
+
+// ClassifyOpening returns the ECO code and name of the longest line in ECOLines
+// which the moves start with. The moves must be played from the default starting
+// position. If no line matches, ok is false.
+func ClassifyOpening(moves []BitMove) (eco string, name string, ok bool) {
+	played := make([]string, len(moves))
+	for i, m := range moves {
+		played[i] = m.MiniNotation()
+	}
+
+	longest := 0
+	for _, line := range ECOLines {
+		lineMoves := strings.Fields(line.Moves)
+		if len(lineMoves) <= longest || len(lineMoves) > len(played) {
+			continue
+		}
+		matches := true
+		for i, m := range lineMoves {
+			if played[i] != m {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			eco, name, ok = line.ECO, line.Name, true
+			longest = len(lineMoves)
+		}
+	}
+	return eco, name, ok
+}
@@ -0,0 +1,51 @@
+package chesskimo
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestClassifyOpening(t *testing.T) {
+	type set struct {
+		Moves string
+		ECO   string
+		Name  string
+	}
+
+	testset := []set{
+		set{"e2e4 e7e5 g1f3 b8c6 f1b5", "C60", "Ruy Lopez"},
+		// Moves after the end of a line do not change the classification.
+		set{"e2e4 e7e5 g1f3 b8c6 f1b5 d7d6 e1g1", "C60", "Ruy Lopez"},
+		set{"e2e4 e7e5 g1f3 b8c6 f1b5 a7a6 b5a4 g8f6 e1g1 f8e7 f1e1 b7b5 a4b3 d7d6", "C88", "Ruy Lopez: Closed"},
+		set{"e2e4 c7c5 g1f3 d7d6 d2d4 c5d4 f3d4 g8f6 b1c3 a7a6", "B90", "Sicilian Defense: Najdorf Variation"},
+		set{"d2d4 g8f6 c2c4 e7e6 b1c3 f8b4", "E20", "Nimzo-Indian Defense"},
+		set{"e2e4", "B00", "King's Pawn Opening"},
+	}
+
+	for i, ts := range testset {
+		moves := []BitMove{}
+		for _, m := range strings.Fields(ts.Moves) {
+			moves = append(moves, parseTestMove(t, m))
+		}
+		eco, name, ok := ClassifyOpening(moves)
+		if !ok || eco != ts.ECO || name != ts.Name {
+			t.Fatalf("Test %d: expected %s %s but got %s %s\n", i, ts.ECO, ts.Name, eco, name)
+		}
+	}
+
+	for _, moves := range [][]BitMove{nil, []BitMove{parseTestMove(t, "a2a3")}} {
+		if eco, _, ok := ClassifyOpening(moves); ok {
+			t.Fatalf("Expected no classification but got %s\n", eco)
+		}
+	}
+}
+
+// TestECOLines tests if all lines of the table are legal from the starting position.
+func TestECOLines(t *testing.T) {
+	for _, line := range ECOLines {
+		board := NewBoard()
+		if err := board.ApplyUCIMoves(strings.Fields(line.Moves)); err != nil {
+			t.Fatalf("%s %s: %s\n", line.ECO, line.Name, err.Error())
+		}
+	}
+}
@@ -2,9 +2,10 @@ package chesskimo
 
 import (
 	"errors"
+	"io"
+	"io/ioutil"
 	"log"
 	"os"
-	"strings"
 )
 
 const (
@@ -18,9 +19,9 @@ var (
 )
 
 type Engine struct {
-	// name of the engine including version number.
-	name   string
-	author string
+	name    string
+	author  string
+	version string
 	// protocol defines how the engine communicates with the frontend.
 	protocol Communicator
 	// atomicState defines the current state of the engine
@@ -30,16 +31,44 @@ type Engine struct {
 	board  Board
 	search SearchFun
 
+	// Eval holds the evaluation parameters used by the search.
+	Eval EvalConfig
+	// Params holds the tunable search parameters.
+	Params SearchParams
+	// lmr caches the late move reductions computed from lmrParams, which are the
+	// LMR parameters of Params at the time the table was built.
+	lmr       *lmrTable
+	lmrParams [2]float64
+
+	// counterMoves is used by the search for move ordering.
+	counterMoves CounterMoveTable
+	// history is used by the search for move ordering of quiet moves.
+	history HistoryTable
+	// captureHistory is used by the search for move ordering of captures.
+	captureHistory CaptureHistoryTable
+
+	// Output receives all protocol output like UCI responses and search info.
+	// It defaults to os.Stdout and can be replaced to embed the engine.
+	Output io.Writer
+
 	logger *log.Logger
 }
 
-func NewEngine(name, author string, protocol Communicator, searchFun SearchFun) *Engine {
+// NewEngine creates an engine which identifies itself by 'name', 'version' and 'author'
+// and communicates via 'protocol'. The version may be empty.
+func NewEngine(name, author, version string, protocol Communicator, searchFun SearchFun) *Engine {
 	e := &Engine{
 		name:     name,
 		author:   author,
+		version:  version,
 		protocol: protocol,
 		board:    NewBoard(),
 		search:   searchFun,
+		Eval:     DefaultEvalConfig,
+		Params:   DefaultSearchParams,
+		Output:   os.Stdout,
+		// Log messages are discarded until Run creates the log file.
+		logger: log.New(ioutil.Discard, "", 0),
 	}
 
 	return e
@@ -56,8 +85,22 @@ func (e *Engine) Run() {
 	e.protocol.RunInputOutputLoop(e)
 }
 
+// NewGame resets the engine for a new game. All search state which is kept
+// between searches (e.g. move ordering tables) is cleared, so no stale data
+// from a previous game influences the next one.
 func (e *Engine) NewGame() {
 	e.board = NewBoard()
+	e.counterMoves.Clear()
+	e.history.Clear()
+	e.captureHistory.Clear()
+}
+
+// FullName returns the name of the engine including its version.
+func (e *Engine) FullName() string {
+	if e.version == "" {
+		return e.name
+	}
+	return e.name + " " + e.version
 }
 
 // Quit shuts everything down gracefully and returns.
@@ -72,38 +115,18 @@ func (e *Engine) GetLegalMoves() MoveList {
 	return ml
 }
 
+// Evaluate returns all terms of the static evaluation of the current position
+// with the configuration of the engine.
+func (e *Engine) Evaluate() EvalBreakdown {
+	return e.board.EvalBreakdownWith(&e.Eval)
+}
+
 func (e *Engine) MakeMove(move string) error {
 	if len(move) >= 4 {
-		promo := NONE
-
-		from, err := parseFENSquare(move[0:2])
-		if err != nil {
-			return ErrInvalidMoveNotation
-		}
-
-		to, err := parseFENSquare(move[2:4])
+		bm, err := ParseMiniNotation(move)
 		if err != nil {
-			return ErrInvalidMoveNotation
-		}
-
-		if len(move) == 5 {
-			switch strings.ToLower(move[4:5]) {
-			case "q":
-				promo = QUEEN
-			case "r":
-				promo = ROOK
-			case "b":
-				promo = BISHOP
-			case "n":
-				promo = KNIGHT
-			default:
-				// Impossible promotion.
-				return ErrInvalidMoveNotation
-			}
+			return err
 		}
-		from = from.To0x88()
-		to = to.To0x88()
-		bm := NewBitMove(from, to, promo)
 
 		e.logger.Print("*** exec move: ", bm.MiniNotation())
 
@@ -111,7 +134,7 @@ func (e *Engine) MakeMove(move string) error {
 
 		e.logger.Print("\n" + e.board.String())
 		e.logger.Print("\n" + e.board.InfoBoardString())
-		e.board.MakeLegalMove(bm)
+		return e.board.MakeLegalMoveChecked(bm)
 	}
 
 	return nil
@@ -0,0 +1,29 @@
+package chesskimo
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNewGameClearsSearchState(t *testing.T) {
+	fen := "r1b1kb1r/pppp1ppp/5q2/4n3/3KP3/2N3PN/PPP4P/R1BQ1B1R b kq - 0 1"
+	ss := SearchSettings{MaxDepth: 4}
+
+	// Reference search with a fresh engine.
+	expected := AlphaBetaSearch(newTestEngine(fen), &ss, nil)
+
+	// Search the same position in a previous game, then start a new game.
+	engine := newTestEngine(fen)
+	AlphaBetaSearch(engine, &ss, nil)
+	engine.NewGame()
+	if err := engine.board.SetFEN(fen); err != nil {
+		t.Fatalf(err.Error())
+	}
+	result := AlphaBetaSearch(engine, &ss, nil)
+
+	// Only the elapsed time may differ.
+	result.Time = expected.Time
+	if !reflect.DeepEqual(result, expected) {
+		t.Fatalf("Expected search result %v after new game but got %v\n", expected, result)
+	}
+}
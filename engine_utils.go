@@ -1,17 +1,69 @@
 package chesskimo
 
+import (
+	"time"
+)
+
 // SearchResult contains all relevant info that should
 // be returned from a best move search.
 type SearchResult struct {
 	Move  BitMove
 	Score int
+	// Depth is the nominal depth of the deepest completed search.
 	Depth int
+	// SelDepth is the highest ply reached by any line, including quiescence search
+	// and extensions.
+	SelDepth int
+	Nodes    uint64
+	// Time is the time spent on the search.
+	Time time.Duration
+	// PV is the principal variation starting with Move.
+	PV []BitMove
+	// Ponder is the expected reply to Move, the second move of the PV. It is the
+	// zero move if the PV is shorter.
+	Ponder BitMove
+}
+
+// ponderMove returns the second move of 'pv' if it starts with 'move'.
+func ponderMove(move BitMove, pv []BitMove) BitMove {
+	if len(pv) < 2 || pv[0] != move {
+		return BitMove(0)
+	}
+	return pv[1]
 }
 
 // SearchSettings defines constraints that may exist for
 // the search.
 type SearchSettings struct {
 	MaxDepth int
+	// MoveTime limits the time used for the search. Zero means no limit.
+	MoveTime time.Duration
+	// Clock information as given by the GUI.
+	Time      [2]time.Duration
+	Increment [2]time.Duration
+	MovesToGo int
+	// ExcludeMoves are not searched at the root.
+	ExcludeMoves []BitMove
+	// DisableCounterMoves turns off the counter-move heuristic in move ordering.
+	DisableCounterMoves bool
+	// DisableKillers turns off the killer heuristic in move ordering.
+	DisableKillers bool
+	// DisableHistory turns off the history heuristic in move ordering.
+	DisableHistory bool
+	// DisableCaptureHistory turns off the capture history in move ordering.
+	DisableCaptureHistory bool
+	// DisableLMR turns off late move reductions.
+	DisableLMR bool
+	// DisableIID turns off internal iterative deepening.
+	DisableIID bool
+	// DisableSEEPruning turns off skipping losing captures in the quiescence search.
+	DisableSEEPruning bool
+	// DisableFutility turns off futility pruning of quiet moves.
+	DisableFutility bool
+	// DisableReverseFutility turns off reverse futility (static null move) pruning.
+	DisableReverseFutility bool
+	// DisableSingular turns off singular extensions.
+	DisableSingular bool
 }
 
 // SearchFun function type defines how a search function
@@ -0,0 +1,710 @@
+package chesskimo
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// Default material values in centipawns.
+	VALUE_PAWN   = 100
+	VALUE_KNIGHT = 320
+	VALUE_BISHOP = 330
+	VALUE_ROOK   = 500
+	VALUE_QUEEN  = 900
+
+	// King tropism weights per piece type. A piece scores its weight for
+	// every step it is closer to the enemy king than the maximum distance of 7.
+	TROPISM_KNIGHT = 3
+	TROPISM_BISHOP = 2
+	TROPISM_ROOK   = 2
+	TROPISM_QUEEN  = 4
+
+	// Mobility weights per piece type. A piece scores its weight for every
+	// square of the mobility area it can move to, see MobilityArea.
+	MOBILITY_KNIGHT = 4
+	MOBILITY_BISHOP = 3
+	MOBILITY_ROOK   = 2
+	MOBILITY_QUEEN  = 1
+
+	// Bonus per pair of rooks on the same file or rank without pieces in between.
+	ROOK_DOUBLED_BONUS   = 20
+	ROOK_CONNECTED_BONUS = 10
+
+	// Tarrasch rule: a rook behind a passed pawn of either color on the same file gets
+	// a bonus, a rook in front of an own passed pawn a penalty.
+	ROOK_BEHIND_PASSED_PAWN   = 20
+	ROOK_IN_FRONT_PASSED_PAWN = 15
+
+	// Bonus for a knight on an outpost, which is defended by an own pawn and cannot
+	// be attacked by enemy pawns. Outposts in the opponent's half are worth more.
+	KNIGHT_OUTPOST          = 15
+	KNIGHT_OUTPOST_ADVANCED = 30
+
+	// A passed pawn whose square in front is occupied scores PASSED_PAWN_BLOCKED_DIVISOR
+	// times less, if the square in front is attacked by the opponent it scores
+	// PASSED_PAWN_CONTROLLED_DIVISOR times less.
+	PASSED_PAWN_BLOCKED_DIVISOR    = 2
+	PASSED_PAWN_CONTROLLED_DIVISOR = 2
+
+	// Bonus per safe central square behind the own pawns, see space.
+	SPACE_WEIGHT = 2
+
+	// Bonus for the side to move. It is divided by TEMPO_ENDGAME_DIVISOR in the endgame,
+	// where the right to move is often a burden (zugzwang).
+	TEMPO_BONUS           = 10
+	TEMPO_ENDGAME_DIVISOR = 2
+
+	// Bonus for a bishop on a long diagonal which sees both central squares of that
+	// diagonal without a pawn in between, and for a fianchettoed bishop, which is
+	// backed by its pawns and shelters the own king, see bishops.
+	BISHOP_LONG_DIAGONAL = 15
+	BISHOP_FIANCHETTO    = 20
+
+	// Penalties for trapped pieces.
+	TRAPPED_BISHOP = 100
+	TRAPPED_ROOK   = 50
+
+	// The total evaluation of a likely fortress is divided by this value
+	// to drive it towards a draw.
+	FORTRESS_DIVISOR = 16
+
+	// The total evaluation is scaled linearly towards zero, when the halfmove clock
+	// of the fifty-move rule climbs from FIFTY_MOVE_SCALE_START to FIFTY_MOVE_LIMIT.
+	// This makes the engine prefer progress over shuffling in won positions.
+	FIFTY_MOVE_SCALE_START = 20
+	FIFTY_MOVE_LIMIT       = 100
+
+	// Game phase weights per piece type. The phase of the starting position
+	// is PHASE_MAX and drops towards 0 as pieces are traded.
+	PHASE_KNIGHT = 1
+	PHASE_BISHOP = 1
+	PHASE_ROOK   = 2
+	PHASE_QUEEN  = 4
+	PHASE_MAX    = 4*PHASE_KNIGHT + 4*PHASE_BISHOP + 4*PHASE_ROOK + 2*PHASE_QUEEN
+
+	// A position is an endgame if its phase is at most ENDGAME_PHASE, or at most
+	// ENDGAME_PHASE_NO_QUEENS if both queens are gone.
+	ENDGAME_PHASE           = 6
+	ENDGAME_PHASE_NO_QUEENS = 12
+)
+
+// PASSED_PAWN_BONUS holds the bonus for a passed pawn indexed by its distance to promotion.
+var PASSED_PAWN_BONUS = [7]int{0, 120, 80, 50, 30, 15, 10}
+
+// EvalConfig holds the tunable parameters of the evaluation.
+type EvalConfig struct {
+	// PieceValues holds the material value of every piece type in centipawns.
+	// It is indexed by Piece.TypeIndex: none, pawn, knight, bishop, rook, queen, king.
+	// Evaluation and move ordering all read the values from here.
+	PieceValues [7]int
+	// Tempo is the bonus for the side to move. Zero disables it.
+	Tempo int
+}
+
+// DefaultEvalConfig is used if no other configuration is given.
+var DefaultEvalConfig = EvalConfig{
+	PieceValues: [7]int{0, VALUE_PAWN, VALUE_KNIGHT, VALUE_BISHOP, VALUE_ROOK, VALUE_QUEEN, 0},
+	Tempo:       TEMPO_BONUS,
+}
+
+// PieceValue returns the material value of a piece regardless of its color.
+func (cfg *EvalConfig) PieceValue(piece Piece) int {
+	return cfg.PieceValues[piece.TypeIndex()]
+}
+
+// EvalBreakdown holds all terms of a static evaluation. All values are
+// given in centipawns from white's point of view.
+type EvalBreakdown struct {
+	Material    int
+	KingTropism int
+	Mobility    int
+	Rooks       int
+	RookPassers int
+	Outposts    int
+	Trapped     int
+	Bishops     int
+	PassedPawns int
+	Space       int
+	Tempo       int
+	// Unscaled is the sum of all terms. Total is scaled from there towards a
+	// draw in likely fortresses and as the fifty-move rule approaches.
+	Unscaled int
+	Total    int
+}
+
+// String formats all terms one per line, followed by the total.
+func (e EvalBreakdown) String() string {
+	terms := []struct {
+		name  string
+		value int
+	}{
+		{"Material", e.Material},
+		{"KingTropism", e.KingTropism},
+		{"Mobility", e.Mobility},
+		{"Rooks", e.Rooks},
+		{"RookPassers", e.RookPassers},
+		{"Outposts", e.Outposts},
+		{"Trapped", e.Trapped},
+		{"Bishops", e.Bishops},
+		{"PassedPawns", e.PassedPawns},
+		{"Space", e.Space},
+		{"Tempo", e.Tempo},
+		{"Total", e.Total},
+	}
+
+	var sb strings.Builder
+	for _, term := range terms {
+		fmt.Fprintf(&sb, "%-12s %6d\n", term.name, term.value)
+	}
+	return sb.String()
+}
+
+// Evaluate returns the static evaluation of the position in centipawns
+// from the point of view of the side to move. The default configuration is used.
+func (b *Board) Evaluate() int {
+	return b.EvaluateWith(&DefaultEvalConfig)
+}
+
+// EvaluateWith returns the static evaluation of the position for the given
+// configuration in centipawns from the point of view of the side to move.
+func (b *Board) EvaluateWith(cfg *EvalConfig) int {
+	score := b.EvalBreakdownWith(cfg).Total
+	if b.Player == BLACK {
+		return -score
+	}
+	return score
+}
+
+// EvalBreakdown evaluates the position with the default configuration
+// and returns all evaluation terms separately.
+func (b *Board) EvalBreakdown() EvalBreakdown {
+	return b.EvalBreakdownWith(&DefaultEvalConfig)
+}
+
+// EvalBreakdownWith evaluates the position with the given configuration
+// and returns all evaluation terms separately.
+func (b *Board) EvalBreakdownWith(cfg *EvalConfig) EvalBreakdown {
+	e := EvalBreakdown{}
+
+	e.Material = b.material(WHITE, cfg) - b.material(BLACK, cfg)
+	e.KingTropism = b.kingTropism(WHITE) - b.kingTropism(BLACK)
+	e.Mobility = b.mobility(WHITE) - b.mobility(BLACK)
+	e.Rooks = b.rookCoordination(WHITE) - b.rookCoordination(BLACK)
+	e.RookPassers = b.rookPassedPawns(WHITE) - b.rookPassedPawns(BLACK)
+	e.Outposts = b.knightOutposts(WHITE) - b.knightOutposts(BLACK)
+	e.Trapped = b.trappedPieces(BLACK) - b.trappedPieces(WHITE)
+	e.Bishops = b.bishops(WHITE) - b.bishops(BLACK)
+	e.PassedPawns = b.passedPawns(WHITE) - b.passedPawns(BLACK)
+	e.Space = b.space(WHITE) - b.space(BLACK)
+	e.Tempo = b.tempo(cfg)
+
+	e.Unscaled = e.Material + e.KingTropism + e.Mobility + e.Rooks + e.RookPassers + e.Outposts + e.Trapped + e.Bishops + e.PassedPawns + e.Space + e.Tempo
+	e.Total = e.Unscaled
+	if b.IsLikelyFortress() {
+		e.Total /= FORTRESS_DIVISOR
+	}
+	e.Total = scaleByDrawCounter(e.Total, int(b.DrawCounter))
+	return e
+}
+
+// tempo returns the bonus for the side to move from white's point of view.
+func (b *Board) tempo(cfg *EvalConfig) int {
+	bonus := cfg.Tempo
+	if b.IsEndgame() {
+		bonus /= TEMPO_ENDGAME_DIVISOR
+	}
+	if b.Player == BLACK {
+		return -bonus
+	}
+	return bonus
+}
+
+// scaleByDrawCounter shrinks 'score' towards zero as the fifty-move rule comes closer.
+func scaleByDrawCounter(score, drawCounter int) int {
+	if drawCounter <= FIFTY_MOVE_SCALE_START {
+		return score
+	}
+	if drawCounter >= FIFTY_MOVE_LIMIT {
+		return 0
+	}
+	return score * (FIFTY_MOVE_LIMIT - drawCounter) / (FIFTY_MOVE_LIMIT - FIFTY_MOVE_SCALE_START)
+}
+
+func (b *Board) material(color Color, cfg *EvalConfig) int {
+	return int(b.Pawns[color].Size)*cfg.PieceValue(PAWN) +
+		int(b.Knights[color].Size)*cfg.PieceValue(KNIGHT) +
+		int(b.Bishops[color].Size)*cfg.PieceValue(BISHOP) +
+		int(b.Rooks[color].Size)*cfg.PieceValue(ROOK) +
+		int(b.Queens[color].Size)*cfg.PieceValue(QUEEN)
+}
+
+// kingTropism rewards pieces of 'color' for being close to the enemy king.
+func (b *Board) kingTropism(color Color) int {
+	oppKingSq := b.Kings[color.Flip()]
+	if oppKingSq == OTB {
+		return 0
+	}
+
+	score := 0
+	score += tropismFor(&b.Knights[color], oppKingSq, TROPISM_KNIGHT)
+	score += tropismFor(&b.Bishops[color], oppKingSq, TROPISM_BISHOP)
+	score += tropismFor(&b.Rooks[color], oppKingSq, TROPISM_ROOK)
+	score += tropismFor(&b.Queens[color], oppKingSq, TROPISM_QUEEN)
+
+	return score
+}
+
+func tropismFor(plist *PieceList, kingSq Square, weight int) int {
+	score := 0
+	for i := uint8(0); i < plist.Size; i++ {
+		score += weight * (7 - plist.Pieces[i].Distance(kingSq))
+	}
+	return score
+}
+
+// PawnAttackSpan marks every square that is attacked by at least one pawn of 'color'.
+// The returned array is indexed like a MinBoard (a1=0 .. h8=63).
+func (b *Board) PawnAttackSpan(color Color) [64]bool {
+	span := [64]bool{}
+	for i := uint8(0); i < b.Pawns[color].Size; i++ {
+		from := b.Pawns[color].Pieces[i]
+		for d := 0; d < 2; d++ {
+			to := from.Add(PAWN_CAPTURE_DIRS[color][d])
+			if to.OnBoard() {
+				span[to.To8x8()] = true
+			}
+		}
+	}
+	return span
+}
+
+// MobilityArea marks the squares a piece of 'color' may usefully move to. Squares
+// occupied by own pieces, attacked by enemy pawns and the start squares of the own
+// king and queen, which are only reached by retreats, are excluded.
+// The returned array is indexed like a MinBoard (a1=0 .. h8=63).
+func (b *Board) MobilityArea(color Color) [64]bool {
+	area := b.PawnAttackSpan(color.Flip())
+	for i := range area {
+		area[i] = !area[i] && !b.Squares[Square(i).To0x88()].HasColor(color)
+	}
+	king, queen := CASTLING_DETECT_SHORT[color][0], CASTLING_DETECT_LONG[color][0]-1
+	area[king.To8x8()] = false
+	area[queen.To8x8()] = false
+	return area
+}
+
+// mobility counts the squares inside the mobility area the pieces of 'color' can move to.
+func (b *Board) mobility(color Color) int {
+	area := b.MobilityArea(color)
+
+	score := 0
+	for i := uint8(0); i < b.Knights[color].Size; i++ {
+		from := b.Knights[color].Pieces[i]
+		for d := 0; d < 8; d++ {
+			to := from.Add(KNIGHT_DIRS[d])
+			if to.OnBoard() && area[to.To8x8()] {
+				score += MOBILITY_KNIGHT
+			}
+		}
+	}
+	score += MOBILITY_BISHOP * b.slidingMobility(&b.Bishops[color], color, DIAGONAL_DIRS, &area)
+	score += MOBILITY_ROOK * b.slidingMobility(&b.Rooks[color], color, ORTHOGONAL_DIRS, &area)
+	score += MOBILITY_QUEEN * b.slidingMobility(&b.Queens[color], color, DIAGONAL_DIRS, &area)
+	score += MOBILITY_QUEEN * b.slidingMobility(&b.Queens[color], color, ORTHOGONAL_DIRS, &area)
+
+	return score
+}
+
+func (b *Board) slidingMobility(plist *PieceList, color Color, dirs [4]int8, area *[64]bool) int {
+	count := 0
+	for i := uint8(0); i < plist.Size; i++ {
+		from := plist.Pieces[i]
+		for d := 0; d < 4; d++ {
+			dir := dirs[d]
+			for to := from.Add(dir); to.OnBoard(); to = to.Add(dir) {
+				tpiece := b.Squares[to]
+				if tpiece.HasColor(color) {
+					break
+				}
+				if area[to.To8x8()] {
+					count++
+				}
+				if !tpiece.IsEmpty() {
+					break
+				}
+			}
+		}
+	}
+	return count
+}
+
+// DoubledRooks counts the pairs of rooks of 'color' which defend each other. Rooks on
+// the same file are doubled and rooks on the same rank are connected, if there are no
+// pieces between them.
+func (b *Board) DoubledRooks(color Color) (doubled, connected int) {
+	plist := &b.Rooks[color]
+	for i := uint8(0); i < plist.Size; i++ {
+		for j := i + 1; j < plist.Size; j++ {
+			sq1, sq2 := plist.Pieces[i], plist.Pieces[j]
+			if sq1 > sq2 {
+				sq1, sq2 = sq2, sq1
+			}
+			if sq1.File() == sq2.File() && b.isPathClear(sq1, sq2, UP) {
+				doubled++
+			} else if sq1.Rank() == sq2.Rank() && b.isPathClear(sq1, sq2, RIGHT) {
+				connected++
+			}
+		}
+	}
+	return doubled, connected
+}
+
+func (b *Board) rookCoordination(color Color) int {
+	doubled, connected := b.DoubledRooks(color)
+	return doubled*ROOK_DOUBLED_BONUS + connected*ROOK_CONNECTED_BONUS
+}
+
+// RookBehindPassedPawn counts the rooks of 'color' behind a passed pawn of either color
+// and the rooks in front of an own passed pawn. The rook and the pawn must be on the
+// same file without pieces between them.
+func (b *Board) RookBehindPassedPawn(color Color) (behind, inFront int) {
+	for i := uint8(0); i < b.Rooks[color].Size; i++ {
+		rookSq := b.Rooks[color].Pieces[i]
+		for pawnColor := BLACK; pawnColor <= WHITE; pawnColor++ {
+			for j := uint8(0); j < b.Pawns[pawnColor].Size; j++ {
+				pawnSq := b.Pawns[pawnColor].Pieces[j]
+				if pawnSq.File() != rookSq.File() || !b.IsPassedPawn(pawnSq, pawnColor) {
+					continue
+				}
+				dir := int8(16)
+				if pawnSq < rookSq {
+					dir = -16
+				}
+				if !b.isPathClear(rookSq, pawnSq, dir) {
+					continue
+				}
+				if relativeRank(rookSq, pawnColor) < relativeRank(pawnSq, pawnColor) {
+					behind++
+				} else if pawnColor == color {
+					inFront++
+				}
+			}
+		}
+	}
+	return behind, inFront
+}
+
+// rookPassedPawns rewards rooks of 'color' behind passed pawns.
+func (b *Board) rookPassedPawns(color Color) int {
+	behind, inFront := b.RookBehindPassedPawn(color)
+	return behind*ROOK_BEHIND_PASSED_PAWN - inFront*ROOK_IN_FRONT_PASSED_PAWN
+}
+
+// knightOutposts rewards knights of 'color' on outposts.
+func (b *Board) knightOutposts(color Color) int {
+	ownPawnSpan := b.PawnAttackSpan(color)
+
+	score := 0
+	for i := uint8(0); i < b.Knights[color].Size; i++ {
+		sq := b.Knights[color].Pieces[i]
+		if !ownPawnSpan[sq.To8x8()] || b.canBeAttackedByPawns(sq, color.Flip()) {
+			continue
+		}
+		if relativeRank(sq, color) >= 4 {
+			score += KNIGHT_OUTPOST_ADVANCED
+		} else {
+			score += KNIGHT_OUTPOST
+		}
+	}
+	return score
+}
+
+// DistanceToPromotion returns the number of moves a pawn of 'color' on 'sq' needs to
+// promote on an empty board. A pawn on its base rank can make a double step.
+func (b *Board) DistanceToPromotion(sq Square, color Color) int {
+	dist := 7 - relativeRank(sq, color)
+	if sq.IsPawnBaseRank(color) {
+		dist--
+	}
+	return dist
+}
+
+// IsPassedPawn tests if no enemy pawn can stop the pawn of 'color' on 'sq', because
+// there is none in front of it on the same or an adjacent file.
+func (b *Board) IsPassedPawn(sq Square, color Color) bool {
+	oppColor := color.Flip()
+	for i := uint8(0); i < b.Pawns[oppColor].Size; i++ {
+		pawnSq := b.Pawns[oppColor].Pieces[i]
+		fileDist := int(pawnSq.File()) - int(sq.File())
+		if fileDist >= -1 && fileDist <= 1 && relativeRank(pawnSq, color) > relativeRank(sq, color) {
+			return false
+		}
+	}
+	return true
+}
+
+// PassedPawns returns the squares of all passed pawns of 'color', see IsPassedPawn.
+func (b *Board) PassedPawns(color Color) []Square {
+	squares := []Square{}
+	for i := uint8(0); i < b.Pawns[color].Size; i++ {
+		if sq := b.Pawns[color].Pieces[i]; b.IsPassedPawn(sq, color) {
+			squares = append(squares, sq)
+		}
+	}
+	return squares
+}
+
+// passedPawns rewards passed pawns of 'color' by their distance to promotion. Pawns
+// with a blocked or controlled square in front of them score less.
+func (b *Board) passedPawns(color Color) int {
+	score := 0
+	for i := uint8(0); i < b.Pawns[color].Size; i++ {
+		sq := b.Pawns[color].Pieces[i]
+		if !b.IsPassedPawn(sq, color) {
+			continue
+		}
+
+		bonus := PASSED_PAWN_BONUS[b.DistanceToPromotion(sq, color)]
+		front := sq.Add(PAWN_PUSH_DIRS[color])
+		if !b.Squares[front].IsEmpty() {
+			bonus /= PASSED_PAWN_BLOCKED_DIVISOR
+		} else if b.IsSquareAttacked(front, OTB, color) {
+			bonus /= PASSED_PAWN_CONTROLLED_DIVISOR
+		}
+		score += bonus
+	}
+	return score
+}
+
+// space rewards 'color' for the room its pieces have to maneuver behind the own pawns.
+// Every square on the files c to f from the second up to the fifth rank is counted, if
+// an own pawn stands in front of it and no enemy pawn attacks it. Advancing the pawn
+// chain therefore gains space.
+func (b *Board) space(color Color) int {
+	oppPawnSpan := b.PawnAttackSpan(color.Flip())
+	ownPawn := PAWN | color
+
+	count := 0
+	for file := Square(2); file <= 5; file++ {
+		// Find the most advanced own pawn on the file.
+		front := 0
+		for i := uint8(0); i < b.Pawns[color].Size; i++ {
+			sq := b.Pawns[color].Pieces[i]
+			if sq.File() == file && relativeRank(sq, color) > front {
+				front = relativeRank(sq, color)
+			}
+		}
+
+		for rank := 1; rank < front && rank <= 4; rank++ {
+			sq := Square(rank)<<4 | file
+			if color == BLACK {
+				sq = Square(7-rank)<<4 | file
+			}
+			if b.Squares[sq] != ownPawn && !oppPawnSpan[sq.To8x8()] {
+				count++
+			}
+		}
+	}
+	return count * SPACE_WEIGHT
+}
+
+// canBeAttackedByPawns tests if any pawn of 'color' attacks 'sq' now or could attack
+// it after advancing, i.e. it stands on an adjacent file in front of 'sq'.
+func (b *Board) canBeAttackedByPawns(sq Square, color Color) bool {
+	for i := uint8(0); i < b.Pawns[color].Size; i++ {
+		pawnSq := b.Pawns[color].Pieces[i]
+		fileDist := int(pawnSq.File()) - int(sq.File())
+		if (fileDist == 1 || fileDist == -1) && relativeRank(pawnSq, color) < relativeRank(sq, color) {
+			return true
+		}
+	}
+	return false
+}
+
+// trappedPieces returns the penalty for pieces of 'color' caught in one of these patterns:
+//   - a bishop on a7 (h7) which is cut off by an enemy pawn on b6 (g6).
+//   - a rook in the corner, which is locked in by the own king that has lost its castling rights.
+//
+// The squares are given from white's point of view and mirrored for black.
+func (b *Board) trappedPieces(color Color) int {
+	// XOR with 0x70 mirrors the rank of a square.
+	mirror := Square(0)
+	if color == BLACK {
+		mirror = 0x70
+	}
+
+	penalty := 0
+	enemyPawn := PAWN | color.Flip()
+	for i := uint8(0); i < b.Bishops[color].Size; i++ {
+		switch b.Bishops[color].Pieces[i] ^ mirror {
+		case 0x60: // a7
+			if b.Squares[0x51^mirror] == enemyPawn {
+				penalty += TRAPPED_BISHOP
+			}
+		case 0x67: // h7
+			if b.Squares[0x56^mirror] == enemyPawn {
+				penalty += TRAPPED_BISHOP
+			}
+		}
+	}
+
+	kingSq := b.Kings[color] ^ mirror
+	if kingSq.Rank() != 0 {
+		return penalty
+	}
+	for i := uint8(0); i < b.Rooks[color].Size; i++ {
+		rookSq := b.Rooks[color].Pieces[i] ^ mirror
+		if rookSq.Rank() != 0 {
+			continue
+		}
+		kingFile, rookFile := kingSq.File(), rookSq.File()
+		if kingFile >= 5 && rookFile > kingFile && !b.CastleShort[color] {
+			// King on f1 or g1 with the rook on g1 or h1.
+			penalty += TRAPPED_ROOK
+		} else if kingFile >= 1 && kingFile <= 2 && rookFile < kingFile && !b.CastleLong[color] {
+			// King on b1 or c1 with the rook on a1 or b1.
+			penalty += TRAPPED_ROOK
+		}
+	}
+
+	return penalty
+}
+
+// bishops rewards bishops of 'color' on a long diagonal, which see both central squares
+// of the diagonal (d4 and e5 or e4 and d5) without a pawn in between, and fianchettoed
+// bishops.
+// A bishop on g2 (b2) is fianchettoed, if it is backed by own pawns on f2, g3 and h2
+// (a2, b3 and c2) and the own king stands on the first rank of the same wing.
+// The squares are given from white's point of view and mirrored for black.
+func (b *Board) bishops(color Color) int {
+	// XOR with 0x70 mirrors the rank of a square.
+	mirror := Square(0)
+	if color == BLACK {
+		mirror = 0x70
+	}
+	ownPawn := PAWN | color
+	kingSq := b.Kings[color] ^ mirror
+
+	score := 0
+	for i := uint8(0); i < b.Bishops[color].Size; i++ {
+		sq := b.Bishops[color].Pieces[i]
+		if sq.Rank() == sq.File() {
+			if b.noPawnBetween(sq, 0x33, 0x11) && b.noPawnBetween(sq, 0x44, 0x11) {
+				score += BISHOP_LONG_DIAGONAL
+			}
+		} else if sq.Rank()+sq.File() == 7 {
+			if b.noPawnBetween(sq, 0x43, 0x0F) && b.noPawnBetween(sq, 0x34, 0x0F) {
+				score += BISHOP_LONG_DIAGONAL
+			}
+		}
+
+		switch sq ^ mirror {
+		case 0x16: // g2
+			if kingSq.Rank() == 0 && kingSq.File() >= 5 && b.Squares[0x15^mirror] == ownPawn &&
+				b.Squares[0x26^mirror] == ownPawn && b.Squares[0x17^mirror] == ownPawn {
+				score += BISHOP_FIANCHETTO
+			}
+		case 0x11: // b2
+			if kingSq.Rank() == 0 && kingSq.File() <= 2 && b.Squares[0x12^mirror] == ownPawn &&
+				b.Squares[0x21^mirror] == ownPawn && b.Squares[0x10^mirror] == ownPawn {
+				score += BISHOP_FIANCHETTO
+			}
+		}
+	}
+	return score
+}
+
+// noPawnBetween tests if no pawn stands between 'from' and 'to', which must lie on
+// the same line along 'dir' (in either direction).
+func (b *Board) noPawnBetween(from, to Square, dir int8) bool {
+	if from == to {
+		return true
+	}
+	if to < from {
+		dir = -dir
+	}
+	for sq := Square(int8(from) + dir); sq != to; sq = Square(int8(sq) + dir) {
+		if b.Squares[sq].Contains(PAWN) {
+			return false
+		}
+	}
+	return true
+}
+
+// GamePhase returns the phase of the game between 0 (only kings and pawns)
+// and PHASE_MAX (all pieces on the board), based on the remaining non-pawn material.
+// Additional pieces from promotions are clamped to PHASE_MAX.
+func (b *Board) GamePhase() int {
+	phase := 0
+	for color := BLACK; color <= WHITE; color++ {
+		phase += int(b.Knights[color].Size)*PHASE_KNIGHT +
+			int(b.Bishops[color].Size)*PHASE_BISHOP +
+			int(b.Rooks[color].Size)*PHASE_ROOK +
+			int(b.Queens[color].Size)*PHASE_QUEEN
+	}
+	if phase > PHASE_MAX {
+		return PHASE_MAX
+	}
+	return phase
+}
+
+// IsEndgame returns true if so much non-pawn material has been traded,
+// that the position is considered an endgame.
+func (b *Board) IsEndgame() bool {
+	phase := b.GamePhase()
+	if b.Queens[WHITE].Size == 0 && b.Queens[BLACK].Size == 0 {
+		return phase <= ENDGAME_PHASE_NO_QUEENS
+	}
+	return phase <= ENDGAME_PHASE
+}
+
+// IsLikelyFortress recognizes a few well-known drawn endgames, where the weak side
+// builds a fortress in front of a single pawn:
+//   - a rook pawn vs a bare king which controls the queening square.
+//   - the Philidor position in KRP vs KR: the defending king controls the queening square
+//     and the defending rook guards the third rank while the pawn has not yet reached it.
+func (b *Board) IsLikelyFortress() bool {
+	for strong := BLACK; strong <= WHITE; strong++ {
+		weak := strong.Flip()
+		if b.Pawns[strong].Size != 1 || b.Pawns[weak].Size != 0 {
+			continue
+		}
+
+		pawnSq := b.Pawns[strong].Pieces[0]
+		promoSq := PAWN_PROMOTE_RANK[strong]<<4 | pawnSq.File()
+		weakKingSq := b.Kings[weak]
+
+		// The weak king must be in front of the pawn, controlling the queening square.
+		if weakKingSq.Distance(promoSq) > 1 || relativeRank(weakKingSq, strong) <= relativeRank(pawnSq, strong) {
+			return false
+		}
+
+		strongPieces := b.Knights[strong].Size + b.Sliders[strong].Size
+		weakPieces := b.Knights[weak].Size + b.Sliders[weak].Size
+
+		if strongPieces == 0 && weakPieces == 0 {
+			// A rook pawn cannot drive the king out of the corner.
+			file := pawnSq.File()
+			return file == 0 || file == 7
+		}
+
+		if strongPieces == 1 && weakPieces == 1 && b.Rooks[strong].Size == 1 && b.Rooks[weak].Size == 1 {
+			// Philidor: the weak rook guards the third rank in front of the pawn.
+			thirdRank := relativeRank(b.Rooks[weak].Pieces[0], strong)
+			return thirdRank == 5 && relativeRank(pawnSq, strong) < 5
+		}
+
+		return false
+	}
+
+	return false
+}
+
+// relativeRank returns the rank (0-7) of a square from the point of view of 'color'.
+func relativeRank(sq Square, color Color) int {
+	if color == WHITE {
+		return int(sq.Rank())
+	}
+	return 7 - int(sq.Rank())
+}
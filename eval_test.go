@@ -0,0 +1,584 @@
+package chesskimo
+
+import (
+	"testing"
+)
+
+func TestKingTropism(t *testing.T) {
+	board := NewBoard()
+
+	// The white queen is far away from the black king.
+	if err := board.SetFEN("4k3/8/8/8/8/8/8/Q3K3 w - - 0 1"); err != nil {
+		t.Fatalf(err.Error())
+	}
+	far := board.EvalBreakdown()
+
+	// The white queen advanced towards the black king.
+	if err := board.SetFEN("4k3/8/8/3Q4/8/8/8/4K3 w - - 0 1"); err != nil {
+		t.Fatalf(err.Error())
+	}
+	near := board.EvalBreakdown()
+
+	if near.KingTropism <= far.KingTropism {
+		t.Fatalf("Expected tropism of advanced queen (%d) to be higher than of distant queen (%d)\n", near.KingTropism, far.KingTropism)
+	}
+	if near.Material != far.Material {
+		t.Fatalf("Expected equal material but got %d and %d\n", near.Material, far.Material)
+	}
+}
+
+func TestDistance(t *testing.T) {
+	type set struct {
+		From Square
+		To   Square
+		Dist int
+	}
+
+	testset := []set{
+		set{0x00, 0x77, 7},
+		set{0x00, 0x00, 0},
+		set{0x33, 0x44, 1},
+		set{0x04, 0x74, 7},
+		set{0x12, 0x35, 3},
+	}
+
+	for i, ts := range testset {
+		dist := ts.From.Distance(ts.To)
+		if dist != ts.Dist {
+			t.Fatalf("Test %d from %s to %s should have distance %d but has %d\n", i, PrintBoardIndex[ts.From], PrintBoardIndex[ts.To], ts.Dist, dist)
+		}
+	}
+}
+
+func TestPawnAttackSpan(t *testing.T) {
+	board := NewBoard()
+	if err := board.SetFEN("4k3/1p3p2/2p3p1/8/3P4/P3P2P/8/4K3 w - - 0 1"); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	// Attacked squares computed by hand for every pawn.
+	expected := map[Color][]string{
+		WHITE: []string{
+			"b4",       // a3
+			"c5", "e5", // d4
+			"d4", "f4", // e3
+			"g4", // h3
+		},
+		BLACK: []string{
+			"a6", "c6", // b7
+			"b5", "d5", // c6
+			"e6", "g6", // f7
+			"f5", "h5", // g6
+		},
+	}
+
+	for color, squares := range expected {
+		manual := [64]bool{}
+		for _, s := range squares {
+			sq, err := parseFENSquare(s)
+			if err != nil {
+				t.Fatalf(err.Error())
+			}
+			manual[sq] = true
+		}
+
+		span := board.PawnAttackSpan(color)
+		if span != manual {
+			t.Fatalf("Pawn attack span for color %d is\n%v\nbut should be\n%v\n", color, span, manual)
+		}
+	}
+}
+
+func TestMobilityIgnoresPawnAttackedSquares(t *testing.T) {
+	board := NewBoard()
+
+	// A knight on d4 which can freely move.
+	if err := board.SetFEN("4k3/8/8/8/3N4/8/8/4K3 w - - 0 1"); err != nil {
+		t.Fatalf(err.Error())
+	}
+	free := board.mobility(WHITE)
+
+	// Two of the knight's target squares (c6, e6) are controlled by a black pawn on d7.
+	if err := board.SetFEN("4k3/3p4/8/8/3N4/8/8/4K3 w - - 0 1"); err != nil {
+		t.Fatalf(err.Error())
+	}
+	restricted := board.mobility(WHITE)
+
+	if free-restricted != 2*MOBILITY_KNIGHT {
+		t.Fatalf("Expected mobility to drop by %d but it dropped from %d to %d\n", 2*MOBILITY_KNIGHT, free, restricted)
+	}
+}
+
+func TestMobilityArea(t *testing.T) {
+	board := NewBoard()
+	if err := board.SetFEN("4k3/1p3p2/2p3p1/8/3P4/P3P2P/8/3QK3 w - - 0 1"); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	area := board.MobilityArea(WHITE)
+	oppSpan := board.PawnAttackSpan(BLACK)
+	for i := range area {
+		sq := Square(i).To0x88()
+		excluded := oppSpan[i] || board.Squares[sq].HasColor(WHITE)
+		if area[i] == excluded {
+			t.Fatalf("Expected square %s in the mobility area to be %v\n", PrintBoardIndex[sq], !excluded)
+		}
+	}
+
+	// The start squares of the own king and queen are excluded even if empty.
+	area = board.MobilityArea(BLACK)
+	for _, s := range []string{"d8", "e8", "d4", "e5", "g4", "b4", "c5", "b7"} {
+		sq, err := parseFENSquare(s)
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		if area[sq] {
+			t.Fatalf("Expected square %s to be excluded from the mobility area of black\n", s)
+		}
+	}
+	for _, s := range []string{"d7", "e7", "a1", "h8", "e3", "d1"} {
+		sq, err := parseFENSquare(s)
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		if !area[sq] {
+			t.Fatalf("Expected square %s to be inside the mobility area of black\n", s)
+		}
+	}
+}
+
+func TestIsLikelyFortress(t *testing.T) {
+	testset := map[string]bool{
+		// Philidor position: the black rook guards the 6th rank.
+		"4k3/8/r7/4PK2/8/8/8/7R b - - 0 1": true,
+		// Same position for the other color.
+		"7r/8/8/8/4kp2/R7/8/4K3 w - - 0 1": true,
+		// Rook pawn vs king in the corner.
+		"k7/8/8/8/P7/8/8/4K3 w - - 0 1": true,
+		// Lucena position: the black king is cut off.
+		"1K6/1P1k4/8/8/8/8/r7/2R5 w - - 0 1": false,
+		// The black rook is passive.
+		"r3k3/8/8/4PK2/8/8/8/7R b - - 0 1": false,
+		// A center pawn vs a king in front is not a fortress.
+		"4k3/8/8/8/4P3/8/8/4K3 w - - 0 1": false,
+		// Start position.
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1": false,
+	}
+
+	board := NewBoard()
+	for fen, fortress := range testset {
+		if err := board.SetFEN(fen); err != nil {
+			t.Fatalf(err.Error())
+		}
+		if board.IsLikelyFortress() != fortress {
+			t.Fatalf("Expected fortress detection %v for FEN %s\n", fortress, fen)
+		}
+	}
+
+	// The evaluation of the Philidor position is scaled towards a draw.
+	if err := board.SetFEN("4k3/8/r7/4PK2/8/8/8/7R b - - 0 1"); err != nil {
+		t.Fatalf(err.Error())
+	}
+	e := board.EvalBreakdown()
+	if e.Total*FORTRESS_DIVISOR > e.Unscaled {
+		t.Fatalf("Expected evaluation %d to be scaled towards a draw\n", e.Total)
+	}
+}
+
+func TestConfigurablePieceValues(t *testing.T) {
+	board := NewBoard()
+	// White is a knight up.
+	if err := board.SetFEN("4k3/pppp4/8/8/8/8/PPPP4/1N2K3 w - - 0 1"); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	cfg := DefaultEvalConfig
+	before := board.EvaluateWith(&cfg)
+	cfg.PieceValues[KNIGHT.TypeIndex()] += 50
+	after := board.EvaluateWith(&cfg)
+
+	if after-before != 50 {
+		t.Fatalf("Expected evaluation to rise by 50 but it changed from %d to %d\n", before, after)
+	}
+
+	// The default configuration must not be changed.
+	if board.Evaluate() != before {
+		t.Fatalf("Expected default evaluation %d but got %d\n", before, board.Evaluate())
+	}
+}
+
+func TestTypeIndex(t *testing.T) {
+	pieces := []Piece{NONE, PAWN, KNIGHT, BISHOP, ROOK, QUEEN, KING}
+	for i, p := range pieces {
+		if p.TypeIndex() != i || (p|WHITE).TypeIndex() != i {
+			t.Fatalf("Expected type index %d for piece %d\n", i, p)
+		}
+	}
+}
+
+func TestIsEndgame(t *testing.T) {
+	type set struct {
+		Fen     string
+		Phase   int
+		Endgame bool
+	}
+
+	testset := []set{
+		set{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", PHASE_MAX, false},
+		set{"4k3/pppp4/8/8/8/8/PPPP4/4K3 w - - 0 1", 0, true},
+		set{"4k3/8/8/8/8/8/8/4K3 w - - 0 1", 0, true},
+		// Promoted pieces do not raise the phase above the maximum.
+		set{"rnbqkbnr/pppppppp/8/8/8/8/1PPPPPPP/QNBQKBNR w Kkq - 0 1", PHASE_MAX, false},
+		// Queens traded, both sides have a rook and two minor pieces.
+		set{"2b1kn1r/pppp4/8/8/8/8/PPPP4/2B1KN1R w - - 0 1", 8, true},
+		// Queens on the board with a rook each.
+		set{"3qk2r/pppp4/8/8/8/8/PPPP4/3QK2R w - - 0 1", 12, false},
+	}
+
+	board := NewBoard()
+	for i, ts := range testset {
+		if err := board.SetFEN(ts.Fen); err != nil {
+			t.Fatalf(err.Error())
+		}
+		if phase := board.GamePhase(); phase != ts.Phase {
+			t.Fatalf("Test %d: expected phase %d but got %d\n", i, ts.Phase, phase)
+		}
+		if board.IsEndgame() != ts.Endgame {
+			t.Fatalf("Test %d: expected endgame %v\n", i, ts.Endgame)
+		}
+	}
+}
+
+func TestDoubledRooks(t *testing.T) {
+	board := NewBoard()
+
+	// The white rooks are connected on the first rank.
+	if err := board.SetFEN("4k3/ppp2ppp/8/8/8/8/PPP2PPP/3RR1K1 w - - 0 1"); err != nil {
+		t.Fatalf(err.Error())
+	}
+	if doubled, connected := board.DoubledRooks(WHITE); doubled != 0 || connected != 1 {
+		t.Fatalf("Expected 0 doubled and 1 connected pair but got %d and %d\n", doubled, connected)
+	}
+	// The white rooks are doubled on the open d-file.
+	if err := board.SetFEN("4k3/ppp2ppp/8/8/8/3R4/PPP2PPP/3R2K1 w - - 0 1"); err != nil {
+		t.Fatalf(err.Error())
+	}
+	if doubled, connected := board.DoubledRooks(WHITE); doubled != 1 || connected != 0 {
+		t.Fatalf("Expected 1 doubled and 0 connected pairs but got %d and %d\n", doubled, connected)
+	}
+	coordinated := board.EvalBreakdown()
+
+	// The same rooks scattered on different files and ranks.
+	if err := board.SetFEN("4k3/ppp2ppp/8/8/8/R7/PPP2PPP/6KR w - - 0 1"); err != nil {
+		t.Fatalf(err.Error())
+	}
+	if doubled, connected := board.DoubledRooks(WHITE); doubled != 0 || connected != 0 {
+		t.Fatalf("Expected no rook pairs but got %d and %d\n", doubled, connected)
+	}
+	scattered := board.EvalBreakdown()
+
+	if coordinated.Rooks <= scattered.Rooks {
+		t.Fatalf("Expected doubled rooks (%d) to score higher than scattered rooks (%d)\n", coordinated.Rooks, scattered.Rooks)
+	}
+}
+
+func TestRookBehindPassedPawn(t *testing.T) {
+	type set struct {
+		Fen     string
+		Behind  int
+		InFront int
+	}
+
+	testset := []set{
+		// Behind the own passed pawn.
+		set{"6k1/8/8/8/P7/8/8/R5K1 w - - 0 1", 1, 0},
+		// In front of the own passed pawn.
+		set{"R5k1/8/8/8/P7/8/8/6K1 w - - 0 1", 0, 1},
+		// Behind the enemy passed pawn.
+		set{"R5k1/8/8/8/p7/8/8/6K1 w - - 0 1", 1, 0},
+		// A piece between rook and pawn.
+		set{"6k1/8/8/8/P7/8/N7/R5K1 w - - 0 1", 0, 0},
+		// The pawn is not passed.
+		set{"6k1/1p6/8/8/P7/8/8/R5K1 w - - 0 1", 0, 0},
+	}
+
+	for i, ts := range testset {
+		board := NewBoard()
+		if err := board.SetFEN(ts.Fen); err != nil {
+			t.Fatalf(err.Error())
+		}
+		if behind, inFront := board.RookBehindPassedPawn(WHITE); behind != ts.Behind || inFront != ts.InFront {
+			t.Fatalf("Test %d: expected %d rooks behind and %d in front but got %d and %d\n", i, ts.Behind, ts.InFront, behind, inFront)
+		}
+	}
+
+	board := NewBoard()
+	if err := board.SetFEN("6k1/8/8/8/P7/8/8/R5K1 w - - 0 1"); err != nil {
+		t.Fatalf(err.Error())
+	}
+	behind := board.EvalBreakdown()
+	if err := board.SetFEN("R5k1/8/8/8/P7/8/8/6K1 w - - 0 1"); err != nil {
+		t.Fatalf(err.Error())
+	}
+	inFront := board.EvalBreakdown()
+	if behind.RookPassers <= 0 || inFront.RookPassers >= 0 {
+		t.Fatalf("Expected a bonus behind (%d) and a penalty in front (%d) of the passed pawn\n", behind.RookPassers, inFront.RookPassers)
+	}
+}
+
+func TestKnightOutposts(t *testing.T) {
+	type set struct {
+		Fen      string
+		Outposts int
+	}
+
+	testset := []set{
+		// Knight on d5 supported by the e4 pawn without black pawns on the c- and e-files.
+		set{"4k3/pp3ppp/3p4/3N4/4P3/8/PPP2PPP/4K3 w - - 0 1", KNIGHT_OUTPOST_ADVANCED},
+		// The same knight without support.
+		set{"4k3/pp3ppp/3p4/3N4/8/8/PPP2PPP/4K3 w - - 0 1", 0},
+		// The c7 pawn can still drive the knight away.
+		set{"4k3/ppp2ppp/3p4/3N4/4P3/8/PP3PPP/4K3 w - - 0 1", 0},
+		// A black outpost on e4 in white's half.
+		set{"4k3/ppp2ppp/8/3p4/4n3/8/PPP3PP/4K3 w - - 0 1", -KNIGHT_OUTPOST_ADVANCED},
+		// A white outpost in the own half.
+		set{"4k3/pp3ppp/8/8/3N4/2P5/PP3PPP/4K3 w - - 0 1", KNIGHT_OUTPOST},
+	}
+
+	board := NewBoard()
+	for i, ts := range testset {
+		if err := board.SetFEN(ts.Fen); err != nil {
+			t.Fatalf(err.Error())
+		}
+		if e := board.EvalBreakdown(); e.Outposts != ts.Outposts {
+			t.Fatalf("Test %d: expected outpost score %d but got %d\n", i, ts.Outposts, e.Outposts)
+		}
+	}
+}
+
+func TestTrappedPieces(t *testing.T) {
+	type set struct {
+		Fen     string
+		Trapped int
+	}
+
+	testset := []set{
+		// White bishop on a7 trapped by the b6 pawn.
+		set{"4k3/B1p5/1p6/8/8/8/8/4K3 w - - 0 1", -TRAPPED_BISHOP},
+		// The bishop can still escape via b6.
+		set{"4k3/B1p5/8/1p6/8/8/8/4K3 w - - 0 1", 0},
+		// Black bishop on h2 trapped by the g3 pawn.
+		set{"4k3/8/8/8/8/6P1/7b/4K3 w - - 0 1", TRAPPED_BISHOP},
+		// White rook locked in by the king on f1 without castling rights.
+		set{"4k3/8/8/8/8/8/5PPP/5K1R w - - 0 1", -TRAPPED_ROOK},
+		// The king on e1 may still castle.
+		set{"4k3/8/8/8/8/8/5PPP/4K2R w K - 0 1", 0},
+		// The castled king on g1 with the rook on f1 is fine.
+		set{"4k3/8/8/8/8/8/5PPP/5RK1 w - - 0 1", 0},
+		// Black rook on a8 locked in by the king on b8.
+		set{"rk6/ppp5/8/8/8/8/8/4K3 w - - 0 1", TRAPPED_ROOK},
+	}
+
+	board := NewBoard()
+	for i, ts := range testset {
+		if err := board.SetFEN(ts.Fen); err != nil {
+			t.Fatalf(err.Error())
+		}
+		if e := board.EvalBreakdown(); e.Trapped != ts.Trapped {
+			t.Fatalf("Test %d: expected trapped piece score %d but got %d\n", i, ts.Trapped, e.Trapped)
+		}
+	}
+}
+
+func TestBishops(t *testing.T) {
+	type set struct {
+		Fen     string
+		Bishops int
+	}
+
+	testset := []set{
+		// Fianchettoed bishop on g2 in front of the castled king.
+		set{"4k3/8/8/8/8/6P1/5PBP/6K1 w - - 0 1", BISHOP_LONG_DIAGONAL + BISHOP_FIANCHETTO},
+		// The diagonal is blocked by the f3 pawn and the pawn shield is gone.
+		set{"4k3/8/8/8/8/5PP1/6BP/6K1 w - - 0 1", 0},
+		// The king castled to the other wing.
+		set{"4k3/8/8/8/8/6P1/5PBP/2K5 w - - 0 1", BISHOP_LONG_DIAGONAL},
+		// Fianchetto on the queen side.
+		set{"4k3/8/8/8/8/1P6/PBP5/1K6 w - - 0 1", BISHOP_LONG_DIAGONAL + BISHOP_FIANCHETTO},
+		// Black fianchetto on g7.
+		set{"6k1/5pbp/6p1/8/8/8/8/4K3 w - - 0 1", -BISHOP_LONG_DIAGONAL - BISHOP_FIANCHETTO},
+		// A bishop on the long diagonal sees d4 and e5.
+		set{"4k3/8/8/8/8/8/1B6/4K3 w - - 0 1", BISHOP_LONG_DIAGONAL},
+		// The pawn on d4 hides e5.
+		set{"4k3/8/8/8/3P4/8/1B6/4K3 w - - 0 1", 0},
+		// A bishop on a central square of the diagonal.
+		set{"4k3/8/8/8/3B4/8/8/4K3 w - - 0 1", BISHOP_LONG_DIAGONAL},
+	}
+
+	board := NewBoard()
+	for i, ts := range testset {
+		if err := board.SetFEN(ts.Fen); err != nil {
+			t.Fatalf(err.Error())
+		}
+		if e := board.EvalBreakdown(); e.Bishops != ts.Bishops {
+			t.Fatalf("Test %d: expected bishop score %d but got %d\n", i, ts.Bishops, e.Bishops)
+		}
+	}
+}
+
+func TestEvaluationScalesWithDrawCounter(t *testing.T) {
+	// White is a rook up in both positions, only the halfmove clock differs.
+	low := NewBoard()
+	if err := low.SetFEN("4k3/8/8/8/8/8/8/R3K3 w - - 4 60"); err != nil {
+		t.Fatalf(err.Error())
+	}
+	high := NewBoard()
+	if err := high.SetFEN("4k3/8/8/8/8/8/8/R3K3 w - - 90 60"); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	lowScore, highScore := low.Evaluate(), high.Evaluate()
+	if lowScore <= 0 || highScore <= 0 {
+		t.Fatalf("Expected white to be winning but got scores %d and %d\n", lowScore, highScore)
+	}
+	if highScore >= lowScore {
+		t.Fatalf("Expected the score at a high halfmove clock (%d) to be smaller than at a low one (%d)\n", highScore, lowScore)
+	}
+
+	high.DrawCounter = FIFTY_MOVE_LIMIT
+	if high.Evaluate() != 0 {
+		t.Fatalf("Expected a score of 0 at the fifty-move limit but got %d\n", high.Evaluate())
+	}
+}
+
+func TestPassedPawns(t *testing.T) {
+	type set struct {
+		Square   string
+		Color    Color
+		Passed   bool
+		Distance int
+	}
+
+	board := NewBoard()
+	if err := board.SetFEN("4k3/1p6/8/P6p/4P3/8/6P1/4K3 w - - 0 1"); err != nil {
+		t.Fatalf(err.Error())
+	}
+	testset := []set{
+		set{"a5", WHITE, false, 3},
+		set{"e4", WHITE, true, 4},
+		set{"g2", WHITE, false, 5},
+		set{"b7", BLACK, false, 5},
+		set{"h5", BLACK, false, 4},
+	}
+	for _, ts := range testset {
+		sq, err := parseFENSquare(ts.Square)
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		sq = sq.To0x88()
+		if board.IsPassedPawn(sq, ts.Color) != ts.Passed {
+			t.Fatalf("Expected pawn on %s to be passed: %v\n", ts.Square, ts.Passed)
+		}
+		if dist := board.DistanceToPromotion(sq, ts.Color); dist != ts.Distance {
+			t.Fatalf("Expected pawn on %s to be %d moves from promotion but got %d\n", ts.Square, ts.Distance, dist)
+		}
+	}
+
+	// A passed pawn on the 6th rank is worth much more than one on the 3rd rank.
+	advanced := NewBoard()
+	if err := advanced.SetFEN("4k3/8/P7/8/8/8/8/4K3 w - - 0 1"); err != nil {
+		t.Fatalf(err.Error())
+	}
+	behind := NewBoard()
+	if err := behind.SetFEN("4k3/8/8/8/8/P7/8/4K3 w - - 0 1"); err != nil {
+		t.Fatalf(err.Error())
+	}
+	adv, beh := advanced.EvalBreakdown(), behind.EvalBreakdown()
+	if adv.PassedPawns < 2*beh.PassedPawns || adv.Total-beh.Total < VALUE_PAWN/2 {
+		t.Fatalf("Expected the advanced passed pawn (%d, total %d) to score much more than the one behind (%d, total %d)\n",
+			adv.PassedPawns, adv.Total, beh.PassedPawns, beh.Total)
+	}
+
+	// A blocked passed pawn scores less.
+	blocked := NewBoard()
+	if err := blocked.SetFEN("4k3/n7/P7/8/8/8/8/4K3 w - - 0 1"); err != nil {
+		t.Fatalf(err.Error())
+	}
+	if blocked.EvalBreakdown().PassedPawns >= adv.PassedPawns {
+		t.Fatalf("Expected the blocked passed pawn to score less than %d\n", adv.PassedPawns)
+	}
+}
+
+func TestPassedPawnSquares(t *testing.T) {
+	board := NewBoard()
+	// Only the pawn on e4 is passed.
+	if err := board.SetFEN("4k3/1p6/8/P6p/4P3/8/6P1/4K3 w - - 0 1"); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	white := board.PassedPawns(WHITE)
+	if len(white) != 1 || PrintBoardIndex[white[0]] != "e4" {
+		t.Fatalf("Expected the passed pawn e4 but got %v\n", white)
+	}
+	if black := board.PassedPawns(BLACK); len(black) != 0 {
+		t.Fatalf("Expected no black passed pawns but got %v\n", black)
+	}
+	if e := board.EvalBreakdown(); e.PassedPawns <= 0 {
+		t.Fatalf("Expected the evaluation to reward white's passed pawn but got %d\n", e.PassedPawns)
+	}
+}
+
+func TestSpace(t *testing.T) {
+	// The advanced white pawn chain gains space, black is cramped.
+	board := NewBoard()
+	if err := board.SetFEN("4k3/8/2ppp3/2PPPp2/5P2/8/8/4K3 w - - 0 1"); err != nil {
+		t.Fatalf(err.Error())
+	}
+	if white, black := board.space(WHITE), board.space(BLACK); white != 10*SPACE_WEIGHT || black != 4*SPACE_WEIGHT {
+		t.Fatalf("Expected space %d for white and %d for black but got %d and %d\n", 10*SPACE_WEIGHT, 4*SPACE_WEIGHT, white, black)
+	}
+	if e := board.EvalBreakdown(); e.Space <= 0 {
+		t.Fatalf("Expected a positive space term for white but got %d\n", e.Space)
+	}
+
+	// The starting position is balanced.
+	board.SetStartingPosition()
+	if e := board.EvalBreakdown(); e.Space != 0 {
+		t.Fatalf("Expected no space advantage in the starting position but got %d\n", e.Space)
+	}
+}
+
+func TestTempo(t *testing.T) {
+	type set struct {
+		Fen   string
+		Tempo int
+	}
+
+	// Symmetric positions evaluate to the tempo bonus of the side to move.
+	testset := []set{
+		set{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", TEMPO_BONUS},
+		set{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR b KQkq - 0 1", TEMPO_BONUS},
+		set{"r1bqkb1r/pppp1ppp/2n2n2/4p3/4P3/2N2N2/PPPP1PPP/R1BQKB1R w KQkq - 4 4", TEMPO_BONUS},
+		// The bonus is reduced in the endgame.
+		set{"4k3/pppp4/8/8/8/8/PPPP4/4K3 w - - 0 1", TEMPO_BONUS / TEMPO_ENDGAME_DIVISOR},
+	}
+
+	for i, ts := range testset {
+		board := NewBoard()
+		if err := board.SetFEN(ts.Fen); err != nil {
+			t.Fatalf(err.Error())
+		}
+		if score := board.Evaluate(); score != ts.Tempo {
+			t.Fatalf("Test %d: expected evaluation %d but got %d\n", i, ts.Tempo, score)
+		}
+	}
+
+	// Without tempo the symmetric starting position is equal.
+	board := NewBoard()
+	board.SetStartingPosition()
+	cfg := DefaultEvalConfig
+	cfg.Tempo = 0
+	if score := board.EvaluateWith(&cfg); score != 0 {
+		t.Fatalf("Expected evaluation 0 without tempo but got %d\n", score)
+	}
+}
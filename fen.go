@@ -17,6 +17,18 @@ var (
 	ErrFENSquareInvalid = errors.New("FEN has invalid square")
 	// ErrFENMoveNumInvalid indicates that a FEN record contains an invalid move number.
 	ErrFENMoveNumInvalid = errors.New("FEN has invalid move number")
+	// ErrFENKingsInvalid indicates that a FEN record does not have exactly one king per color.
+	ErrFENKingsInvalid = errors.New("FEN must have exactly one king per color")
+	// ErrFENTooManyPieces indicates that a FEN record has more than 16 pieces or 8 pawns of one color.
+	ErrFENTooManyPieces = errors.New("FEN has too many pieces of one color")
+	// ErrFENCastlingInvalid indicates that a FEN record has castling rights, but the king
+	// or the rook is not on its home square.
+	ErrFENCastlingInvalid = errors.New("FEN has castling rights without king or rook on the home square")
+	// ErrFENEnPassantInvalid indicates that a FEN record has an e.p. square, which is not
+	// behind a pawn that just moved two squares.
+	ErrFENEnPassantInvalid = errors.New("FEN has invalid en passant square")
+	// ErrFENOpponentInCheck indicates that the side not to move is in check in a FEN record.
+	ErrFENOpponentInCheck = errors.New("FEN has the side not to move in check")
 
 	// FENMap maps FEN piece symbols (plus empty ' ') to the internal definition.
 	FENMap = map[rune]Piece{
@@ -85,15 +97,21 @@ func ParseFEN(fen string) (MinBoard, error) {
 	}
 
 	// Extract half moves since last capture or pawn movement.
-	halfMoves, err := parseFENMoveNumber(fields[4])
-	if err != nil {
-		return mb, err
+	// Many FENs in the wild omit the move counters, so they default to 0 and 1.
+	halfMoves, moveNum := uint16(0), uint16(1)
+	if len(fields) > 4 {
+		halfMoves, err = parseFENMoveNumber(fields[4])
+		if err != nil {
+			return mb, err
+		}
 	}
 
 	// Extract full move number.
-	moveNum, err := parseFENMoveNumber(fields[5])
-	if err != nil {
-		return mb, err
+	if len(fields) > 5 {
+		moveNum, err = parseFENMoveNumber(fields[5])
+		if err != nil {
+			return mb, err
+		}
 	}
 
 	mb.Squares = pieces
@@ -107,12 +125,145 @@ func ParseFEN(fen string) (MinBoard, error) {
 	return mb, nil
 }
 
+// FEN returns the FEN record of the current position.
+func (b *Board) FEN() string {
+	var sb strings.Builder
+
+	// Piece placement from rank 8 down to rank 1.
+	for r := 7; r >= 0; r-- {
+		empty := 0
+		for f := 0; f < 8; f++ {
+			piece := b.Squares[16*r+f]
+			if piece.IsEmpty() {
+				empty++
+				continue
+			}
+			if empty > 0 {
+				sb.WriteString(strconv.Itoa(empty))
+				empty = 0
+			}
+			sb.WriteString(PrintMap[piece])
+		}
+		if empty > 0 {
+			sb.WriteString(strconv.Itoa(empty))
+		}
+		if r > 0 {
+			sb.WriteByte('/')
+		}
+	}
+
+	// Active color.
+	if b.Player == WHITE {
+		sb.WriteString(" w ")
+	} else {
+		sb.WriteString(" b ")
+	}
+
+	// Castling rights.
+	sb.WriteString(b.CastlingRights())
+
+	// En passent square.
+	if b.EpSquare != OTB {
+		sb.WriteString(" " + PrintBoardIndex[b.EpSquare] + " ")
+	} else {
+		sb.WriteString(" - ")
+	}
+
+	// Move counters.
+	sb.WriteString(strconv.Itoa(int(b.DrawCounter)) + " " + strconv.Itoa(int(b.MoveNumber)))
+
+	return sb.String()
+}
+
+// CastlingRights returns the castling rights in FEN notation, e.g. "KQkq" or "Kq",
+// or "-" if neither player may castle.
+func (b *Board) CastlingRights() string {
+	castling := ""
+	if b.CanCastle(WHITE, true) {
+		castling += "K"
+	}
+	if b.CanCastle(WHITE, false) {
+		castling += "Q"
+	}
+	if b.CanCastle(BLACK, true) {
+		castling += "k"
+	}
+	if b.CanCastle(BLACK, false) {
+		castling += "q"
+	}
+	if castling == "" {
+		castling = "-"
+	}
+	return castling
+}
+
+// IsLegalFEN validates a FEN record without setting up a board. Besides the syntax it
+// tests the numbers of kings, pieces and pawns, pawns on the first or last rank, the
+// consistency of the castling rights and the e.p. square, and if the side not to move
+// is in check. It returns nil if the FEN is legal and a descriptive error otherwise.
+// Contrary to Board.IsLegalPosition it does not reject impossible multiple checks.
+func IsLegalFEN(fen string) error {
+	mb, err := ParseFEN(fen)
+	if err != nil {
+		return err
+	}
+	if err := mb.validate(); err != nil {
+		return err
+	}
+
+	// The attacks are detected on the bare squares, without piece lists or an info board.
+	squares := mb.to0x88()
+	king := KING | mb.Color.Flip()
+	for _, sq := range Lookup0x88 {
+		if squares[sq] == king && isAttackedOn(&squares, sq, mb.Color) {
+			return ErrFENOpponentInCheck
+		}
+	}
+	return nil
+}
+
+// isAttackedOn tests if 'sq' is attacked by any piece of 'color' on the bare 0x88 'squares'.
+func isAttackedOn(squares *[128]Piece, sq Square, color Color) bool {
+	for _, dir := range PAWN_CAPTURE_DIRS[color] {
+		if from := sq.Add(-dir); from.OnBoard() && squares[from] == PAWN|color {
+			return true
+		}
+	}
+	for _, dir := range KNIGHT_DIRS {
+		if from := sq.Add(dir); from.OnBoard() && squares[from] == KNIGHT|color {
+			return true
+		}
+	}
+	for _, dir := range KING_DIRS {
+		if from := sq.Add(dir); from.OnBoard() && squares[from] == KING|color {
+			return true
+		}
+	}
+	sliders := [2]struct {
+		dirs  [4]int8
+		ptype Piece
+	}{{DIAGONAL_DIRS, BISHOP}, {ORTHOGONAL_DIRS, ROOK}}
+	for _, slider := range sliders {
+		for _, dir := range slider.dirs {
+			from := sq.Add(dir)
+			for from.OnBoard() && squares[from].IsEmpty() {
+				from = from.Add(dir)
+			}
+			if from.OnBoard() && (squares[from] == slider.ptype|color || squares[from] == QUEEN|color) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // SplitFields splits a FEN into its fields and returns them separated into a slice,
-// or an error if the amount of fields is not equal 6.
+// or an error if the amount of fields is not between 4 and 6. The move counter
+// fields are optional.
 func splitFENFields(fen string) ([]string, error) {
 	// Split for any number of whitespaces. Is fault tolerant to some malformed FENs.
 	fields := strings.Fields(fen)
-	if len(fields) != 6 {
+	if len(fields) < 4 || len(fields) > 6 {
 		return nil, ErrFENFieldsInvalid
 	}
 
@@ -191,6 +342,7 @@ func parseFENColor(color string) (Color, error) {
 }
 
 // ParseCastlingRights parses which player still has rights to castle short and long.
+// The letters may appear in any order, "-" means no castling rights at all.
 // The returned arrays describe the castling rights as follows:
 // short:[BLACK, WHITE], long:[BLACK, WHITE]
 func parseFENCastlingRights(castle string) ([2]bool, [2]bool) {
@@ -1,22 +1,29 @@
 package chesskimo
 
 import (
+	"math/rand"
+	"strings"
 	"testing"
 )
 
 // TestSplitOK tests if the SplitFields function behaves correctly.
 func TestSplitFields(t *testing.T) {
 	sixFields := "1 2 3 4 5 6"
-	lessThanSixFields := "1   2  3  4"
+	fourFields := "1   2  3  4"
+	lessThanFourFields := "1 2  3"
 	moreThanSixFields := "1 2 3 4 5 6 7 8"
 
 	_, err1 := splitFENFields(sixFields)
 	if err1 != nil {
 		t.Fatalf("Expected pass, split FEN is %s\n", sixFields)
 	}
-	_, err2 := splitFENFields(lessThanSixFields)
-	if err2 == nil {
-		t.Fatalf("Expected fail, split FEN is %s\n", lessThanSixFields)
+	_, err2 := splitFENFields(fourFields)
+	if err2 != nil {
+		t.Fatalf("Expected pass, split FEN is %s\n", fourFields)
+	}
+	_, err4 := splitFENFields(lessThanFourFields)
+	if err4 == nil {
+		t.Fatalf("Expected fail, split FEN is %s\n", lessThanFourFields)
 	}
 
 	_, err3 := splitFENFields(moreThanSixFields)
@@ -25,6 +32,55 @@ func TestSplitFields(t *testing.T) {
 	}
 }
 
+// TestParseFENOptionalCounters tests that FENs without move counters are accepted.
+func TestParseFENOptionalCounters(t *testing.T) {
+	six := "r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 3 17"
+	five := "r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 3"
+	four := "r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq -"
+
+	mb6, err := ParseFEN(six)
+	if err != nil {
+		t.Fatalf("Expected pass for FEN %s but got ERR: %s\n", six, err.Error())
+	}
+	mb5, err := ParseFEN(five)
+	if err != nil {
+		t.Fatalf("Expected pass for FEN %s but got ERR: %s\n", five, err.Error())
+	}
+	mb4, err := ParseFEN(four)
+	if err != nil {
+		t.Fatalf("Expected pass for FEN %s but got ERR: %s\n", four, err.Error())
+	}
+
+	if mb5.HalfMoves != 3 || mb5.MoveNum != 1 {
+		t.Fatalf("Expected counters 3 1 for FEN %s but got %d %d\n", five, mb5.HalfMoves, mb5.MoveNum)
+	}
+	if mb4.HalfMoves != 0 || mb4.MoveNum != 1 {
+		t.Fatalf("Expected counters 0 1 for FEN %s but got %d %d\n", four, mb4.HalfMoves, mb4.MoveNum)
+	}
+
+	// Aside from the counters all boards must be equal.
+	mb5.HalfMoves, mb5.MoveNum = mb6.HalfMoves, mb6.MoveNum
+	mb4.HalfMoves, mb4.MoveNum = mb6.HalfMoves, mb6.MoveNum
+	if mb5 != mb6 {
+		t.Fatalf("Expected equal boards for FENs %s and %s\n", six, five)
+	}
+	if mb4 != mb6 {
+		t.Fatalf("Expected equal boards for FENs %s and %s\n", six, four)
+	}
+
+	// Truly malformed FENs are still rejected.
+	malformed := []string{
+		"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq",
+		"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - x",
+		"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1 5",
+	}
+	for _, fen := range malformed {
+		if _, err := ParseFEN(fen); err == nil {
+			t.Fatalf("Expected fail for FEN %s\n", fen)
+		}
+	}
+}
+
 // TestParsePieces tests if the ParsePieces function behaves correctly.
 func TestParsePieces(t *testing.T) {
 	valid := []string{
@@ -166,3 +222,166 @@ func TestParseMoveNumber(t *testing.T) {
 		}
 	}
 }
+
+// TestFEN tests if FEN records survive a round trip through the board.
+func TestFEN(t *testing.T) {
+	fens := []string{
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+		"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1",
+		"rnbqkb1r/pp2pppp/5n2/2ppP3/3P4/8/PPP2PPP/RNBQKBNR w KQkq d6 0 4",
+		"r3k2r/Pppp1ppp/1b3nbN/nP6/BBP1P3/q4N2/Pp1P2PP/R2Q1RK1 w kq - 0 1",
+		"8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 0",
+	}
+
+	board := NewBoard()
+	for _, fen := range fens {
+		if err := board.SetFEN(fen); err != nil {
+			t.Fatalf(err.Error())
+		}
+		if board.FEN() != fen {
+			t.Fatalf("Expected FEN %s but got %s\n", fen, board.FEN())
+		}
+	}
+}
+
+func TestCastlingRights(t *testing.T) {
+	type set struct {
+		Fen    string
+		Moves  []string
+		Rights string
+	}
+
+	kiwipete := "r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1"
+	testset := []set{
+		set{kiwipete, nil, "KQkq"},
+		set{kiwipete, []string{"h1f1"}, "Qkq"},
+		set{kiwipete, []string{"a1b1", "h8f8"}, "Kq"},
+		set{kiwipete, []string{"e1d1", "a8b8"}, "k"},
+		set{kiwipete, []string{"e1g1", "e8c8"}, "-"},
+		// Capturing the rook on a8 removes the right of black to castle long.
+		set{"r3k2r/1p6/8/8/8/8/8/R3K2R w KQkq - 0 1", []string{"a1a8"}, "Kk"},
+		set{"4k2r/8/8/8/8/8/8/R3K3 w Qk - 0 1", nil, "Qk"},
+	}
+
+	board := NewBoard()
+	for i, ts := range testset {
+		if err := board.SetFEN(ts.Fen); err != nil {
+			t.Fatalf(err.Error())
+		}
+		if err := board.ApplyUCIMoves(ts.Moves); err != nil {
+			t.Fatalf(err.Error())
+		}
+		if rights := board.CastlingRights(); rights != ts.Rights {
+			t.Fatalf("Test %d: expected castling rights %s but got %s\n", i, ts.Rights, rights)
+		}
+		for j, r := range "KQkq" {
+			color := WHITE
+			if j >= 2 {
+				color = BLACK
+			}
+			if board.CanCastle(color, j%2 == 0) != strings.ContainsRune(ts.Rights, r) {
+				t.Fatalf("Test %d: CanCastle disagrees with %s for %c\n", i, ts.Rights, r)
+			}
+		}
+	}
+}
+
+func TestIsLegalFEN(t *testing.T) {
+	type set struct {
+		Fen string
+		Err error
+	}
+
+	testset := []set{
+		set{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", nil},
+		set{"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1", nil},
+		set{"rnbqkbnr/pppp1ppp/8/4p3/4P3/8/PPPP1PPP/RNBQKBNR w KQkq e6 0 2", nil},
+		// The side to move may be in check.
+		set{"4k3/8/8/8/8/8/8/4RK2 b - - 0 1", nil},
+		set{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0", nil},
+		set{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPP/RNBQKBNR w KQkq - 0 1", ErrFENRanksInvalid},
+		set{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR x KQkq - 0 1", ErrFENColorInvalid},
+		set{"8/8/8/8/8/8/8/4K3 w - - 0 1", ErrFENKingsInvalid},
+		set{"4k3/8/8/8/8/8/8/3KK3 w - - 0 1", ErrFENKingsInvalid},
+		set{"P3k3/8/8/8/8/8/8/4K3 w - - 0 1", ErrPawnOnBackRank},
+		set{"4k3/pppppppp/p7/8/8/8/8/4K3 w - - 0 1", ErrFENTooManyPieces},
+		set{"4k3/8/8/8/8/8/8/4K3 w K - 0 1", ErrFENCastlingInvalid},
+		set{"r3k3/8/8/8/8/8/8/4K3 w q - 0 1", nil},
+		set{"1r2k3/8/8/8/8/8/8/4K3 w q - 0 1", ErrFENCastlingInvalid},
+		set{"4k3/8/8/8/8/8/8/4K3 w - e6 0 1", ErrFENEnPassantInvalid},
+		set{"4k3/8/8/8/8/8/8/4RK2 w - - 0 1", ErrFENOpponentInCheck},
+		set{"8/8/8/8/8/8/3k4/4K3 w - - 0 1", ErrFENOpponentInCheck},
+		set{"4k3/8/8/8/8/8/6B1/K7 w - - 0 1", nil},
+		set{"4k3/8/8/1B6/8/8/8/K7 w - - 0 1", ErrFENOpponentInCheck},
+		set{"4k3/3P4/8/8/8/8/8/K7 w - - 0 1", ErrFENOpponentInCheck},
+		set{"4k3/8/3N4/8/8/8/8/K7 w - - 0 1", ErrFENOpponentInCheck},
+	}
+
+	for i, ts := range testset {
+		if err := IsLegalFEN(ts.Fen); err != ts.Err {
+			t.Fatalf("Test %d: expected error %v for %s but got %v\n", i, ts.Err, ts.Fen, err)
+		}
+		// Both validations share their rules for every FEN which can be parsed.
+		board := NewBoard()
+		if board.SetFEN(ts.Fen) == nil && board.IsLegalPosition() != (ts.Err == nil) {
+			t.Fatalf("Test %d: expected IsLegalPosition to be %v for %s\n", i, ts.Err == nil, ts.Fen)
+		}
+	}
+
+	// IsLegalFEN agrees with IsLegalPosition on legal random positions.
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 500; i++ {
+		board := RandomLegalPosition(rng, 2+i%31)
+		if err := IsLegalFEN(board.FEN()); err != nil {
+			t.Fatalf("Expected %s to be legal but got %v\n", board.FEN(), err)
+		}
+	}
+}
+
+// TestFENSloppyFormatting tests if FENs with extra whitespace and reordered castling
+// rights result in the same board as their canonical form.
+func TestFENSloppyFormatting(t *testing.T) {
+	type set struct {
+		Sloppy    string
+		Canonical string
+	}
+
+	testset := []set{
+		set{"  r3k2r/8/8/8/8/8/8/R3K2R  w   qKkQ  -  0   1  ", "r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1"},
+		set{"r3k2r/8/8/8/8/8/8/R3K2R\tb qk - 3 12\n", "r3k2r/8/8/8/8/8/8/R3K2R b kq - 3 12"},
+		set{"rnbqkbnr/pp1ppppp/8/2p5/4P3/8/PPPP1PPP/RNBQKBNR w  kQqK  c6 0 2", "rnbqkbnr/pp1ppppp/8/2p5/4P3/8/PPPP1PPP/RNBQKBNR w KQkq c6 0 2"},
+		set{"4k3/8/8/8/8/8/8/4K3 b - -", "4k3/8/8/8/8/8/8/4K3 b - - 0 1"},
+	}
+
+	for _, ts := range testset {
+		sloppy, canonical := NewBoard(), NewBoard()
+		if err := sloppy.SetFEN(ts.Sloppy); err != nil {
+			t.Fatalf("Expected %q to be accepted but got: %s\n", ts.Sloppy, err.Error())
+		}
+		if err := canonical.SetFEN(ts.Canonical); err != nil {
+			t.Fatalf(err.Error())
+		}
+		if !sloppy.Equals(&canonical) || sloppy.Hash != canonical.Hash || sloppy.FEN() != ts.Canonical {
+			t.Fatalf("Expected %q to equal %s but got %s\n", ts.Sloppy, ts.Canonical, sloppy.FEN())
+		}
+	}
+}
+
+// TestFullMoveNumber tests if the full move number is only increased after black moved.
+func TestFullMoveNumber(t *testing.T) {
+	board := NewBoard()
+	if err := board.SetFEN("r1bqkbnr/pppp1ppp/2n5/4p3/4P3/5N2/PPPP1PPP/RNBQKB1R b KQkq - 3 3"); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	expected := []string{
+		"r1bqkb1r/pppp1ppp/2n2n2/4p3/4P3/5N2/PPPP1PPP/RNBQKB1R w KQkq - 4 4",
+		"r1bqkb1r/pppp1ppp/2n2n2/4p3/4P3/2N2N2/PPPP1PPP/R1BQKB1R b KQkq - 5 4",
+	}
+	for i, m := range []string{"g8f6", "b1c3"} {
+		board.MakeLegalMove(parseTestMove(t, m))
+		if board.FEN() != expected[i] {
+			t.Fatalf("Expected FEN %s after %s but got %s\n", expected[i], m, board.FEN())
+		}
+	}
+}
@@ -0,0 +1,276 @@
+package chesskimo
+
+const (
+	// Rules which allow a player to claim a draw.
+	DRAW_RULE_THREEFOLD  = "threefold"
+	DRAW_RULE_FIFTY_MOVE = "fiftymove"
+)
+
+// Game is a chess game with its history of moves and positions. Contrary to Board
+// it knows all previous positions and can therefore detect repetitions.
+type Game struct {
+	// Tags holds meta data like the PGN tag pairs, e.g. "White" or "Event".
+	Tags  map[string]string
+	Board Board
+	Moves []BitMove
+	// Tree holds all moves including variations and comments, if the game was read from PGN.
+	Tree *GameTree
+	// hashes contains the hash of every position of the game, including the current one.
+	hashes []uint64
+	// barrier is the index in hashes of the position after the last irreversible
+	// move (capture or pawn move). No position before it can ever repeat.
+	barrier int
+}
+
+// NewGame creates a game starting from the default starting position.
+func NewGame() *Game {
+	g := &Game{Tags: map[string]string{}, Board: NewBoard()}
+	g.hashes = append(g.hashes, g.Board.Hash)
+	return g
+}
+
+// NewGameFromFEN creates a game starting from the position given by 'fen'.
+func NewGameFromFEN(fen string) (*Game, error) {
+	g := NewGame()
+	if err := g.Board.SetFEN(fen); err != nil {
+		return nil, err
+	}
+	g.hashes[0] = g.Board.Hash
+	return g, nil
+}
+
+// MakeMove plays the move if it is legal and returns ErrIllegalMove otherwise.
+func (g *Game) MakeMove(m BitMove) error {
+	if !g.Board.IsLegalMove(m) {
+		return ErrIllegalMove
+	}
+	g.Board.MakeLegalMove(m)
+	g.Moves = append(g.Moves, m)
+	g.hashes = append(g.hashes, g.Board.Hash)
+	if g.Board.DrawCounter == 0 {
+		// The move was irreversible, just like the reset of the fifty-move rule.
+		g.barrier = len(g.hashes) - 1
+	}
+	return nil
+}
+
+// Repetitions returns how often the current position occurred in the game, including now.
+func (g *Game) Repetitions() int {
+	last := len(g.hashes) - 1
+	count := 1
+	// Positions before the last capture or pawn move cannot repeat. The same
+	// side must be to move, so only every second position is compared.
+	for i := last - 2; i >= g.barrier; i -= 2 {
+		if g.hashes[i] == g.hashes[last] {
+			count++
+		}
+	}
+	return count
+}
+
+// IsThreefoldRepetition tests if the current position occurred at least three times
+// since the last irreversible move.
+func (g *Game) IsThreefoldRepetition() bool {
+	return g.Repetitions() >= 3
+}
+
+// CanClaimDraw tests if the side to move may claim a draw and returns the applicable
+// rule: DRAW_RULE_THREEFOLD or DRAW_RULE_FIFTY_MOVE. Contrary to the automatic draws
+// reported by Result, these draws must be claimed by a player.
+func (g *Game) CanClaimDraw() (rule string, ok bool) {
+	if g.IsThreefoldRepetition() {
+		return DRAW_RULE_THREEFOLD, true
+	}
+	if g.Board.DrawCounter >= 100 {
+		return DRAW_RULE_FIFTY_MOVE, true
+	}
+	return "", false
+}
+
+// Result returns the state of the game. Checkmate ends the game and stalemate,
+// fivefold repetition, the seventy-five-move rule and dead positions
+// draw it automatically.
+func (g *Game) Result() State {
+	mlist := MoveList{}
+	g.Board.GenerateAllLegalMoves(&mlist)
+	if mlist.Size == 0 {
+		if g.Board.CheckInfo != CHECK_NONE {
+			// The side to move is mated.
+			if g.Board.Player == WHITE {
+				return GAMESTATE_BLACK_WIN
+			}
+			return GAMESTATE_WHITE_WIN
+		}
+		return GAMESTATE_DRAW
+	}
+
+	if g.Repetitions() >= 5 || g.Board.DrawCounter >= 150 || g.Board.IsDeadPosition() {
+		return GAMESTATE_DRAW
+	}
+	return GAMESTATE_ONGOING
+}
+
+// PushKey puts the hash of the position on a repetition stack, before the recursion of a
+// search descends into the moves of the position. It is removed again with PopKey. Contrary
+// to Game no moves are stored, so this is cheap enough for the search.
+func (b *Board) PushKey(stack *[]uint64) {
+	*stack = append(*stack, b.Hash)
+}
+
+// PopKey removes the hash on top of the repetition stack, see PushKey.
+func (b *Board) PopKey(stack *[]uint64) {
+	*stack = (*stack)[:len(*stack)-1]
+}
+
+// IsRepetition tests if the position occurred before on the repetition stack, which holds
+// the positions of the current line up to the previous one. Only positions since the last
+// irreversible move with the same side to move are compared.
+func (b *Board) IsRepetition(stack []uint64) bool {
+	oldest := len(stack) - int(b.DrawCounter)
+	for i := len(stack) - 2; i >= 0 && i >= oldest; i -= 2 {
+		if stack[i] == b.Hash {
+			return true
+		}
+	}
+	return false
+}
+
+// IsCheckmate tests if the side to move is in check and has no legal moves.
+// Moves are only generated if the king is in check.
+func (b *Board) IsCheckmate() bool {
+	return b.InCheck(b.Player) && !b.hasLegalMoves()
+}
+
+// IsStalemate tests if the side to move is not in check but has no legal moves.
+// Moves are only generated if the king is not in check.
+func (b *Board) IsStalemate() bool {
+	return !b.InCheck(b.Player) && !b.hasLegalMoves()
+}
+
+func (b *Board) hasLegalMoves() bool {
+	mlist := MoveList{}
+	b.GenerateAllLegalMoves(&mlist)
+	return mlist.Size > 0
+}
+
+// IsLegalPosition tests if the position could be set up for play: both sides have
+// exactly one king, the kings do not touch, no pawns stand on the first or last rank,
+// no side has more than 8 pawns or 16 pieces, the side not to move is not in check
+// and the side to move is checked by at most two pieces, one of them a slider.
+// Castling rights require the king and rook on their home squares and an e.p. square
+// requires the pawn which just moved two squares.
+func (b *Board) IsLegalPosition() bool {
+	mb := b.minBoard()
+	if mb.validate() != nil {
+		return false
+	}
+	// Touching kings are detected as a check of the side not to move.
+	if b.InCheck(b.Player.Flip()) {
+		return false
+	}
+	if checkers := b.Checkers(); len(checkers) > 2 ||
+		// One piece of a double check was uncovered, which must be a slider.
+		(len(checkers) == 2 && !b.Squares[checkers[0]].Overlaps(PINNERS_MASK) && !b.Squares[checkers[1]].Overlaps(PINNERS_MASK)) {
+		return false
+	}
+	return true
+}
+
+// IsInsufficientMaterial tests if neither side can possibly mate, which is the case
+// for a bare king against a king with at most one minor piece.
+func (b *Board) IsInsufficientMaterial() bool {
+	pieces := 0
+	for color := BLACK; color <= WHITE; color++ {
+		if b.Pawns[color].Size > 0 || b.Rooks[color].Size > 0 || b.Queens[color].Size > 0 {
+			return false
+		}
+		pieces += int(b.Knights[color].Size + b.Bishops[color].Size)
+	}
+	return pieces <= 1
+}
+
+// IsDeadPosition tests if no sequence of legal moves can lead to a checkmate. This is
+// a best-effort test, which covers insufficient material, bishops of only one square
+// color and pawn walls: all pawns are blocked by enemy pawns and cannot capture, the
+// kings cannot break through the wall and the only other pieces are bishops of one
+// square color, which cannot attack any enemy pawn.
+func (b *Board) IsDeadPosition() bool {
+	if b.IsInsufficientMaterial() {
+		return true
+	}
+
+	// Bishops on both square colors can mate, even if they belong to different sides.
+	bishopColors := [2]bool{}
+	for color := BLACK; color <= WHITE; color++ {
+		if b.Knights[color].Size > 0 || b.Rooks[color].Size > 0 || b.Queens[color].Size > 0 {
+			return false
+		}
+		for i := uint8(0); i < b.Bishops[color].Size; i++ {
+			bishopColors[b.Bishops[color].Pieces[i].SquareColor()] = true
+		}
+	}
+	if bishopColors[0] && bishopColors[1] {
+		return false
+	}
+	if len(b.EnPassantCapturers()) > 0 {
+		return false
+	}
+
+	for color := BLACK; color <= WHITE; color++ {
+		oppColor := color.Flip()
+		for i := uint8(0); i < b.Pawns[color].Size; i++ {
+			sq := b.Pawns[color].Pieces[i]
+			if b.Squares[sq.Add(PAWN_PUSH_DIRS[color])] != PAWN|oppColor {
+				return false
+			}
+			for _, dir := range PAWN_CAPTURE_DIRS[color] {
+				if to := sq.Add(dir); to.OnBoard() && !b.Squares[to].IsEmpty() && b.Squares[to].PieceColor() == oppColor {
+					return false
+				}
+			}
+		}
+		for i := uint8(0); i < b.Bishops[color].Size; i++ {
+			bishopColor := b.Bishops[color].Pieces[i].SquareColor()
+			for k := uint8(0); k < b.Pawns[oppColor].Size; k++ {
+				if b.Pawns[oppColor].Pieces[k].SquareColor() == bishopColor {
+					return false
+				}
+			}
+		}
+		if b.kingCanReachPawns(color) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// kingCanReachPawns tests if the king of 'color' can walk to a square where it captures
+// an enemy pawn. The pawns are assumed not to move, squares attacked by them are avoided.
+func (b *Board) kingCanReachPawns(color Color) bool {
+	oppPawn := PAWN | color.Flip()
+	visited := [128]bool{}
+	stack := []Square{b.Kings[color]}
+	visited[b.Kings[color]] = true
+
+	for len(stack) > 0 {
+		sq := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		for _, dir := range KING_DIRS {
+			to := sq.Add(dir)
+			if !to.OnBoard() || visited[to] || b.Squares[to] == PAWN|color {
+				continue
+			}
+			visited[to] = true
+			if b.IsAttackedByType(to, color.Flip(), PAWN) {
+				continue
+			}
+			if b.Squares[to] == oppPawn {
+				return true
+			}
+			stack = append(stack, to)
+		}
+	}
+	return false
+}
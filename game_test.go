@@ -0,0 +1,249 @@
+package chesskimo
+
+import (
+	"testing"
+)
+
+func TestCanClaimDrawThreefold(t *testing.T) {
+	g := NewGame()
+	shuffle := []string{"g1f3", "g8f6", "f3g1", "f6g8"}
+
+	for round := 0; round < 2; round++ {
+		if rule, ok := g.CanClaimDraw(); ok {
+			t.Fatalf("Expected no draw claim in round %d but got %s\n", round, rule)
+		}
+		for _, m := range shuffle {
+			if err := g.MakeMove(parseTestMove(t, m)); err != nil {
+				t.Fatalf(err.Error())
+			}
+		}
+	}
+
+	// The starting position occurred three times now.
+	if g.Repetitions() != 3 {
+		t.Fatalf("Expected 3 repetitions but got %d\n", g.Repetitions())
+	}
+	rule, ok := g.CanClaimDraw()
+	if !ok || rule != DRAW_RULE_THREEFOLD {
+		t.Fatalf("Expected draw claim by %s but got %q\n", DRAW_RULE_THREEFOLD, rule)
+	}
+	if g.Result() != GAMESTATE_ONGOING {
+		t.Fatalf("Expected the game to go on until the draw is claimed\n")
+	}
+
+	// A fivefold repetition ends the game automatically.
+	for round := 0; round < 2; round++ {
+		for _, m := range shuffle {
+			if err := g.MakeMove(parseTestMove(t, m)); err != nil {
+				t.Fatalf(err.Error())
+			}
+		}
+	}
+	if g.Result() != GAMESTATE_DRAW {
+		t.Fatalf("Expected a draw after fivefold repetition\n")
+	}
+}
+
+func TestRepetitionBarrier(t *testing.T) {
+	g, err := NewGameFromFEN("4k1n1/8/8/8/8/2p5/8/1N2K1N1 w - - 0 1")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	play := func(moves ...string) {
+		for _, m := range moves {
+			if err := g.MakeMove(parseTestMove(t, m)); err != nil {
+				t.Fatalf("Move %s: %s\n", m, err.Error())
+			}
+		}
+	}
+
+	// The starting position occurs twice before the capture and the
+	// position after the capture occurs twice after it.
+	play("g1f3", "g8f6", "f3g1", "f6g8", "b1c3")
+	play("g8f6", "g1f3", "f6g8", "f3g1")
+	if g.Repetitions() != 2 || g.IsThreefoldRepetition() {
+		t.Fatalf("Expected 2 repetitions but got %d\n", g.Repetitions())
+	}
+
+	// Positions before the capture must not be counted, even if they look
+	// identical. Fake a hash collision with the current position.
+	for i := 0; i < g.barrier; i++ {
+		g.hashes[i] = g.Board.Hash
+	}
+	if g.Repetitions() != 2 {
+		t.Fatalf("Expected positions before the capture to be ignored but got %d repetitions\n", g.Repetitions())
+	}
+	if rule, ok := g.CanClaimDraw(); ok {
+		t.Fatalf("Expected no draw claim but got %s\n", rule)
+	}
+
+	play("g8f6", "g1f3", "f6g8", "f3g1")
+	if !g.IsThreefoldRepetition() {
+		t.Fatalf("Expected threefold repetition but got %d repetitions\n", g.Repetitions())
+	}
+}
+
+func TestRepetitionKeys(t *testing.T) {
+	board := NewBoard()
+	if err := board.SetFEN("4k1n1/8/8/8/8/2p5/8/1N2K1N1 w - - 0 1"); err != nil {
+		t.Fatalf(err.Error())
+	}
+	stack := []uint64{}
+	play := func(moves ...string) {
+		for _, m := range moves {
+			board.PushKey(&stack)
+			board.MakeLegalMove(parseTestMove(t, m))
+		}
+	}
+
+	play("g1f3", "g8f6", "f3g1")
+	if board.IsRepetition(stack) {
+		t.Fatalf("Expected no repetition after 3 plies\n")
+	}
+	play("f6g8")
+	if !board.IsRepetition(stack) || len(stack) != 4 {
+		t.Fatalf("Expected the starting position to repeat after 4 plies\n")
+	}
+
+	board.PopKey(&stack)
+	if len(stack) != 3 || stack[2] == board.Hash {
+		t.Fatalf("Expected the last key to be popped\n")
+	}
+
+	// Positions before the capture are not compared, even if their hash matches.
+	if err := board.SetFEN("4k1n1/8/8/8/8/2p5/8/1N2K1N1 w - - 0 1"); err != nil {
+		t.Fatalf(err.Error())
+	}
+	stack = stack[:0]
+	play("b1c3", "g8f6", "g1f3", "f6g8")
+	stack[0] = board.Hash
+	if board.IsRepetition(stack) {
+		t.Fatalf("Expected positions before the capture to be ignored\n")
+	}
+	play("f3g1", "g8f6", "g1f3", "f6g8")
+	if !board.IsRepetition(stack) {
+		t.Fatalf("Expected a repetition after the capture\n")
+	}
+}
+
+func TestCanClaimDrawFiftyMove(t *testing.T) {
+	g, err := NewGameFromFEN("4k3/8/8/8/8/8/4R3/4K3 w - - 99 80")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if rule, ok := g.CanClaimDraw(); ok {
+		t.Fatalf("Expected no draw claim but got %s\n", rule)
+	}
+
+	if err := g.MakeMove(parseTestMove(t, "e2a2")); err != nil {
+		t.Fatalf(err.Error())
+	}
+	rule, ok := g.CanClaimDraw()
+	if !ok || rule != DRAW_RULE_FIFTY_MOVE {
+		t.Fatalf("Expected draw claim by %s but got %q\n", DRAW_RULE_FIFTY_MOVE, rule)
+	}
+
+	// A pawn move or capture resets the counter.
+	g, err = NewGameFromFEN("4k3/8/8/8/8/8/4P3/4K3 w - - 99 80")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if err := g.MakeMove(parseTestMove(t, "e2e4")); err != nil {
+		t.Fatalf(err.Error())
+	}
+	if rule, ok := g.CanClaimDraw(); ok {
+		t.Fatalf("Expected no draw claim after pawn move but got %s\n", rule)
+	}
+}
+
+func TestGameResult(t *testing.T) {
+	type set struct {
+		Fen    string
+		Result State
+	}
+
+	testset := []set{
+		set{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", GAMESTATE_ONGOING},
+		set{"rnb1kbnr/pppp1ppp/8/4p3/6Pq/5P2/PPPPP2P/RNBQKBNR w KQkq - 1 3", GAMESTATE_BLACK_WIN},
+		set{"r1bqkb1r/pppp1Qpp/2n2n2/4p3/2B1P3/8/PPPP1PPP/RNB1K1NR b KQkq - 0 4", GAMESTATE_WHITE_WIN},
+		set{"7k/5Q2/6K1/8/8/8/8/8 b - - 0 1", GAMESTATE_DRAW},
+		set{"4k3/8/8/8/8/8/8/2B1K3 w - - 0 1", GAMESTATE_DRAW},
+		set{"4k3/8/8/8/8/8/4R3/4K3 w - - 150 100", GAMESTATE_DRAW},
+	}
+
+	for i, ts := range testset {
+		g, err := NewGameFromFEN(ts.Fen)
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		if result := g.Result(); result != ts.Result {
+			t.Fatalf("Test %d: expected result %d but got %d\n", i, ts.Result, result)
+		}
+	}
+
+	// Illegal moves are rejected.
+	g := NewGame()
+	if err := g.MakeMove(parseTestMove(t, "e2e5")); err != ErrIllegalMove {
+		t.Fatalf("Expected illegal move error but got %v\n", err)
+	}
+}
+
+func TestIsCheckmateAndStalemate(t *testing.T) {
+	type set struct {
+		Fen       string
+		Checkmate bool
+		Stalemate bool
+	}
+
+	testset := []set{
+		set{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", false, false},
+		set{"rnb1kbnr/pppp1ppp/8/4p3/6Pq/5P2/PPPPP2P/RNBQKBNR w KQkq - 1 3", true, false},
+		set{"7k/5Q2/6K1/8/8/8/8/8 b - - 0 1", false, true},
+		// In check but not mated.
+		set{"4k3/8/8/8/8/8/4r3/4K3 w - - 0 1", false, false},
+	}
+
+	for i, ts := range testset {
+		board := NewBoard()
+		if err := board.SetFEN(ts.Fen); err != nil {
+			t.Fatalf(err.Error())
+		}
+		if mate := board.IsCheckmate(); mate != ts.Checkmate {
+			t.Fatalf("Test %d: expected checkmate %v but got %v\n", i, ts.Checkmate, mate)
+		}
+		if stalemate := board.IsStalemate(); stalemate != ts.Stalemate {
+			t.Fatalf("Test %d: expected stalemate %v but got %v\n", i, ts.Stalemate, stalemate)
+		}
+	}
+}
+
+func TestIsDeadPosition(t *testing.T) {
+	testset := map[string]bool{
+		// Insufficient material.
+		"4k3/8/8/8/8/8/8/4KB2 w - - 0 1": true,
+		// Bishops of one square color cannot mate, bishops of both colors can.
+		"4k3/8/8/8/8/8/8/3bKB2 w - - 0 1":  true,
+		"4k3/8/8/8/8/8/8/2B1KB2 w - - 0 1": false,
+		"4k3/8/8/8/8/8/8/2b1KB2 w - - 0 1": false,
+		// The kings cannot pass the pawn wall, the bishop cannot attack black pawns.
+		"8/8/1k6/p1p1p1p1/P1P1P1P1/8/4K3/5B2 w - - 0 1": true,
+		"8/8/1k6/p1p1p1p1/P1P1P1P1/8/4K3/8 b - - 0 1":   true,
+		// The bishop can attack black pawns.
+		"8/8/1k6/p1p1p1p1/P1P1P1P1/8/4K3/2B5 w - - 0 1": false,
+		// The kings can walk around the wall on the h-file.
+		"8/8/1k6/p1p1p3/P1P1P3/8/4K3/8 w - - 0 1": false,
+		// A pawn can capture.
+		"8/8/1k6/p1p1pp2/P1P1P1P1/8/4K3/8 w - - 0 1":               false,
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1": false,
+	}
+
+	board := NewBoard()
+	for fen, dead := range testset {
+		if err := board.SetFEN(fen); err != nil {
+			t.Fatalf(err.Error())
+		}
+		if board.IsDeadPosition() != dead {
+			t.Fatalf("Expected dead position %v for %s\n", dead, fen)
+		}
+	}
+}
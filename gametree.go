@@ -0,0 +1,58 @@
+package chesskimo
+
+// GameNode is a move in the move tree of a game together with its annotations.
+type GameNode struct {
+	Move BitMove
+	// SAN is the move as written in the source, e.g. the PGN movetext.
+	SAN     string
+	Comment string
+	NAGs    []int
+	Parent  *GameNode
+	// Children holds the mainline continuation first, followed by all sidelines.
+	Children []*GameNode
+	// position is the board after the move.
+	position Board
+}
+
+// GameTree holds the moves of a game including all variations. The root node
+// has no move, it holds the starting position and the comment before the first move.
+type GameTree struct {
+	Root *GameNode
+}
+
+// NewGameTree creates an empty tree starting from the given position.
+func NewGameTree(start Board) *GameTree {
+	return &GameTree{Root: &GameNode{position: start}}
+}
+
+// Position returns the board after the move of the node.
+func (n *GameNode) Position() Board {
+	return n.position
+}
+
+// Sidelines returns the alternatives to the mainline continuation of the node.
+func (n *GameNode) Sidelines() []*GameNode {
+	if len(n.Children) < 2 {
+		return nil
+	}
+	return n.Children[1:]
+}
+
+// AddChild appends a node for the legal move 'm' to the children of 'n'. The first
+// child of a node is the mainline.
+func (n *GameNode) AddChild(m BitMove, san string) *GameNode {
+	child := &GameNode{Move: m, SAN: san, Parent: n, position: n.position}
+	child.position.MakeLegalMove(m)
+	n.Children = append(n.Children, child)
+	return child
+}
+
+// Mainline returns the nodes of the mainline in the order they were played.
+func (t *GameTree) Mainline() []*GameNode {
+	nodes := []*GameNode{}
+	for n := t.Root; len(n.Children) > 0; {
+		n = n.Children[0]
+		nodes = append(nodes, n)
+	}
+	return nodes
+}
@@ -0,0 +1,122 @@
+package chesskimo
+
+import (
+	"time"
+)
+
+// mateSearch counts the nodes and the deepest ply of a mate search.
+type mateSearch struct {
+	nodes    uint64
+	selDepth int
+}
+
+// SolveMate searches for a forced mate for the side to move within 'maxPlies' plies.
+// If there is one, the principal mating line is returned: the attacker mates as fast
+// as possible and the defender resists as long as possible. Shorter mates are tried
+// first, so the returned line is always one of the shortest mates.
+func (b *Board) SolveMate(maxPlies int) ([]BitMove, bool) {
+	sr, ok := b.SolveMateWithStats(maxPlies)
+	return sr.PV, ok
+}
+
+// SolveMateWithStats works like SolveMate, but returns the mating line as SearchResult
+// together with the statistics of the search. Depth is the length of the longest mate
+// which was tried. If no mate is found, only the statistics are set.
+func (b *Board) SolveMateWithStats(maxPlies int) (SearchResult, bool) {
+	start := time.Now()
+	ms := mateSearch{}
+	sr := SearchResult{}
+	for plies := 1; plies <= maxPlies; plies += 2 {
+		sr.Depth = plies
+		if line, ok := ms.attack(b, plies, 0); ok {
+			sr.Move = line[0]
+			sr.Score = MatePliesToScore(len(line))
+			sr.PV = line
+			sr.Ponder = ponderMove(sr.Move, line)
+			break
+		}
+	}
+	sr.SelDepth = ms.selDepth
+	sr.Nodes = ms.nodes
+	sr.Time = time.Since(start)
+	return sr, sr.PV != nil
+}
+
+// visit counts a node at 'ply'.
+func (ms *mateSearch) visit(ply int) {
+	ms.nodes++
+	if ply > ms.selDepth {
+		ms.selDepth = ply
+	}
+}
+
+// attack tries to find an attacker's move which mates within 'plies' plies
+// (an odd number) against every defense.
+func (ms *mateSearch) attack(b *Board, plies, ply int) ([]BitMove, bool) {
+	ms.visit(ply)
+	mlist := MoveList{}
+	b.GenerateAllLegalMoves(&mlist)
+
+	// Checking moves are tried first, they are the most likely to mate.
+	checks := 0
+	for i := uint32(0); i < mlist.Size; i++ {
+		if b.GivesCheck(mlist.Moves[i]) {
+			mlist.Moves[i], mlist.Moves[checks] = mlist.Moves[checks], mlist.Moves[i]
+			checks++
+		}
+	}
+
+	cpy := *b
+	for i := uint32(0); i < mlist.Size; i++ {
+		if plies == 1 && i >= uint32(checks) {
+			// Only checks can mate immediately.
+			break
+		}
+		move := mlist.Moves[i]
+		b.MakeLegalMove(move)
+		line, ok := ms.defend(b, plies-1, ply+1)
+		*b = cpy
+		if ok {
+			return append([]BitMove{move}, line...), true
+		}
+	}
+
+	return nil, false
+}
+
+// defend tests if the defender gets mated within 'plies' plies (an even number)
+// after every reply. The line after the most resilient defense is returned.
+func (ms *mateSearch) defend(b *Board, plies, ply int) ([]BitMove, bool) {
+	ms.visit(ply)
+	mlist := MoveList{}
+	b.GenerateAllLegalMoves(&mlist)
+	if mlist.Size == 0 {
+		// Checkmate or stalemate.
+		return nil, b.CheckInfo != CHECK_NONE
+	}
+	if plies == 0 {
+		return nil, false
+	}
+
+	var longest []BitMove
+	cpy := *b
+	for i := uint32(0); i < mlist.Size; i++ {
+		move := mlist.Moves[i]
+		b.MakeLegalMove(move)
+		var line []BitMove
+		ok := false
+		// Find the fastest mate after this defense.
+		for p := 1; p < plies && !ok; p += 2 {
+			line, ok = ms.attack(b, p, ply+1)
+		}
+		*b = cpy
+		if !ok {
+			return nil, false
+		}
+		if longest == nil || len(line)+1 > len(longest) {
+			longest = append([]BitMove{move}, line...)
+		}
+	}
+
+	return longest, true
+}
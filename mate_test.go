@@ -0,0 +1,84 @@
+package chesskimo
+
+import (
+	"testing"
+)
+
+func TestSolveMate(t *testing.T) {
+	type set struct {
+		Fen   string
+		Plies int
+		First string
+	}
+
+	testset := []set{
+		// Scholar's mate.
+		set{"r1bqkb1r/pppp1ppp/2n2n2/4p2Q/2B1P3/8/PPPP1PPP/RNB1K1NR w KQkq - 4 4", 1, "h5f7"},
+		// Mate in 2: 1. Nf6+ gxf6 2. Bxf7#
+		set{"r2qkb1r/pp2nppp/3p4/2pNN1B1/2BnP3/3P4/PPP2PPP/R2bK2R w KQkq - 1 1", 3, "d5f6"},
+		// Mate in 3 for black against the exposed king.
+		set{"r1b1kb1r/pppp1ppp/5q2/4n3/3KP3/2N3PN/PPP4P/R1BQ1B1R b kq - 0 1", 5, ""},
+	}
+
+	board := NewBoard()
+	for i, ts := range testset {
+		if err := board.SetFEN(ts.Fen); err != nil {
+			t.Fatalf(err.Error())
+		}
+
+		// There is no shorter mate.
+		if _, ok := board.SolveMate(ts.Plies - 2); ok {
+			t.Fatalf("Test %d: found a mate shorter than %d plies\n", i, ts.Plies)
+		}
+
+		line, ok := board.SolveMate(ts.Plies)
+		if !ok {
+			t.Fatalf("Test %d: no mate found within %d plies\n", i, ts.Plies)
+		}
+		if len(line) != ts.Plies {
+			t.Fatalf("Test %d: expected a line of %d plies but got %d\n", i, ts.Plies, len(line))
+		}
+		if ts.First != "" && line[0].MiniNotation() != ts.First {
+			t.Fatalf("Test %d: expected first move %s but got %s\n", i, ts.First, line[0].MiniNotation())
+		}
+
+		// Play the line and verify it ends in mate.
+		b := board
+		for _, m := range line {
+			if !b.IsLegalMove(m) {
+				t.Fatalf("Test %d: illegal move %s in mating line\n", i, m.MiniNotation())
+			}
+			b.MakeLegalMove(m)
+		}
+		mlist := MoveList{}
+		b.GenerateAllLegalMoves(&mlist)
+		if mlist.Size != 0 || b.CheckInfo == CHECK_NONE {
+			t.Fatalf("Test %d: the line does not end in mate\n", i)
+		}
+	}
+}
+
+func TestSolveMateStats(t *testing.T) {
+	board := NewBoard()
+	if err := board.SetFEN("r2qkb1r/pp2nppp/3p4/2pNN1B1/2BnP3/3P4/PPP2PPP/R2bK2R w KQkq - 1 1"); err != nil {
+		t.Fatalf(err.Error())
+	}
+	sr, ok := board.SolveMateWithStats(5)
+	if !ok || sr.Move != sr.PV[0] || sr.Score != MatePliesToScore(3) || sr.Depth != 3 || sr.SelDepth != 3 {
+		t.Fatalf("Expected a mate in 3 plies but got %v with score %d, depth %d and seldepth %d\n", sr.PV, sr.Score, sr.Depth, sr.SelDepth)
+	}
+	if sr.Nodes == 0 || sr.Time <= 0 {
+		t.Fatalf("Expected the nodes and time of the search\n")
+	}
+
+	// Without a mate the search visits more nodes for longer lines.
+	board.SetStartingPosition()
+	prev := uint64(0)
+	for plies := 1; plies <= 3; plies += 2 {
+		sr, ok := board.SolveMateWithStats(plies)
+		if ok || sr.Depth != plies || sr.Nodes <= prev {
+			t.Fatalf("Expected no mate and more than %d nodes within %d plies but got %d\n", prev, plies, sr.Nodes)
+		}
+		prev = sr.Nodes
+	}
+}
@@ -20,3 +20,143 @@ func NewMinBoard() MinBoard {
 	}
 	return mb
 }
+
+// validate tests the rules shared by IsLegalFEN and Board.IsLegalPosition, which do not
+// need attack detection: both colors have exactly one king, no more than 16 pieces and
+// 8 pawns, no pawns stand on the first or last rank, castling rights require the king
+// and rook on their home squares and an e.p. square requires the pawn which just moved
+// two squares.
+func (mb *MinBoard) validate() error {
+	squares := mb.to0x88()
+	kings, pieces, pawns := [2]int{}, [2]int{}, [2]int{}
+	for idx, piece := range mb.Squares {
+		if piece.IsEmpty() {
+			continue
+		}
+		sq := Lookup0x88[idx]
+		color := piece.PieceColor()
+		pieces[color]++
+		switch piece & PIECE_MASK {
+		case KING:
+			kings[color]++
+		case PAWN:
+			if sq.Rank() == 0 || sq.Rank() == 7 {
+				return ErrPawnOnBackRank
+			}
+			pawns[color]++
+		}
+	}
+
+	for color := BLACK; color <= WHITE; color++ {
+		if kings[color] != 1 {
+			return ErrFENKingsInvalid
+		}
+		if pieces[color] > 16 || pawns[color] > 8 {
+			return ErrFENTooManyPieces
+		}
+		king := CASTLING_DETECT_SHORT[color][0]
+		if mb.CastleShort[color] && (squares[king] != KING|color || squares[CASTLING_ROOK_SHORT[color]] != ROOK|color) {
+			return ErrFENCastlingInvalid
+		}
+		if mb.CastleLong[color] && (squares[king] != KING|color || squares[CASTLING_ROOK_LONG[color]] != ROOK|color) {
+			return ErrFENCastlingInvalid
+		}
+	}
+
+	if mb.EpSquare != OTB {
+		// The pawn of the opponent passed the e.p. square, which must be empty like
+		// the square it came from.
+		oppColor := mb.Color.Flip()
+		epSq := Lookup0x88[mb.EpSquare]
+		pawnSq := epSq.Add(PAWN_PUSH_DIRS[oppColor])
+		fromSq := epSq.Add(-PAWN_PUSH_DIRS[oppColor])
+		if !fromSq.OnBoard() || !fromSq.IsPawnBaseRank(oppColor) || squares[pawnSq] != PAWN|oppColor ||
+			!squares[epSq].IsEmpty() || !squares[fromSq].IsEmpty() {
+			return ErrFENEnPassantInvalid
+		}
+	}
+	return nil
+}
+
+// to0x88 returns the pieces on a bare 0x88 board without an info board.
+func (mb *MinBoard) to0x88() [128]Piece {
+	squares := [128]Piece{}
+	for i := range squares {
+		squares[i] = EMPTY
+	}
+	for idx, piece := range mb.Squares {
+		squares[Lookup0x88[idx]] = piece
+	}
+	return squares
+}
+
+// minBoard returns the position of the board as MinBoard.
+func (b *Board) minBoard() MinBoard {
+	mb := MinBoard{
+		Squares:     b.ToMinBoard(),
+		Color:       b.Player,
+		CastleShort: b.CastleShort,
+		CastleLong:  b.CastleLong,
+		EpSquare:    OTB,
+		HalfMoves:   b.DrawCounter,
+		MoveNum:     b.MoveNumber,
+	}
+	if b.EpSquare != OTB {
+		mb.EpSquare = b.EpSquare.To8x8()
+	}
+	return mb
+}
+
+// ToMinBoard returns the pieces of the board in 8x8 order (a1=0 .. h8=63)
+// for consumers which do not know about 0x88 indexes.
+func (b *Board) ToMinBoard() [64]Piece {
+	squares := [64]Piece{}
+	for idx, sq := range Lookup0x88 {
+		squares[idx] = b.Squares[sq]
+	}
+	return squares
+}
+
+// FromMinBoard creates a board from pieces in 8x8 order (a1=0 .. h8=63), the side to
+// move, the castling rights and the en passant square, which is a 8x8 index or OTB.
+// The move counters start at 0 and 1. An error is returned if a square holds an invalid
+// piece, a color does not have exactly one king or the player or e.p. square is invalid.
+func FromMinBoard(squares [64]Piece, player Color, castleShort, castleLong [2]bool, epSquare Square) (Board, error) {
+	if player != BLACK && player != WHITE {
+		return Board{}, ErrColorInvalid
+	}
+	if epSquare != OTB && epSquare >= 64 {
+		return Board{}, ErrSquareOffBoard
+	}
+	kings := [2]int{}
+	for _, piece := range squares {
+		if piece == EMPTY {
+			continue
+		}
+		if _, ok := PrintMap[piece]; !ok {
+			return Board{}, ErrPieceInvalid
+		}
+		if piece&PIECE_MASK == KING {
+			kings[piece.PieceColor()]++
+		}
+	}
+	for _, n := range kings {
+		if n == 0 {
+			return Board{}, ErrKingMissing
+		} else if n > 1 {
+			return Board{}, ErrSecondKing
+		}
+	}
+
+	mb := MinBoard{
+		Squares:     squares,
+		Color:       player,
+		CastleShort: castleShort,
+		CastleLong:  castleLong,
+		EpSquare:    epSquare,
+		MoveNum:     1,
+	}
+	b := Board{Kings: [2]Square{OTB, OTB}}
+	b.setMinBoard(&mb)
+	return b, nil
+}
@@ -0,0 +1,77 @@
+package chesskimo
+
+import (
+	"testing"
+)
+
+func TestMinBoardRoundTrip(t *testing.T) {
+	fens := []string{
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+		"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1",
+		"rnbqkbnr/pppp1ppp/8/4p3/4P3/8/PPPP1PPP/RNBQKBNR w KQkq e6 0 2",
+		"8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 b - - 0 1",
+	}
+
+	for _, fen := range fens {
+		board := NewBoard()
+		if err := board.SetFEN(fen); err != nil {
+			t.Fatalf(err.Error())
+		}
+
+		squares := board.ToMinBoard()
+		if squares[0] != board.Squares[0x00] || squares[63] != board.Squares[0x77] {
+			t.Fatalf("Expected a1 and h8 at the ends of the MinBoard for %s\n", fen)
+		}
+
+		ep := board.EpSquare
+		if ep != OTB {
+			ep = ep.To8x8()
+		}
+		restored, err := FromMinBoard(squares, board.Player, board.CastleShort, board.CastleLong, ep)
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		if !restored.Equals(&board) {
+			t.Fatalf("Expected %s but got %s after the round trip\n", fen, restored.FEN())
+		}
+		if restored.Hash != board.Hash {
+			t.Fatalf("Expected hash %d but got %d for %s\n", board.Hash, restored.Hash, fen)
+		}
+		checkPieceLists(t, &restored)
+	}
+
+	// Equals detects a different side to move.
+	board := NewBoard()
+	other := NewBoard()
+	other.Player = BLACK
+	if board.Equals(&other) {
+		t.Fatalf("Expected boards with different players to differ\n")
+	}
+}
+
+func TestFromMinBoardInvalid(t *testing.T) {
+	board := NewBoard()
+	start := board.ToMinBoard()
+
+	type set struct {
+		Edit   func(squares *[64]Piece) (Color, Square)
+		Expect error
+	}
+	testset := []set{
+		// The white king is missing.
+		set{func(squares *[64]Piece) (Color, Square) { squares[4] = EMPTY; return WHITE, OTB }, ErrKingMissing},
+		// Black has two kings.
+		set{func(squares *[64]Piece) (Color, Square) { squares[61] = BKING; return WHITE, OTB }, ErrSecondKing},
+		set{func(squares *[64]Piece) (Color, Square) { squares[20] = KING | PAWN; return WHITE, OTB }, ErrPieceInvalid},
+		set{func(squares *[64]Piece) (Color, Square) { return Color(2), OTB }, ErrColorInvalid},
+		set{func(squares *[64]Piece) (Color, Square) { return WHITE, 64 }, ErrSquareOffBoard},
+	}
+
+	for i, ts := range testset {
+		squares := start
+		player, ep := ts.Edit(&squares)
+		if _, err := FromMinBoard(squares, player, [2]bool{}, [2]bool{}, ep); err != ts.Expect {
+			t.Fatalf("Test %d: expected error %v but got %v\n", i, ts.Expect, err)
+		}
+	}
+}
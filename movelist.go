@@ -1,5 +1,9 @@
 package chesskimo
 
+import (
+	"sync"
+)
+
 const (
 	// If this is not enough at any point the engine will blow :)
 	// I could not find any position with that many legal moves
@@ -16,6 +20,11 @@ func (ml *MoveList) Clear() {
 	ml.Size = 0
 }
 
+// Reset empties the list so it can be reused without allocating a new one.
+func (ml *MoveList) Reset() {
+	ml.Clear()
+}
+
 func (ml *MoveList) Put(m BitMove) {
 	ml.Moves[ml.Size] = m
 	ml.Size++
@@ -37,3 +46,25 @@ func (ml *MoveList) String() string {
 	str += "]"
 	return str
 }
+
+// movePool holds move lists for reuse, so tight loops like the search do not
+// allocate a new list for every node.
+var movePool = sync.Pool{
+	New: func() interface{} {
+		return &MoveList{}
+	},
+}
+
+// AcquireMoveList returns an empty move list from the pool. It is safe to be
+// called concurrently. The list should be returned via ReleaseMoveList.
+func AcquireMoveList() *MoveList {
+	ml := movePool.Get().(*MoveList)
+	ml.Reset()
+	return ml
+}
+
+// ReleaseMoveList returns the move list to the pool. The list must not be
+// used by the caller afterwards.
+func ReleaseMoveList(ml *MoveList) {
+	movePool.Put(ml)
+}
@@ -0,0 +1,67 @@
+package chesskimo
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestMoveListReuse(t *testing.T) {
+	board := NewBoard()
+	mlist := MoveList{}
+
+	board.GenerateAllLegalMoves(&mlist)
+	if mlist.Size != 20 {
+		t.Fatalf("Expected 20 moves but got %d\n", mlist.Size)
+	}
+	// Without a reset the moves are appended.
+	board.GenerateAllLegalMoves(&mlist)
+	if mlist.Size != 40 {
+		t.Fatalf("Expected 40 moves but got %d\n", mlist.Size)
+	}
+	mlist.Reset()
+	board.GenerateAllLegalMoves(&mlist)
+	if mlist.Size != 20 {
+		t.Fatalf("Expected 20 moves after reset but got %d\n", mlist.Size)
+	}
+}
+
+func TestMoveListPool(t *testing.T) {
+	fens := []string{
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+		"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1",
+		"8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1",
+	}
+	expected := []uint32{20, 48, 14}
+
+	wg := sync.WaitGroup{}
+	errs := make(chan string, len(fens)*50)
+	for n := 0; n < 50; n++ {
+		for i, fen := range fens {
+			wg.Add(1)
+			go func(fen string, want uint32) {
+				defer wg.Done()
+				board := NewBoard()
+				if err := board.SetFEN(fen); err != nil {
+					errs <- err.Error()
+					return
+				}
+				mlist := AcquireMoveList()
+				defer ReleaseMoveList(mlist)
+				if mlist.Size != 0 {
+					errs <- "acquired move list is not empty"
+					return
+				}
+				board.GenerateAllLegalMoves(mlist)
+				if mlist.Size != want {
+					errs <- fen + ": unexpected number of moves"
+				}
+			}(fen, expected[i])
+		}
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Fatalf("%s\n", err)
+	}
+}
@@ -0,0 +1,312 @@
+package chesskimo
+
+const (
+	// Stages of the MovePicker in the order they are processed.
+	STAGE_TT_MOVE = iota
+	STAGE_GENERATE_CAPTURES
+	STAGE_CAPTURES
+	STAGE_KILLERS
+	STAGE_GENERATE_QUIETS
+	STAGE_QUIETS
+	STAGE_DONE
+)
+
+// MovePicker yields the legal moves of a position in stages, so that work can be
+// saved if an early move causes a cutoff. The stages are:
+//   - the transposition table move, which is validated without generating all moves,
+//   - captures and promotions, which are generated alone and sorted by static exchange
+//     evaluation and capture history,
+//   - killer moves, which are validated without generating all moves,
+//   - all remaining quiet moves, which are only generated now.
+//
+// Every legal move is yielded exactly once.
+//
+// The search does not use a MovePicker yet: alphaBeta and quiesce generate all legal
+// moves up front anyway to detect checkmate and stalemate, so staged generation would
+// not save any work there.
+type MovePicker struct {
+	board   *Board
+	cfg     *EvalConfig
+	history *CaptureHistoryTable
+	ttMove  BitMove
+	killers [2]BitMove
+	stage   int
+
+	mlist  MoveList
+	scores [max_movelist_size]int
+	next   uint32
+	killer int
+}
+
+// NewMovePicker creates a MovePicker for the position on board 'b'. 'ttMove' and 'killers'
+// are hints which may be 0 or even illegal in this position. Captures are sorted by
+// the piece values of 'cfg'. Captures with equal exchange evaluations are ordered by
+// 'captureHistory', which may be nil.
+func NewMovePicker(b *Board, cfg *EvalConfig, captureHistory *CaptureHistoryTable, ttMove BitMove, killers [2]BitMove) MovePicker {
+	if killers[1] == killers[0] {
+		killers[1] = BitMove(0)
+	}
+	return MovePicker{
+		board:   b,
+		cfg:     cfg,
+		history: captureHistory,
+		ttMove:  ttMove,
+		killers: killers,
+		stage:   STAGE_TT_MOVE,
+	}
+}
+
+// Next returns the next move and true, or false if all moves have been yielded.
+func (mp *MovePicker) Next() (BitMove, bool) {
+	for {
+		switch mp.stage {
+		case STAGE_TT_MOVE:
+			mp.stage = STAGE_GENERATE_CAPTURES
+			if mp.ttMove != 0 && mp.board.isLegalPieceMove(mp.ttMove) {
+				return mp.ttMove, true
+			}
+
+		case STAGE_GENERATE_CAPTURES:
+			mp.generateCaptures()
+			mp.stage = STAGE_CAPTURES
+
+		case STAGE_CAPTURES:
+			for mp.next < mp.mlist.Size {
+				mp.pickCapture()
+				move := mp.mlist.Moves[mp.next]
+				mp.next++
+				if move != mp.ttMove {
+					return move, true
+				}
+			}
+			mp.stage = STAGE_KILLERS
+
+		case STAGE_KILLERS:
+			for mp.killer < len(mp.killers) {
+				move := mp.killers[mp.killer]
+				mp.killer++
+				if mp.isKiller(move) {
+					return move, true
+				}
+			}
+			mp.stage = STAGE_GENERATE_QUIETS
+
+		case STAGE_GENERATE_QUIETS:
+			// The captures were yielded already and are skipped below.
+			mp.mlist.Clear()
+			mp.next = 0
+			mp.board.GenerateAllLegalMoves(&mp.mlist)
+			mp.stage = STAGE_QUIETS
+
+		case STAGE_QUIETS:
+			for mp.next < mp.mlist.Size {
+				move := mp.mlist.Moves[mp.next]
+				mp.next++
+				if move != mp.ttMove && move != mp.killers[0] && move != mp.killers[1] && mp.board.isQuiet(move) {
+					return move, true
+				}
+			}
+			mp.stage = STAGE_DONE
+
+		default:
+			return BitMove(0), false
+		}
+	}
+}
+
+// generateCaptures generates the legal captures and promotions, scored by static
+// exchange evaluation and capture history.
+func (mp *MovePicker) generateCaptures() {
+	b := mp.board
+	b.GenerateLegalCaptures(&mp.mlist)
+
+	for i := uint32(0); i < mp.mlist.Size; i++ {
+		move := mp.mlist.Moves[i]
+		mp.scores[i] = b.SEE(move, mp.cfg)
+		if captured := b.capturedPiece(move); captured != EMPTY && mp.history != nil {
+			mp.scores[i] += mp.history.Get(b.Squares[move.From()], captured, move.To()) >> CAPTURE_HISTORY_SHIFT
+		}
+	}
+}
+
+// pickCapture swaps the remaining capture with the best score to index 'next'.
+func (mp *MovePicker) pickCapture() {
+	best := mp.next
+	for i := mp.next + 1; i < mp.mlist.Size; i++ {
+		if mp.scores[i] > mp.scores[best] {
+			best = i
+		}
+	}
+	if best != mp.next {
+		mp.mlist.Moves[mp.next], mp.mlist.Moves[best] = mp.mlist.Moves[best], mp.mlist.Moves[mp.next]
+		mp.scores[mp.next], mp.scores[best] = mp.scores[best], mp.scores[mp.next]
+	}
+}
+
+// isKiller tests if 'move' is a legal quiet move which has not been yielded yet.
+func (mp *MovePicker) isKiller(move BitMove) bool {
+	if move == 0 || move == mp.ttMove || !mp.board.isQuiet(move) {
+		return false
+	}
+	return mp.board.isLegalPieceMove(move)
+}
+
+// GenerateLegalCaptures appends the legal captures, including en passant, and all
+// promotions of the side to move to the list. Together with the quiet moves these are
+// exactly the moves of GenerateAllLegalMoves.
+func (b *Board) GenerateLegalCaptures(mlist *MoveList) {
+	color := b.Player
+	oppColor := color.Flip()
+	b.DetectChecksAndPins(color)
+
+	// The king captures pieces on squares which are not attacked. Squares behind the king
+	// on the line of a checking slider are marked as forbidden escapes.
+	from := b.Kings[color]
+	for _, dir := range KING_DIRS {
+		to := from.Add(dir)
+		if to.OnBoard() && b.Squares[to].HasColor(oppColor) && !b.Squares[to.ToInfoIndex()].IsSet(INFO_MASK_FORBIDDEN_ESCAPE) &&
+			!b.IsSquareAttacked(to, OTB, color) {
+			mlist.Put(NewBitMove(from, to, NONE))
+		}
+	}
+	if b.CheckInfo == CHECK_DOUBLE_CHECK {
+		return
+	}
+
+	// In check the other pieces can only capture the checking piece.
+	isCheck := b.CheckInfo.OnBoard()
+	for i := uint8(0); i < b.Knights[color].Size; i++ {
+		from := b.Knights[color].Pieces[i]
+		if b.Squares[from.ToInfoIndex()].Pinval() != 0 {
+			continue
+		}
+		for _, dir := range KNIGHT_DIRS {
+			to := from.Add(dir)
+			if to.OnBoard() && b.Squares[to].HasColor(oppColor) && (!isCheck || b.Squares[to.ToInfoIndex()].IsSet(INFO_MASK_CHECK)) {
+				mlist.Put(NewBitMove(from, to, NONE))
+			}
+		}
+	}
+
+	b.generateSlidingCaptures(mlist, &b.Queens[color], KING_DIRS[:])
+	b.generateSlidingCaptures(mlist, &b.Rooks[color], ORTHOGONAL_DIRS[:])
+	b.generateSlidingCaptures(mlist, &b.Bishops[color], DIAGONAL_DIRS[:])
+	b.generatePawnCaptures(mlist, color)
+}
+
+// generateSlidingCaptures appends the legal captures of the sliders in 'plist' along
+// 'dirs'. Pins and checks are handled like in GenerateSlidingMoves.
+func (b *Board) generateSlidingCaptures(mlist *MoveList, plist *PieceList, dirs []int8) {
+	isCheck := b.CheckInfo.OnBoard()
+	oppColor := b.Player.Flip()
+	for i := uint8(0); i < plist.Size; i++ {
+		from := plist.Pieces[i]
+		pin := b.Squares[from.ToInfoIndex()]
+		isPinned := pin.Pinval() != 0
+		for _, dir := range dirs {
+			to := from.Add(dir)
+			for to.OnBoard() && b.Squares[to].IsEmpty() && (!isPinned || b.Squares[to.ToInfoIndex()] == pin) {
+				to = to.Add(dir)
+			}
+			if !to.OnBoard() || !b.Squares[to].HasColor(oppColor) {
+				continue
+			}
+			if isPinned && b.Squares[to.ToInfoIndex()] != pin {
+				continue
+			}
+			if isCheck && !b.Squares[to.ToInfoIndex()].IsSet(INFO_MASK_CHECK) {
+				continue
+			}
+			mlist.Put(NewBitMove(from, to, NONE))
+		}
+	}
+}
+
+// generatePawnCaptures appends the legal pawn captures including en passant and all
+// promotions of 'color'. Legality is tested like in GeneratePawnMoves.
+func (b *Board) generatePawnCaptures(mlist *MoveList, color Color) {
+	oppColor := color.Flip()
+	piece := PAWN | color
+
+	if b.EpSquare != OTB {
+		// E.p. capturers are found by searching in the opposite direction.
+		for _, dir := range PAWN_CAPTURE_DIRS[oppColor] {
+			from := b.EpSquare.Add(dir)
+			if from.OnBoard() && b.Squares[from] == piece {
+				if move, legal := b.newPawnMoveIfLegal(color, from, b.EpSquare, piece, PAWN|oppColor, EMPTY, EP_TYPE_CAPTURE); legal {
+					mlist.Put(move)
+				}
+			}
+		}
+	}
+
+	for i := uint8(0); i < b.Pawns[color].Size; i++ {
+		from := b.Pawns[color].Pieces[i]
+		for _, dir := range PAWN_CAPTURE_DIRS[color] {
+			to := from.Add(dir)
+			if !to.OnBoard() || !b.Squares[to].HasColor(oppColor) {
+				continue
+			}
+			b.putPawnCapture(mlist, color, from, to)
+		}
+		// Pushes to the last rank are promotions, which are generated with the captures.
+		if to := from.Add(PAWN_PUSH_DIRS[color]); to.IsPawnPromoting(color) && b.Squares[to].IsEmpty() {
+			b.putPawnCapture(mlist, color, from, to)
+		}
+	}
+}
+
+// putPawnCapture appends the pawn move from 'from' to 'to', which captures or promotes,
+// if it is legal. A promotion is appended for every piece type.
+func (b *Board) putPawnCapture(mlist *MoveList, color Color, from, to Square) {
+	tpiece := b.Squares[to]
+	if !to.IsPawnPromoting(color) {
+		if move, legal := b.newPawnMoveIfLegal(color, from, to, PAWN|color, tpiece, EMPTY, EP_TYPE_NONE); legal {
+			mlist.Put(move)
+		}
+		return
+	}
+	// If one type of promotion is legal, all are.
+	if b.tryPawnMoveLegality(from, to, to, tpiece, color) {
+		for prom := QUEEN; prom >= KNIGHT; prom >>= 1 {
+			mlist.Put(NewBitMove(from, to, prom))
+		}
+	}
+}
+
+// isLegalPieceMove tests if 'm' is legal, by only generating the moves of the moving piece type.
+func (b *Board) isLegalPieceMove(m BitMove) bool {
+	piece := b.Squares[m.From()]
+	if !piece.HasColor(b.Player) {
+		return false
+	}
+
+	b.DetectChecksAndPins(b.Player)
+	ptype := piece & PIECE_MASK
+	if b.CheckInfo == CHECK_DOUBLE_CHECK && ptype != KING {
+		return false
+	}
+
+	mlist := MoveList{}
+	switch ptype {
+	case PAWN:
+		b.GeneratePawnMoves(&mlist, b.Player)
+	case KNIGHT:
+		b.GenerateKnightMoves(&mlist, b.Player)
+	case BISHOP:
+		b.GenerateBishopMoves(&mlist, b.Player)
+	case ROOK:
+		b.GenerateRookMoves(&mlist, b.Player)
+	case QUEEN:
+		b.GenerateQueenMoves(&mlist, b.Player)
+	case KING:
+		b.GenerateKingMoves(&mlist, b.Player)
+	}
+
+	for i := uint32(0); i < mlist.Size; i++ {
+		if mlist.Moves[i] == m {
+			return true
+		}
+	}
+	return false
+}
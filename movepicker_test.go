@@ -0,0 +1,172 @@
+package chesskimo
+
+import (
+	"testing"
+)
+
+func TestMovePickerYieldsAllLegalMoves(t *testing.T) {
+	type set struct {
+		Fen     string
+		TTMove  string
+		Killers [2]string
+	}
+
+	testset := []set{
+		set{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", "e2e4", [2]string{"g1f3", "g1f3"}},
+		// Kiwipete with a capture as TT move and a killer which is not legal.
+		set{"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1", "e2a6", [2]string{"e1g1", "a1a8"}},
+		// Illegal TT move, the killer is the TT move.
+		set{"8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1", "e2e5", [2]string{"b4b1", "a5a6"}},
+		// Promotions and an en passant capture.
+		set{"n1n5/PPPk4/8/8/8/8/4Kppp/5N1N b - - 0 1", "g2f1q", [2]string{"d7d6", ""}},
+		set{"rnbqkbnr/ppp1p1pp/8/3pPp2/8/8/PPPP1PPP/RNBQKBNR w KQkq f6 0 3", "", [2]string{"e5f6", "d1h5"}},
+		// Double check.
+		set{"4k3/8/8/8/8/5n2/8/R3K2r w Q - 0 1", "a1a2", [2]string{"e1e2", ""}},
+	}
+
+	board := NewBoard()
+	for i, ts := range testset {
+		if err := board.SetFEN(ts.Fen); err != nil {
+			t.Fatalf(err.Error())
+		}
+		ttMove := parseTestMove(t, ts.TTMove)
+		killers := [2]BitMove{parseTestMove(t, ts.Killers[0]), parseTestMove(t, ts.Killers[1])}
+
+		mlist := MoveList{}
+		board.GenerateAllLegalMoves(&mlist)
+		expected := map[BitMove]int{}
+		for j := uint32(0); j < mlist.Size; j++ {
+			expected[mlist.Moves[j]]++
+		}
+
+		picked := map[BitMove]int{}
+		mp := NewMovePicker(&board, &DefaultEvalConfig, nil, ttMove, killers)
+		for move, ok := mp.Next(); ok; move, ok = mp.Next() {
+			picked[move]++
+		}
+
+		if len(picked) != len(expected) {
+			t.Fatalf("Test %d: expected %d moves but picked %d\n", i, len(expected), len(picked))
+		}
+		for move, n := range expected {
+			if picked[move] != n {
+				t.Fatalf("Test %d: move %s was picked %d times\n", i, move.MiniNotation(), picked[move])
+			}
+		}
+	}
+}
+
+func TestMovePickerStages(t *testing.T) {
+	board := NewBoard()
+	// White can capture a protected knight with the queen (bad) or the pawn (good).
+	if err := board.SetFEN("4k3/8/2p5/3n4/4P3/8/3Q4/4K3 w - - 0 1"); err != nil {
+		t.Fatalf(err.Error())
+	}
+	ttMove := parseTestMove(t, "e1f1")
+	killer := parseTestMove(t, "d2h6")
+
+	mp := NewMovePicker(&board, &DefaultEvalConfig, nil, ttMove, [2]BitMove{killer, 0})
+	order := []BitMove{}
+	for move, ok := mp.Next(); ok; move, ok = mp.Next() {
+		order = append(order, move)
+	}
+
+	expected := []BitMove{ttMove, parseTestMove(t, "e4d5"), parseTestMove(t, "d2d5"), killer}
+	for i, move := range expected {
+		if order[i] != move {
+			t.Fatalf("Expected move %s at position %d but got %s\n", move.MiniNotation(), i, order[i].MiniNotation())
+		}
+	}
+
+	// The quiet moves are not generated before the killers have been tried.
+	mp = NewMovePicker(&board, &DefaultEvalConfig, nil, ttMove, [2]BitMove{killer, 0})
+	for i := 0; i < 3; i++ {
+		mp.Next()
+	}
+	if mp.stage > STAGE_KILLERS || mp.mlist.Size != 2 {
+		t.Fatalf("Expected only the 2 captures to be generated but got %d moves in stage %d\n", mp.mlist.Size, mp.stage)
+	}
+}
+
+func TestGenerateLegalCaptures(t *testing.T) {
+	testset := []string{
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+		"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1",
+		"8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1",
+		"r3k2r/Pppp1ppp/1b3nbN/nP6/BBP1P3/q4N2/Pp1P2PP/R2Q1RK1 w kq - 0 1",
+		"rnbq1k1r/pp1Pbppp/2p5/8/2B5/8/PPP1NnPP/RNBQK2R w KQ - 1 8",
+		"n1n5/PPPk4/8/8/8/8/4Kppp/5N1N b - - 0 1",
+	}
+
+	// Compare the captures with the tactical moves of all legal moves, also in the
+	// positions after every legal move and reply.
+	var compare func(board Board, depth int)
+	compare = func(board Board, depth int) {
+		captures := MoveList{}
+		board.GenerateLegalCaptures(&captures)
+		mlist := MoveList{}
+		board.GenerateAllLegalMoves(&mlist)
+
+		expected := map[BitMove]bool{}
+		for i := uint32(0); i < mlist.Size; i++ {
+			if !board.isQuiet(mlist.Moves[i]) {
+				expected[mlist.Moves[i]] = true
+			}
+		}
+		if int(captures.Size) != len(expected) {
+			t.Fatalf("Expected %d captures but got %d in %s\n", len(expected), captures.Size, board.FEN())
+		}
+		for i := uint32(0); i < captures.Size; i++ {
+			if !expected[captures.Moves[i]] {
+				t.Fatalf("Unexpected capture %s in %s\n", captures.Moves[i].MiniNotation(), board.FEN())
+			}
+		}
+
+		if depth == 0 {
+			return
+		}
+		for i := uint32(0); i < mlist.Size; i++ {
+			cpy := board
+			cpy.MakeLegalMove(mlist.Moves[i])
+			compare(cpy, depth-1)
+		}
+	}
+
+	board := NewBoard()
+	for _, fen := range testset {
+		if err := board.SetFEN(fen); err != nil {
+			t.Fatalf(err.Error())
+		}
+		compare(board, 2)
+	}
+}
+
+func TestMovePickerCaptureHistory(t *testing.T) {
+	board := NewBoard()
+	// The knight can capture either undefended pawn, so both captures have equal exchange values.
+	if err := board.SetFEN("4k3/8/8/2p1p3/8/3N4/8/4K3 w - - 0 1"); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	for _, first := range []string{"d3c5", "d3e5"} {
+		move := parseTestMove(t, first)
+		ch := CaptureHistoryTable{}
+		ch.Add(WKNIGHT, BPAWN, move.To(), 16)
+
+		mp := NewMovePicker(&board, &DefaultEvalConfig, &ch, BitMove(0), [2]BitMove{})
+		if next, _ := mp.Next(); next != move {
+			t.Fatalf("Expected capture %s first but got %s\n", first, next.MiniNotation())
+		}
+	}
+}
+
+func parseTestMove(t *testing.T, move string) BitMove {
+	if move == "" {
+		return BitMove(0)
+	}
+	m, err := ParseMiniNotation(move)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	return m
+}
@@ -0,0 +1,533 @@
+package chesskimo
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestPerftSuite(t *testing.T) {
+	type set struct {
+		Name  string
+		Fen   string
+		Depth int
+		Nodes uint64
+	}
+
+	start := "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
+	kiwipete := "r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1"
+	promotions := "n1n5/PPPk4/8/8/8/8/4Kppp/5N1N b - - 0 1"
+	endgame := "8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1"
+
+	// Node counts as published on the chessprogramming wiki.
+	testset := []set{
+		set{"start", start, 1, 20},
+		set{"start", start, 2, 400},
+		set{"start", start, 3, 8902},
+		set{"start", start, 4, 197281},
+		set{"kiwipete", kiwipete, 1, 48},
+		set{"kiwipete", kiwipete, 2, 2039},
+		set{"kiwipete", kiwipete, 3, 97862},
+		set{"promotions", promotions, 1, 24},
+		set{"promotions", promotions, 2, 496},
+		set{"promotions", promotions, 3, 9483},
+		set{"promotions", promotions, 4, 182838},
+		set{"endgame", endgame, 1, 14},
+		set{"endgame", endgame, 2, 191},
+		set{"endgame", endgame, 3, 2812},
+		set{"endgame", endgame, 4, 43238},
+		set{"endgame", endgame, 5, 674624},
+	}
+
+	board := NewBoard()
+	for _, ts := range testset {
+		if err := board.SetFEN(ts.Fen); err != nil {
+			t.Fatalf(err.Error())
+		}
+		nodes := board.Perft(ts.Depth)
+		if nodes != ts.Nodes {
+			t.Fatalf("Perft of %s position is %d but should be %d at depth %d.\n", ts.Name, nodes, ts.Nodes, ts.Depth)
+		}
+	}
+}
+
+func TestPerftSafe(t *testing.T) {
+	board := NewBoard()
+	board.SetStartingPosition()
+
+	for _, depth := range []int{-1, MAX_PERFT_DEPTH + 1, 100} {
+		if _, err := board.PerftSafe(depth); !errors.Is(err, ErrPerftDepthInvalid) {
+			t.Fatalf("Expected depth %d to be rejected but got error %v\n", depth, err)
+		}
+	}
+
+	nodes, err := board.PerftSafe(3)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if nodes != 8902 {
+		t.Fatalf("Expected 8902 nodes but got %d\n", nodes)
+	}
+}
+
+func TestPerftFromFEN(t *testing.T) {
+	nodes, err := PerftFromFEN("rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", 3)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if nodes != 8902 {
+		t.Fatalf("Expected 8902 nodes but got %d\n", nodes)
+	}
+
+	if _, err := PerftFromFEN("rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP w KQkq - 0 1", 3); err != ErrFENRanksInvalid {
+		t.Fatalf("Expected an invalid FEN to be rejected but got error %v\n", err)
+	}
+	if _, err := PerftFromFEN("rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", -1); !errors.Is(err, ErrPerftDepthInvalid) {
+		t.Fatalf("Expected an invalid depth to be rejected but got error %v\n", err)
+	}
+}
+
+func TestPerftDivideSorted(t *testing.T) {
+	board := NewBoard()
+	if err := board.SetFEN("r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1"); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	// Reference divide of Kiwipete at depth 2.
+	reference := strings.Fields(`
+		a1b1 43 a1c1 43 a1d1 43 a2a3 44 a2a4 44 b2b3 42 c3a4 42 c3b1 42
+		c3b5 39 c3d1 42 d2c1 43 d2e3 43 d2f4 43 d2g5 42 d2h6 41 d5d6 41
+		d5e6 46 e1c1 43 e1d1 43 e1f1 43 e1g1 43 e2a6 36 e2b5 39 e2c4 41
+		e2d1 44 e2d3 42 e2f1 44 e5c4 42 e5c6 41 e5d3 43 e5d7 45 e5f7 44
+		e5g4 44 e5g6 42 f3d3 42 f3e3 43 f3f4 43 f3f5 45 f3f6 39 f3g3 43
+		f3g4 43 f3h3 43 f3h5 43 g2g3 42 g2g4 42 g2h3 43 h1f1 43 h1g1 43`)
+
+	entries := board.PerftDivideSorted(2)
+	if len(entries)*2 != len(reference) {
+		t.Fatalf("Expected %d root moves but got %d\n", len(reference)/2, len(entries))
+	}
+	for i, entry := range entries {
+		line := fmt.Sprintf("%s %d", entry.Move, entry.Nodes)
+		if expected := reference[2*i] + " " + reference[2*i+1]; line != expected {
+			t.Fatalf("Entry %d: expected %s but got %s\n", i, expected, line)
+		}
+	}
+}
+
+func TestCrossCheckMoveGen(t *testing.T) {
+	fens := []string{
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+		"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1",
+		"n1n5/PPPk4/8/8/8/8/4Kppp/5N1N b - - 0 1",
+		"8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1",
+		// Pins, checks, e.p. captures and castling through attacked squares.
+		"r3k2r/Pppp1ppp/1b3nbN/nP6/BBP1P3/q4N2/Pp1P2PP/R2Q1RK1 w kq - 0 1",
+		"rnbq1k1r/pp1Pbppp/2p5/8/2B5/8/PPP1NnPP/RNBQK2R w KQ - 1 8",
+		"8/8/3p4/KPp4r/1R2Pp1k/8/6P1/8 b - e3 0 1",
+		"4k3/8/8/2KpP2r/8/8/8/8 w - d6 0 1",
+		"r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1",
+		"4k3/4r3/8/8/8/8/3PPP2/4K2R w K - 0 1",
+	}
+
+	rnd := rand.New(rand.NewSource(1))
+	for _, fen := range fens {
+		for game := 0; game < 10; game++ {
+			board := NewBoard()
+			if err := board.SetFEN(fen); err != nil {
+				t.Fatalf(err.Error())
+			}
+			// Play a random game and compare the moves of every position.
+			for ply := 0; ply < 60; ply++ {
+				mlist := crossCheckMoveGen(t, &board)
+				if mlist.Size == 0 {
+					break
+				}
+				board.MakeLegalMove(mlist.Moves[rnd.Intn(int(mlist.Size))])
+			}
+		}
+	}
+}
+
+// crossCheckMoveGen compares the legal moves generated for the board with the moves found by
+// a deliberately naive generator, which tries every from, to and promotion combination and
+// tests legality by making the move and looking for attacks on the own king. Any difference
+// fails the test. The moves of GenerateAllLegalMoves are returned.
+func crossCheckMoveGen(t *testing.T, b *Board) MoveList {
+	mlist := MoveList{}
+	cpy := *b
+	cpy.GenerateAllLegalMoves(&mlist)
+
+	generated := map[BitMove]bool{}
+	for i := uint32(0); i < mlist.Size; i++ {
+		if generated[mlist.Moves[i]] {
+			t.Fatalf("Move %s was generated twice in %s\n", mlist.Moves[i].MiniNotation(), b.FEN())
+		}
+		generated[mlist.Moves[i]] = true
+	}
+
+	naive := naiveLegalMoves(b)
+	for m := range naive {
+		if !generated[m] {
+			t.Fatalf("Missing move %s in %s\ngenerated: %s\nnaive: %v\n", m.MiniNotation(), b.FEN(), mlist.String(), naiveMoveNames(naive))
+		}
+	}
+	for m := range generated {
+		if !naive[m] {
+			t.Fatalf("Illegal move %s in %s\ngenerated: %s\nnaive: %v\n", m.MiniNotation(), b.FEN(), mlist.String(), naiveMoveNames(naive))
+		}
+	}
+	return mlist
+}
+
+func naiveMoveNames(moves map[BitMove]bool) []string {
+	names := []string{}
+	for m := range moves {
+		names = append(names, m.MiniNotation())
+	}
+	sort.Strings(names)
+	return names
+}
+
+// naiveLegalMoves returns all legal moves of the side to move.
+func naiveLegalMoves(b *Board) map[BitMove]bool {
+	moves := map[BitMove]bool{}
+	color := b.Player
+
+	for i := Square(0); i < 64; i++ {
+		from := i.To0x88()
+		piece := b.Squares[from]
+		if piece.IsEmpty() || piece.PieceColor() != color {
+			continue
+		}
+		for j := Square(0); j < 64; j++ {
+			to := j.To0x88()
+			if !naivePseudoLegal(b, from, to) {
+				continue
+			}
+			promos := []Piece{NONE}
+			if piece&PIECE_MASK == PAWN && to.IsPawnPromoting(color) {
+				promos = []Piece{QUEEN, ROOK, BISHOP, KNIGHT}
+			}
+			for _, promo := range promos {
+				m := NewBitMove(from, to, promo)
+				cpy := *b
+				cpy.MakeLegalMove(m)
+				if !naiveAttacked(&cpy, naiveKing(&cpy, color), color.Flip()) {
+					moves[m] = true
+				}
+			}
+		}
+	}
+	return moves
+}
+
+// naivePseudoLegal tests if the piece on 'from' may move to 'to', ignoring checks.
+func naivePseudoLegal(b *Board, from, to Square) bool {
+	piece, target := b.Squares[from], b.Squares[to]
+	color := piece.PieceColor()
+	if !target.IsEmpty() && (target.PieceColor() == color || target&PIECE_MASK == KING) {
+		return false
+	}
+	df, dr := int(to.File())-int(from.File()), int(to.Rank())-int(from.Rank())
+
+	switch piece & PIECE_MASK {
+	case PAWN:
+		dir := 1
+		if color == BLACK {
+			dir = -1
+		}
+		if df == 0 && target.IsEmpty() {
+			if dr == dir {
+				return true
+			}
+			middle := Square(int(from) + 16*dir)
+			return dr == 2*dir && from.IsPawnBaseRank(color) && b.Squares[middle].IsEmpty()
+		}
+		return (df == 1 || df == -1) && dr == dir && (!target.IsEmpty() || (to == b.EpSquare && b.EpSquare != OTB))
+	case KING:
+		if df == 2 || df == -2 {
+			return naiveCastling(b, from, to)
+		}
+	}
+	return naiveAttacks(b, from, to)
+}
+
+// naiveCastling tests if the king on 'from' may castle to 'to'.
+func naiveCastling(b *Board, from, to Square) bool {
+	color := b.Squares[from].PieceColor()
+	rank := Square(0)
+	if color == BLACK {
+		rank = 7
+	}
+	if from != rank<<4|4 || to.Rank() != rank {
+		return false
+	}
+
+	rookSq, between, transit := rank<<4|7, []Square{rank<<4 | 5, rank<<4 | 6}, rank<<4|5
+	allowed := b.CastleShort[color]
+	if to.File() == 2 {
+		rookSq, between, transit = rank<<4, []Square{rank<<4 | 1, rank<<4 | 2, rank<<4 | 3}, rank<<4|3
+		allowed = b.CastleLong[color]
+	} else if to.File() != 6 {
+		return false
+	}
+
+	if !allowed || b.Squares[rookSq] != ROOK|color {
+		return false
+	}
+	for _, sq := range between {
+		if !b.Squares[sq].IsEmpty() {
+			return false
+		}
+	}
+	// The king must not be in check or cross an attacked square.
+	return !naiveAttacked(b, from, color.Flip()) && !naiveAttacked(b, transit, color.Flip())
+}
+
+// naiveAttacks tests if the piece on 'from' attacks 'to'.
+func naiveAttacks(b *Board, from, to Square) bool {
+	piece := b.Squares[from]
+	df, dr := int(to.File())-int(from.File()), int(to.Rank())-int(from.Rank())
+	adf, adr := df, dr
+	if adf < 0 {
+		adf = -adf
+	}
+	if adr < 0 {
+		adr = -adr
+	}
+	if adf == 0 && adr == 0 {
+		return false
+	}
+
+	switch piece & PIECE_MASK {
+	case PAWN:
+		dir := 1
+		if piece.PieceColor() == BLACK {
+			dir = -1
+		}
+		return adf == 1 && dr == dir
+	case KNIGHT:
+		return (adf == 1 && adr == 2) || (adf == 2 && adr == 1)
+	case KING:
+		return adf <= 1 && adr <= 1
+	case BISHOP:
+		return adf == adr && naiveClearPath(b, from, to)
+	case ROOK:
+		return (adf == 0 || adr == 0) && naiveClearPath(b, from, to)
+	case QUEEN:
+		return (adf == adr || adf == 0 || adr == 0) && naiveClearPath(b, from, to)
+	}
+	return false
+}
+
+// naiveClearPath tests if all squares between 'from' and 'to' on a line are empty.
+func naiveClearPath(b *Board, from, to Square) bool {
+	sign := func(n int) int {
+		if n > 0 {
+			return 1
+		} else if n < 0 {
+			return -1
+		}
+		return 0
+	}
+	step := 16*sign(int(to.Rank())-int(from.Rank())) + sign(int(to.File())-int(from.File()))
+	for sq := Square(int(from) + step); sq != to; sq = Square(int(sq) + step) {
+		if !b.Squares[sq].IsEmpty() {
+			return false
+		}
+	}
+	return true
+}
+
+// naiveAttacked tests if any piece of 'color' attacks 'sq'.
+func naiveAttacked(b *Board, sq Square, color Color) bool {
+	for i := Square(0); i < 64; i++ {
+		from := i.To0x88()
+		piece := b.Squares[from]
+		if !piece.IsEmpty() && piece.PieceColor() == color && naiveAttacks(b, from, sq) {
+			return true
+		}
+	}
+	return false
+}
+
+// naiveKing returns the square of the king of 'color'.
+func naiveKing(b *Board, color Color) Square {
+	for i := Square(0); i < 64; i++ {
+		if sq := i.To0x88(); b.Squares[sq] == KING|color {
+			return sq
+		}
+	}
+	return OTB
+}
+
+// perftDivideCopy is the divide based on copying the board, which PerftDivide used
+// before UnmakeMove existed.
+func perftDivideCopy(b *Board, depth int) map[string]uint64 {
+	mlist := MoveList{}
+	cpy := *b
+	results := map[string]uint64{}
+
+	b.GenerateAllLegalMoves(&mlist)
+	for i := uint32(0); i < mlist.Size; i++ {
+		b.MakeLegalMove(mlist.Moves[i])
+		results[mlist.Moves[i].MiniNotation()] += b.Perft(depth - 1)
+		*b = cpy
+	}
+	return results
+}
+
+func divideString(results map[string]uint64) string {
+	moves := []string{}
+	for m := range results {
+		moves = append(moves, m)
+	}
+	sort.Strings(moves)
+	var sb strings.Builder
+	for _, m := range moves {
+		fmt.Fprintf(&sb, "%s: %d\n", m, results[m])
+	}
+	return sb.String()
+}
+
+func TestPerftDivideUnmake(t *testing.T) {
+	type set struct {
+		Fen   string
+		Depth int
+	}
+
+	testset := []set{
+		set{"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1", 3},
+		set{"8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 0", 4},
+		set{"r3k2r/Pppp1ppp/1b3nbN/nP6/BBP1P3/q4N2/Pp1P2PP/R2Q1RK1 w kq - 0 1", 3},
+		set{"n1n5/PPPk4/8/8/8/8/4Kppp/5N1N b - - 0 1", 3},
+	}
+
+	for _, ts := range testset {
+		board := NewBoard()
+		if err := board.SetFEN(ts.Fen); err != nil {
+			t.Fatalf(err.Error())
+		}
+		reference := NewBoard()
+		if err := reference.SetFEN(ts.Fen); err != nil {
+			t.Fatalf(err.Error())
+		}
+
+		expected := divideString(perftDivideCopy(&reference, ts.Depth))
+		if divide := divideString(board.PerftDivide(ts.Depth)); divide != expected {
+			t.Fatalf("Divide of %s differs from the copy version:\n%s\nexpected:\n%s\n", ts.Fen, divide, expected)
+		}
+		if !board.Equals(&reference) || board.FEN() != ts.Fen || board.Hash != reference.Hash {
+			t.Fatalf("Expected the board to be restored to %s but got %s\n", ts.Fen, board.FEN())
+		}
+		checkPieceLists(t, &board)
+	}
+}
+
+func BenchmarkPerftDivide(b *testing.B) {
+	board := NewBoard()
+	board.SetFEN("r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1")
+	for i := 0; i < b.N; i++ {
+		board.PerftDivide(3)
+	}
+}
+
+func TestPerftDrill(t *testing.T) {
+	kiwipete := "r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1"
+	board := NewBoard()
+	if err := board.SetFEN(kiwipete); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	// Reference divide after 1. Bxa6 bxc3.
+	divide, err := board.PerftDrill(2, []string{"e2a6", "b4c3"})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	expected := map[string]uint64{
+		"a6b5": 40, "a6c4": 41, "d5d6": 34, "d5e6": 41, "e1c1": 38, "e1g1": 38,
+		"e5f7": 40, "f3c3": 36, "f3f6": 34, "d2h6": 35, "b2b4": 36, "g2h3": 38,
+	}
+	if len(divide) != 52 {
+		t.Fatalf("Expected 52 moves after e2a6 b4c3 but got %d\n", len(divide))
+	}
+	total := uint64(0)
+	for move, nodes := range divide {
+		total += nodes
+		if n, ok := expected[move]; ok && n != nodes {
+			t.Fatalf("Expected %d nodes after %s but got %d\n", n, move, nodes)
+		}
+	}
+	if total != 1976 {
+		t.Fatalf("Expected 1976 nodes after e2a6 b4c3 but got %d\n", total)
+	}
+
+	// The drilled divides of all replies sum up to the divide one level above.
+	replies, err := board.PerftDrill(2, []string{"e2a6"})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if n := board.PerftDivide(3)["e2a6"]; n != 1907 {
+		t.Fatalf("Expected 1907 nodes after e2a6 but got %d\n", n)
+	}
+	for reply, nodes := range replies {
+		divide, err := board.PerftDrill(1, []string{"e2a6", reply})
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		sum := uint64(0)
+		for _, n := range divide {
+			sum += n
+		}
+		if sum != nodes {
+			t.Fatalf("Expected %d nodes after e2a6 %s but got %d\n", nodes, reply, sum)
+		}
+	}
+	if board.FEN() != kiwipete {
+		t.Fatalf("Expected the board to stay unchanged but got %s\n", board.FEN())
+	}
+
+	if _, err := board.PerftDrill(2, []string{"e2a6", "e1g1"}); !errors.Is(err, ErrIllegalMove) {
+		t.Fatalf("Expected ErrIllegalMove but got %v\n", err)
+	}
+	if _, err := board.PerftDrill(0, nil); !errors.Is(err, ErrPerftDepthInvalid) {
+		t.Fatalf("Expected ErrPerftDepthInvalid but got %v\n", err)
+	}
+}
+
+func TestCrazyhousePerft(t *testing.T) {
+	// Published node counts of the starting position. Drops are possible from ply 5 on.
+	expected := []uint64{1, 20, 400, 8902, 197281, 4888832}
+	board := NewBoard()
+	board.Crazyhouse = true
+	for depth, nodes := range expected {
+		if n := board.Perft(depth); n != nodes {
+			t.Fatalf("Expected %d nodes at depth %d but got %d\n", nodes, depth, n)
+		}
+	}
+
+	// Both sides hold pieces in their hands. Make/unmake must agree with copy/make.
+	fen := "r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1"
+	if err := board.SetFEN(fen); err != nil {
+		t.Fatalf(err.Error())
+	}
+	board.Hand[WHITE][PAWN.TypeIndex()] = 1
+	board.Hand[WHITE][KNIGHT.TypeIndex()] = 1
+	board.Hand[BLACK][BISHOP.TypeIndex()] = 1
+	board.Hand[BLACK][QUEEN.TypeIndex()] = 2
+	board.Hash = board.ComputeHash()
+	reference := board
+
+	expectedDivide := divideString(perftDivideCopy(&reference, 3))
+	if divide := divideString(board.PerftDivide(3)); divide != expectedDivide {
+		t.Fatalf("Crazyhouse divide differs from the copy version:\n%s\nexpected:\n%s\n", divide, expectedDivide)
+	}
+	if !board.Equals(&reference) || board.Hash != reference.Hash {
+		t.Fatalf("Expected the board and hands to be restored but got %s %v\n", board.FEN(), board.Hand)
+	}
+	checkPieceLists(t, &board)
+	checkHashes(t, &board, 2)
+}
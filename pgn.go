@@ -0,0 +1,250 @@
+package chesskimo
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrPGNInvalid signals a PGN which cannot be parsed.
+	ErrPGNInvalid = errors.New("PGN is invalid")
+)
+
+// ParsePGN parses a single game in portable game notation. All tag pairs are
+// stored in Game.Tags and the moves of the mainline are played. The full move
+// tree with variations, comments and numeric annotations is kept in Game.Tree.
+func ParsePGN(pgn string) (*Game, error) {
+	tags := map[string]string{}
+	var movetext strings.Builder
+
+	for _, line := range strings.Split(pgn, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if movetext.Len() == 0 && strings.HasPrefix(trimmed, "[") {
+			key, value, err := parsePGNTag(trimmed)
+			if err != nil {
+				return nil, err
+			}
+			tags[key] = value
+		} else if !strings.HasPrefix(trimmed, "%") {
+			// Lines starting with '%' are escaped and ignored.
+			movetext.WriteString(line + "\n")
+		}
+	}
+
+	g := NewGame()
+	if fen, ok := tags["FEN"]; ok {
+		var err error
+		if g, err = NewGameFromFEN(fen); err != nil {
+			return nil, err
+		}
+	}
+	g.Tags = tags
+
+	tree := NewGameTree(g.Board)
+	if err := parsePGNMovetext(tree, movetext.String()); err != nil {
+		return nil, err
+	}
+	for _, node := range tree.Mainline() {
+		if err := g.MakeMove(node.Move); err != nil {
+			return nil, err
+		}
+	}
+	g.Tree = tree
+	return g, nil
+}
+
+// parsePGNTag parses a tag pair like [Event "Casual Game"].
+func parsePGNTag(line string) (key, value string, err error) {
+	if !strings.HasSuffix(line, "]") {
+		return "", "", fmt.Errorf("%w: bad tag %s", ErrPGNInvalid, line)
+	}
+	fields := strings.SplitN(strings.TrimSpace(line[1:len(line)-1]), " ", 2)
+	if len(fields) != 2 {
+		return "", "", fmt.Errorf("%w: bad tag %s", ErrPGNInvalid, line)
+	}
+	value = strings.TrimSpace(fields[1])
+	if len(value) < 2 || value[0] != '"' || value[len(value)-1] != '"' {
+		return "", "", fmt.Errorf("%w: bad tag %s", ErrPGNInvalid, line)
+	}
+	value = strings.NewReplacer(`\"`, `"`, `\\`, `\`).Replace(value[1 : len(value)-1])
+	return fields[0], value, nil
+}
+
+// parsePGNMovetext reads the movetext into the move tree 'tree'. Variations become
+// sidelines of the move they replace and comments are attached to the preceding move.
+func parsePGNMovetext(tree *GameTree, text string) error {
+	cur := tree.Root
+	// stack holds the node to continue with after each open variation.
+	stack := []*GameNode{}
+	for i := 0; i < len(text); {
+		switch c := text[i]; c {
+		case '{':
+			end := strings.IndexByte(text[i:], '}')
+			if end < 0 {
+				return fmt.Errorf("%w: unterminated comment", ErrPGNInvalid)
+			}
+			addPGNComment(cur, text[i+1:i+end])
+			i += end + 1
+		case ';':
+			end := strings.IndexByte(text[i:], '\n')
+			if end < 0 {
+				end = len(text) - i
+			}
+			addPGNComment(cur, text[i+1:i+end])
+			i += end
+		case '(':
+			// The variation replaces the last move.
+			if cur.Parent == nil {
+				return fmt.Errorf("%w: variation without a move", ErrPGNInvalid)
+			}
+			stack = append(stack, cur)
+			cur = cur.Parent
+			i++
+		case ')':
+			if len(stack) == 0 {
+				return fmt.Errorf("%w: unbalanced variation", ErrPGNInvalid)
+			}
+			cur = stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			i++
+		case ' ', '\t', '\r', '\n':
+			i++
+		default:
+			end := i
+			for end < len(text) && !strings.ContainsRune(" \t\r\n{};()", rune(text[end])) {
+				end++
+			}
+			token := text[i:end]
+			i = end
+			next, err := playPGNToken(cur, token)
+			if err != nil {
+				return err
+			}
+			cur = next
+		}
+	}
+	if len(stack) != 0 {
+		return fmt.Errorf("%w: unbalanced variation", ErrPGNInvalid)
+	}
+	return nil
+}
+
+// addPGNComment attaches a comment to the node. Several comments are separated by a space.
+func addPGNComment(n *GameNode, comment string) {
+	comment = strings.TrimSpace(comment)
+	if comment == "" {
+		return
+	}
+	if n.Comment != "" {
+		n.Comment += " "
+	}
+	n.Comment += comment
+}
+
+// playPGNToken adds the move given by a token of the movetext as child of 'n' and
+// returns the new node. Move numbers and results are skipped, numeric annotation
+// glyphs are stored in 'n'.
+func playPGNToken(n *GameNode, token string) (*GameNode, error) {
+	switch token {
+	case "1-0", "0-1", "1/2-1/2", "*":
+		return n, nil
+	}
+	if token[0] == '$' {
+		nag, err := strconv.Atoi(token[1:])
+		if err != nil {
+			return nil, fmt.Errorf("%w: bad annotation %s", ErrPGNInvalid, token)
+		}
+		n.NAGs = append(n.NAGs, nag)
+		return n, nil
+	}
+	if token[0] >= '1' && token[0] <= '9' {
+		// Move numbers like "12." or "12..." may be followed by the move without space.
+		token = strings.TrimLeft(token, "0123456789")
+		token = strings.TrimLeft(token, ".")
+		if token == "" {
+			return n, nil
+		}
+	}
+
+	board := n.position
+	m, err := board.ParseSAN(token)
+	if err != nil {
+		return nil, fmt.Errorf("%w: move %s in position %s: %v", ErrPGNInvalid, token, board.FEN(), err)
+	}
+	return n.AddChild(m, token), nil
+}
+
+// PGNScanner reads games from a PGN stream one by one, without loading the
+// whole stream into memory.
+type PGNScanner struct {
+	lines *bufio.Scanner
+	// next holds the first line of the next game, which was already read.
+	next    string
+	hasNext bool
+	game    *Game
+	err     error
+}
+
+// NewPGNScanner creates a scanner reading games from 'r'.
+func NewPGNScanner(r io.Reader) *PGNScanner {
+	lines := bufio.NewScanner(r)
+	lines.Buffer(make([]byte, 64*1024), 1024*1024)
+	return &PGNScanner{lines: lines}
+}
+
+// Scan reads the next game, which is then available via Game. It returns false
+// at the end of the stream or on errors, which are reported by Err.
+func (s *PGNScanner) Scan() bool {
+	s.game = nil
+	if s.err != nil {
+		return false
+	}
+
+	var sb strings.Builder
+	if s.hasNext {
+		sb.WriteString(s.next + "\n")
+		s.hasNext = false
+	}
+
+	inMovetext := false
+	comments := 0
+	for s.lines.Scan() {
+		line := s.lines.Text()
+		trimmed := strings.TrimSpace(line)
+		isTag := comments == 0 && strings.HasPrefix(trimmed, "[")
+		if isTag && inMovetext {
+			// The tags of the next game start.
+			s.next, s.hasNext = line, true
+			break
+		}
+		if !isTag && trimmed != "" && !strings.HasPrefix(trimmed, "%") {
+			inMovetext = true
+		}
+		comments += strings.Count(line, "{") - strings.Count(line, "}")
+		sb.WriteString(line + "\n")
+	}
+	if err := s.lines.Err(); err != nil {
+		s.err = err
+		return false
+	}
+
+	if strings.TrimSpace(sb.String()) == "" {
+		return false
+	}
+	s.game, s.err = ParsePGN(sb.String())
+	return s.err == nil
+}
+
+// Game returns the game read by the last call to Scan.
+func (s *PGNScanner) Game() *Game {
+	return s.game
+}
+
+// Err returns the first error which occurred while scanning.
+func (s *PGNScanner) Err() error {
+	return s.err
+}
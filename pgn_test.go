@@ -0,0 +1,149 @@
+package chesskimo
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParsePGN(t *testing.T) {
+	pgn := `[Event "Casual Game"]
+[White "Anderssen, Adolf"]
+[Black "Kieseritzky, Lionel"]
+[Result "1-0"]
+
+1. e4 e5 2. f4 exf4 3. Bc4 Qh4+ {Queen check} 4. Kf1 b5 $2 5. Bxb5 Nf6
+6. Nf3 Qh6 7. d3 Nh5 (7... a5 8. Nh4) 8. Nh4 Qg5 9. Nf5 c6 10. g4 Nf6
+11. Rg1 cxb5 12. h4 Qg6 13. h5 Qg5 14. Qf3 Ng8 15. Bxf4 Qf6 16. Nc3 Bc5
+17. Nd5 Qxb2 18. Bd6 Bxg1 19. e5 Qxa1+ 20. Ke2 Na6 21. Nxg7+ Kd8
+22. Qf6+ Nxf6 23. Be7# 1-0`
+
+	g, err := ParsePGN(pgn)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if g.Tags["White"] != "Anderssen, Adolf" || g.Tags["Result"] != "1-0" {
+		t.Fatalf("Wrong tags %v\n", g.Tags)
+	}
+	if len(g.Moves) != 45 {
+		t.Fatalf("Expected 45 plies but got %d\n", len(g.Moves))
+	}
+	if g.Result() != GAMESTATE_WHITE_WIN {
+		t.Fatalf("Expected white to win by checkmate\n")
+	}
+	if fen := g.Board.FEN(); !strings.HasPrefix(fen, "r1bk3r/p2pBpNp/n4n2/1p1NP2P/6P1/3P4/P1P1K3/q5b1 b") {
+		t.Fatalf("Wrong final position %s\n", fen)
+	}
+
+	// Castling with zeros, promotion and a FEN start position.
+	pgn = `[FEN "4k3/P7/8/8/8/8/8/4K2R w K - 0 1"]
+
+1. 0-0 Kd7 2. a8=Q *`
+	g, err = ParsePGN(pgn)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if fen := g.Board.FEN(); !strings.HasPrefix(fen, "Q7/3k4/8/8/8/8/8/5RK1 b") {
+		t.Fatalf("Wrong final position %s\n", fen)
+	}
+
+	// Illegal moves are reported.
+	if _, err := ParsePGN("1. e4 e5 2. Ke3"); err == nil {
+		t.Fatalf("Expected error for illegal move\n")
+	}
+}
+
+func TestParsePGNVariations(t *testing.T) {
+	pgn := `{Opening} 1. e4 e5 2. Nf3 {Develops} (2. f4 exf4 {King's gambit}) 2... Nc6 $1 *`
+
+	g, err := ParsePGN(pgn)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	// The mainline is played.
+	mainline := g.Tree.Mainline()
+	sans := []string{}
+	for _, node := range mainline {
+		sans = append(sans, node.SAN)
+	}
+	if strings.Join(sans, " ") != "e4 e5 Nf3 Nc6" || len(g.Moves) != 4 {
+		t.Fatalf("Expected mainline e4 e5 Nf3 Nc6 but got %v\n", sans)
+	}
+	if g.Tree.Root.Comment != "Opening" || mainline[2].Comment != "Develops" {
+		t.Fatalf("Expected comments to be attached but got %q and %q\n", g.Tree.Root.Comment, mainline[2].Comment)
+	}
+	if len(mainline[3].NAGs) != 1 || mainline[3].NAGs[0] != 1 {
+		t.Fatalf("Expected annotation $1 but got %v\n", mainline[3].NAGs)
+	}
+
+	// The variation is a sideline of 2. Nf3.
+	sidelines := mainline[1].Sidelines()
+	if len(sidelines) != 1 || sidelines[0].SAN != "f4" {
+		t.Fatalf("Expected sideline 2. f4 but got %v\n", sidelines)
+	}
+	reply := sidelines[0].Children
+	if len(reply) != 1 || reply[0].SAN != "exf4" || reply[0].Comment != "King's gambit" {
+		t.Fatalf("Expected reply exf4 with comment but got %v\n", reply)
+	}
+	pos := reply[0].Position()
+	if !strings.HasPrefix(pos.FEN(), "rnbqkbnr/pppp1ppp/8/8/4Pp2/8/PPPP2PP/RNBQKBNR w") {
+		t.Fatalf("Wrong position after the variation %s\n", pos.FEN())
+	}
+
+	// Variations must be balanced and follow a move.
+	for _, bad := range []string{"1. e4 (1. d4", "1. e4 e5)", "(1. d4) 1. e4"} {
+		if _, err := ParsePGN(bad); !errors.Is(err, ErrPGNInvalid) {
+			t.Fatalf("Expected ErrPGNInvalid for %q but got %v\n", bad, err)
+		}
+	}
+}
+
+func TestPGNScanner(t *testing.T) {
+	stream := `[Event "Game 1"]
+[Result "1-0"]
+
+1. e4 e5 2. Bc4 Nc6 3. Qh5 Nf6 4. Qxf7# 1-0
+
+[Event "Game 2"]
+[Result "0-1"]
+
+1. f3 e5 2. g4 {
+[Not a tag, but a comment]
+} Qh4# 0-1
+[Event "Game 3"]
+[Result "*"]
+
+1. d4 d5
+2. c4 *
+`
+
+	expected := []struct {
+		Event string
+		Plies int
+	}{
+		{"Game 1", 7},
+		{"Game 2", 4},
+		{"Game 3", 3},
+	}
+
+	scanner := NewPGNScanner(strings.NewReader(stream))
+	n := 0
+	for scanner.Scan() {
+		if n >= len(expected) {
+			t.Fatalf("Too many games\n")
+		}
+		g := scanner.Game()
+		if g.Tags["Event"] != expected[n].Event || len(g.Moves) != expected[n].Plies {
+			t.Fatalf("Game %d: expected event %s with %d plies but got %s with %d plies\n",
+				n, expected[n].Event, expected[n].Plies, g.Tags["Event"], len(g.Moves))
+		}
+		n++
+	}
+	if scanner.Err() != nil {
+		t.Fatalf(scanner.Err().Error())
+	}
+	if n != len(expected) {
+		t.Fatalf("Expected %d games but got %d\n", len(expected), n)
+	}
+}
@@ -0,0 +1,79 @@
+package chesskimo
+
+import (
+	"math/rand"
+)
+
+// RANDOM_PIECE_TYPES are the piece types placed by RandomLegalPosition besides the kings.
+var RANDOM_PIECE_TYPES = [5]Piece{PAWN, KNIGHT, BISHOP, ROOK, QUEEN}
+
+// RandomLegalPosition creates a random position with both kings and up to 'maxPieces'
+// pieces in total, which is clamped to the range 2..32. Pawns never stand on the first
+// or last rank and the side not to move is never in check. There are no castling
+// rights and no e.p. square. Positions are drawn until IsLegalPosition accepts one.
+func RandomLegalPosition(rng *rand.Rand, maxPieces int) Board {
+	if maxPieces < 2 {
+		maxPieces = 2
+	} else if maxPieces > 32 {
+		maxPieces = 32
+	}
+
+	for {
+		squares := NewMinBoard().Squares
+		free := rng.Perm(64)
+		for color := BLACK; color <= WHITE; color++ {
+			squares[free[0]] = KING | color
+			free = free[1:]
+		}
+
+		pawns := [2]int{}
+		pieces := [2]int{1, 1}
+		for n := rng.Intn(maxPieces - 1); n > 0; n-- {
+			color := Color(rng.Intn(2))
+			ptype := RANDOM_PIECE_TYPES[rng.Intn(len(RANDOM_PIECE_TYPES))]
+			if pieces[color] == 16 || (ptype == PAWN && pawns[color] == 8) {
+				continue
+			}
+			// Pawns take the first free square off the back ranks.
+			i := 0
+			if ptype == PAWN {
+				for i < len(free) && (free[i] < 8 || free[i] >= 56) {
+					i++
+				}
+				if i == len(free) {
+					continue
+				}
+				pawns[color]++
+			}
+			squares[free[i]] = ptype | color
+			free = append(free[:i], free[i+1:]...)
+			pieces[color]++
+		}
+
+		b, err := FromMinBoard(squares, Color(rng.Intn(2)), [2]bool{}, [2]bool{}, OTB)
+		if err == nil && b.IsLegalPosition() {
+			return b
+		}
+	}
+}
+
+// PlayRandomGame plays random legal moves from the current position until the game ends,
+// as decided by Game.Result, or 'maxPlies' moves were made. The board is left at the final
+// position and the played moves are returned. The same 'rng' state always yields the same
+// game, which makes it suitable to generate test data.
+func (b *Board) PlayRandomGame(rng *rand.Rand, maxPlies int) []BitMove {
+	g := NewGame()
+	g.Board = *b
+	g.hashes[0] = b.Hash
+
+	mlist := MoveList{}
+	for len(g.Moves) < maxPlies && g.Result() == GAMESTATE_ONGOING {
+		mlist.Clear()
+		g.Board.GenerateAllLegalMoves(&mlist)
+		// The move is legal, MakeMove only keeps track of the repetitions.
+		g.MakeMove(mlist.Moves[rng.Intn(int(mlist.Size))])
+	}
+
+	*b = g.Board
+	return g.Moves
+}
@@ -0,0 +1,91 @@
+package chesskimo
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestRandomLegalPosition(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		board := RandomLegalPosition(rng, 2+i%31)
+		if !board.IsLegalPosition() {
+			t.Fatalf("Expected a legal position but got %s\n", board.FEN())
+		}
+		checkPieceLists(t, &board)
+		if board.Hash != board.ComputeHash() {
+			t.Fatalf("Expected hash %x but got %x in %s\n", board.ComputeHash(), board.Hash, board.FEN())
+		}
+		crossCheckMoveGen(t, &board)
+	}
+}
+
+func TestPlayRandomGame(t *testing.T) {
+	for seed := int64(0); seed < 20; seed++ {
+		board := NewBoard()
+		moves := board.PlayRandomGame(rand.New(rand.NewSource(seed)), 300)
+		if len(moves) > 300 {
+			t.Fatalf("Seed %d: expected at most 300 moves but got %d\n", seed, len(moves))
+		}
+
+		// Replaying the moves reproduces the final position.
+		g := NewGame()
+		for i, m := range moves {
+			if err := g.MakeMove(m); err != nil {
+				t.Fatalf("Seed %d: move %d %s is illegal\n", seed, i, m.MiniNotation())
+			}
+		}
+		if !g.Board.Equals(&board) || g.Board.Hash != board.Hash {
+			t.Fatalf("Seed %d: expected %s but got %s\n", seed, g.Board.FEN(), board.FEN())
+		}
+		if len(moves) < 300 && g.Result() == GAMESTATE_ONGOING {
+			t.Fatalf("Seed %d: the game stopped after %d moves but is not over\n", seed, len(moves))
+		}
+
+		// The same seed plays the same game.
+		again := NewBoard()
+		if replay := again.PlayRandomGame(rand.New(rand.NewSource(seed)), 300); len(replay) != len(moves) || !again.Equals(&board) {
+			t.Fatalf("Seed %d: expected the same game again\n", seed)
+		}
+	}
+}
+
+func TestIsLegalPosition(t *testing.T) {
+	type set struct {
+		Fen   string
+		Legal bool
+	}
+
+	testset := []set{
+		set{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", true},
+		set{"rnbqkbnr/pppp1ppp/8/4p3/4P3/8/PPPP1PPP/RNBQKBNR w KQkq e6 0 2", true},
+		// No black king.
+		set{"8/8/8/8/8/8/8/4K3 w - - 0 1", false},
+		// The kings touch.
+		set{"8/8/8/8/8/8/3k4/4K3 w - - 0 1", false},
+		// Pawn on the last rank.
+		set{"P3k3/8/8/8/8/8/8/4K3 w - - 0 1", false},
+		// The side not to move is in check.
+		set{"4k3/8/8/8/8/8/8/4RK2 w - - 0 1", false},
+		// Triple check.
+		set{"4k3/8/3N4/7B/4R3/8/8/5K2 b - - 0 1", false},
+		// Double check by two knights.
+		set{"4k3/8/3N1N2/8/8/8/8/5K2 b - - 0 1", false},
+		// Double check by a knight and a rook.
+		set{"4k3/8/3N4/8/4R3/8/8/5K2 b - - 0 1", true},
+		// Castling without the rook.
+		set{"4k3/8/8/8/8/8/8/4K3 w K - 0 1", false},
+		// The e.p. square is not behind a pawn which moved two squares.
+		set{"4k3/8/8/8/8/8/8/4K3 w - e6 0 1", false},
+	}
+
+	for i, ts := range testset {
+		board := NewBoard()
+		if err := board.SetFEN(ts.Fen); err != nil {
+			t.Fatalf(err.Error())
+		}
+		if board.IsLegalPosition() != ts.Legal {
+			t.Fatalf("Test %d: expected IsLegalPosition to be %v for %s\n", i, ts.Legal, ts.Fen)
+		}
+	}
+}
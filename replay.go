@@ -0,0 +1,63 @@
+package chesskimo
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrIllegalMove indicates that a move is not legal in the given position.
+	ErrIllegalMove = errors.New("Move is illegal")
+)
+
+// ReplayAndVerify sets up the position given by 'startFEN' and applies all moves in
+// coordinate notation. Every move is verified to be legal in the intermediate position.
+// The final board is returned or an error naming the offending move and the FEN of the
+// position where it failed. This is useful to diagnose 'illegal move' reports.
+func ReplayAndVerify(startFEN string, uciMoves []string) (*Board, error) {
+	b := NewBoard()
+	if err := b.SetFEN(startFEN); err != nil {
+		return nil, err
+	}
+	if err := b.ApplyUCIMoves(uciMoves); err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// ApplyUCIMoves applies all moves in coordinate notation, as sent by the UCI 'position'
+// command. Every move is verified to be legal. If a move fails, the error names its
+// number and the position it failed in. The moves before it remain applied.
+func (b *Board) ApplyUCIMoves(moves []string) error {
+	for i, str := range moves {
+		m, err := ParseMiniNotation(str)
+		if err != nil {
+			return fmt.Errorf("move %d (%s) in position %s: %w", i+1, str, b.FEN(), err)
+		}
+		if !b.IsLegalMove(m) {
+			return fmt.Errorf("move %d (%s) in position %s: %w", i+1, str, b.FEN(), ErrIllegalMove)
+		}
+		b.MakeLegalMove(m)
+	}
+	return nil
+}
+
+// SetFENWithMoves sets up the board from a FEN followed by an optional list of moves in
+// coordinate notation, e.g. "<fen> moves e2e4 e7e5". This is not standard FEN but used
+// by some tools and equals the semantics of the UCI 'position fen' command.
+func SetFENWithMoves(b *Board, s string) error {
+	fields := strings.Fields(s)
+	moves := []string{}
+	for i, field := range fields {
+		if field == "moves" {
+			fields, moves = fields[:i], fields[i+1:]
+			break
+		}
+	}
+
+	if err := b.SetFEN(strings.Join(fields, " ")); err != nil {
+		return err
+	}
+	return b.ApplyUCIMoves(moves)
+}
@@ -0,0 +1,99 @@
+package chesskimo
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestReplayAndVerify(t *testing.T) {
+	start := "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
+
+	// A valid sequence (Ruy Lopez).
+	moves := []string{"e2e4", "e7e5", "g1f3", "b8c6", "f1b5"}
+	b, err := ReplayAndVerify(start, moves)
+	if err != nil {
+		t.Fatalf("Expected valid replay but got ERR: %s\n", err.Error())
+	}
+	// Only compare the position fields.
+	expected := "r1bqkbnr/pppp1ppp/2n5/1B2p3/4P3/5N2/PPPP1PPP/RNBQK2R b KQkq -"
+	fields := strings.Fields(b.FEN())
+	if strings.Join(fields[:4], " ") != expected {
+		t.Fatalf("Expected position %s but got %s\n", expected, b.FEN())
+	}
+
+	// The same sequence with an injected illegal move.
+	moves = []string{"e2e4", "e7e5", "g1f3", "e8e6", "f1b5"}
+	_, err = ReplayAndVerify(start, moves)
+	if err == nil {
+		t.Fatalf("Expected replay to fail for illegal move e8e6\n")
+	}
+	if !errors.Is(err, ErrIllegalMove) {
+		t.Fatalf("Expected ErrIllegalMove but got: %s\n", err.Error())
+	}
+	if !strings.Contains(err.Error(), "e8e6") || !strings.Contains(err.Error(), "rnbqkbnr/pppp1ppp/8/4p3/4P3/5N2/PPPP1PPP/RNBQKB1R b KQkq") {
+		t.Fatalf("Expected error to name the move and position but got: %s\n", err.Error())
+	}
+
+	// Malformed notation.
+	_, err = ReplayAndVerify(start, []string{"e2e9"})
+	if !errors.Is(err, ErrInvalidMoveNotation) {
+		t.Fatalf("Expected ErrInvalidMoveNotation but got: %v\n", err)
+	}
+}
+
+func TestApplyUCIMoves(t *testing.T) {
+	// Ruy Lopez, closed Chigorin variation.
+	moves := strings.Fields(`
+		e2e4 e7e5 g1f3 b8c6 f1b5 a7a6 b5a4 g8f6 e1g1 f8e7
+		f1e1 b7b5 a4b3 d7d6 c2c3 e8g8 h2h3 c6a5 b3c2 c7c5
+		d2d4 d8c7 b1d2 c5d4 c3d4 a5c6 d2b3 a6a5 c1e3 a5a4
+		b3d2 c8d7 a1c1 c7b7 d1e2 f8e8 c2d3 a8c8 a2a3 e7f8`)
+
+	board := NewBoard()
+	if err := board.ApplyUCIMoves(moves); err != nil {
+		t.Fatalf("Expected all moves to be applied but got ERR: %s\n", err.Error())
+	}
+	expected := "2r1rbk1/1q1b1ppp/2np1n2/1p2p3/p2PP3/P2BBN1P/1P1NQPP1/2R1R1K1 w - - 1 21"
+	if board.FEN() != expected {
+		t.Fatalf("Expected position %s but got %s\n", expected, board.FEN())
+	}
+
+	// The error names the number of the failing move.
+	board = NewBoard()
+	err := board.ApplyUCIMoves([]string{"e2e4", "e7e5", "e1e3"})
+	if !errors.Is(err, ErrIllegalMove) || !strings.Contains(err.Error(), "move 3 (e1e3)") {
+		t.Fatalf("Expected illegal move 3 (e1e3) but got: %v\n", err)
+	}
+}
+
+func TestSetFENWithMoves(t *testing.T) {
+	type set struct {
+		Input string
+		FEN   string
+	}
+
+	testset := []set{
+		set{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1 moves e2e4 e7e5",
+			"rnbqkbnr/pppp1ppp/8/4p3/4P3/8/PPPP1PPP/RNBQKBNR w KQkq e6 0 2"},
+		// Without moves and move counters.
+		set{"4k3/8/8/8/8/8/4P3/4K3 b - -", "4k3/8/8/8/8/8/4P3/4K3 b - - 0 1"},
+		set{"4k3/8/8/8/8/8/4P3/4K3 w - - 3 20 moves e1d1 e8d8 e2e4",
+			"3k4/8/8/8/4P3/8/8/3K4 b - e3 0 21"},
+	}
+
+	for i, ts := range testset {
+		board := NewBoard()
+		if err := SetFENWithMoves(&board, ts.Input); err != nil {
+			t.Fatalf("Test %d: unexpected ERR: %s\n", i, err.Error())
+		}
+		if board.FEN() != ts.FEN {
+			t.Fatalf("Test %d: expected position %s but got %s\n", i, ts.FEN, board.FEN())
+		}
+	}
+
+	board := NewBoard()
+	if err := SetFENWithMoves(&board, "4k3/8/8/8/8/8/4P3/4K3 w - - 0 1 moves e2e5"); !errors.Is(err, ErrIllegalMove) {
+		t.Fatalf("Expected ErrIllegalMove but got: %v\n", err)
+	}
+}
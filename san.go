@@ -0,0 +1,176 @@
+package chesskimo
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	// ErrSANInvalid signals a move in standard algebraic notation which is
+	// malformed, ambiguous or not legal in the position.
+	ErrSANInvalid = errors.New("SAN move is invalid")
+)
+
+// SAN returns the given legal move in standard algebraic notation,
+// e.g. Nf3, exd5, Rad1, e8=Q+ or O-O#.
+func (b *Board) SAN(m BitMove) string {
+	mlist := MoveList{}
+	b.GenerateAllLegalMoves(&mlist)
+	return b.san(m, moveOrigins(b, &mlist))
+}
+
+// ParseSAN returns the legal move given in standard algebraic notation. Check and
+// annotation suffixes (+, #, !, ?) are optional, castling may also be written with
+// zeros and the '=' of promotions may be omitted.
+func (b *Board) ParseSAN(san string) (BitMove, error) {
+	want := normalizeSAN(san)
+
+	mlist := MoveList{}
+	b.GenerateAllLegalMoves(&mlist)
+	sans := b.SANBatch(&mlist)
+
+	found := BitMove(0)
+	for i, s := range sans {
+		s = normalizeSAN(s)
+		if s == want || strings.Replace(s, "=", "", 1) == want {
+			if found != 0 {
+				return 0, ErrSANInvalid
+			}
+			found = mlist.Moves[i]
+		}
+	}
+	if found == 0 {
+		return 0, ErrSANInvalid
+	}
+	return found, nil
+}
+
+func normalizeSAN(san string) string {
+	san = strings.TrimRight(san, "+#!?")
+	return strings.Replace(san, "0", "O", -1)
+}
+
+// SANBatch returns the standard algebraic notation of all moves in 'mlist', which must
+// hold the legal moves of the current position. Contrary to calling SAN for every move,
+// the moves are generated and grouped for disambiguation only once.
+func (b *Board) SANBatch(mlist *MoveList) []string {
+	origins := moveOrigins(b, mlist)
+
+	sans := make([]string, mlist.Size)
+	for i := uint32(0); i < mlist.Size; i++ {
+		sans[i] = b.san(mlist.Moves[i], origins)
+	}
+	return sans
+}
+
+// originKey identifies all moves of one piece type to one target square.
+type originKey struct {
+	to    Square
+	ptype Piece
+}
+
+// moveOrigins groups the from squares of all moves by target square and piece type.
+func moveOrigins(b *Board, mlist *MoveList) map[originKey][]Square {
+	origins := make(map[originKey][]Square, mlist.Size)
+	for i := uint32(0); i < mlist.Size; i++ {
+		from, to := mlist.Moves[i].From(), mlist.Moves[i].To()
+		key := originKey{to: to, ptype: b.Squares[from] & PIECE_MASK}
+		origins[key] = append(origins[key], from)
+	}
+	return origins
+}
+
+func (b *Board) san(m BitMove, origins map[originKey][]Square) string {
+	from, to, promo := m.All()
+	piece := b.Squares[from]
+	ptype := piece & PIECE_MASK
+
+	var sb strings.Builder
+
+	if ptype == KING && (from.File() == to.File()+2 || to.File() == from.File()+2) {
+		// Castling is the only king move spanning two files.
+		if to.File() > from.File() {
+			sb.WriteString("O-O")
+		} else {
+			sb.WriteString("O-O-O")
+		}
+	} else {
+		capture := !b.Squares[to].IsEmpty() || (ptype == PAWN && to == b.EpSquare)
+
+		if ptype == PAWN {
+			if capture {
+				sb.WriteString(fileName(from))
+			}
+		} else {
+			sb.WriteString(PrintMap[ptype|WHITE])
+			sb.WriteString(disambiguation(from, origins[originKey{to: to, ptype: ptype}]))
+		}
+
+		if capture {
+			sb.WriteByte('x')
+		}
+		sb.WriteString(PrintBoardIndex[to])
+
+		if promo != NONE {
+			sb.WriteString("=" + PrintMap[promo|WHITE])
+		}
+	}
+
+	sb.WriteString(b.sanCheckSuffix(m))
+
+	return sb.String()
+}
+
+// disambiguation returns the file, rank or square of 'from', if any of the other
+// 'origins' of moves by the same piece type to the same target square share them.
+func disambiguation(from Square, origins []Square) string {
+	ambiguous, sameFile, sameRank := false, false, false
+	for _, other := range origins {
+		if other == from {
+			continue
+		}
+		ambiguous = true
+		if other.File() == from.File() {
+			sameFile = true
+		}
+		if other.Rank() == from.Rank() {
+			sameRank = true
+		}
+	}
+
+	if !ambiguous {
+		return ""
+	} else if !sameFile {
+		return fileName(from)
+	} else if !sameRank {
+		return rankName(from)
+	}
+	return PrintBoardIndex[from]
+}
+
+// sanCheckSuffix returns '+' if the move gives check, '#' if it mates and
+// an empty string otherwise.
+func (b *Board) sanCheckSuffix(m BitMove) string {
+	cpy := *b
+	cpy.MakeLegalMove(m)
+	if !cpy.InCheck(cpy.Player) {
+		return ""
+	}
+
+	mlist := MoveList{}
+	cpy.GenerateAllLegalMoves(&mlist)
+	if mlist.Size == 0 {
+		return "#"
+	}
+	return "+"
+}
+
+// fileName returns the file letter (a-h) of a square.
+func fileName(sq Square) string {
+	return PrintBoardIndex[sq][0:1]
+}
+
+// rankName returns the rank digit (1-8) of a square.
+func rankName(sq Square) string {
+	return PrintBoardIndex[sq][1:2]
+}
@@ -0,0 +1,163 @@
+package chesskimo
+
+import (
+	"testing"
+)
+
+func TestSAN(t *testing.T) {
+	type set struct {
+		Fen  string
+		Move string
+		SAN  string
+	}
+
+	testset := []set{
+		set{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", "g1f3", "Nf3"},
+		set{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", "e2e4", "e4"},
+		set{"rnbqkbnr/ppp1pppp/8/3p4/4P3/8/PPPP1PPP/RNBQKBNR w KQkq d6 0 2", "e4d5", "exd5"},
+		set{"rnbqkb1r/pp2pppp/5n2/2ppP3/3P4/8/PPP2PPP/RNBQKBNR w KQkq d6 0 4", "e5d6", "exd6"},
+		set{"r3k2r/pppq1ppp/2npbn2/2b1p3/2B1P3/2NPBN2/PPPQ1PPP/R3K2R w KQkq - 4 8", "e1g1", "O-O"},
+		set{"r3k2r/pppq1ppp/2npbn2/2b1p3/2B1P3/2NPBN2/PPPQ1PPP/R3K2R w KQkq - 4 8", "e1c1", "O-O-O"},
+		// Disambiguation by file, rank and square.
+		set{"4k3/8/8/8/8/8/8/R4RK1 w - - 0 1", "a1d1", "Rad1"},
+		set{"4k3/8/R7/8/8/8/8/R3K3 w - - 0 1", "a1a3", "R1a3"},
+		set{"7k/8/8/8/Q1Q5/8/Q7/4K3 w - - 0 1", "a4b3", "Qa4b3"},
+		// Promotions, checks and mates.
+		set{"8/4P3/8/8/8/8/k7/4K3 w - - 0 1", "e7e8q", "e8=Q"},
+		set{"k7/4P3/8/8/8/8/8/4K3 w - - 0 1", "e7e8r", "e8=R+"},
+		set{"r1bqkb1r/pppp1ppp/2n2n2/4p2Q/2B1P3/8/PPPP1PPP/RNB1K1NR w KQkq - 4 4", "h5f7", "Qxf7#"},
+	}
+
+	board := NewBoard()
+	for i, ts := range testset {
+		if err := board.SetFEN(ts.Fen); err != nil {
+			t.Fatalf(err.Error())
+		}
+		m, err := ParseMiniNotation(ts.Move)
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		san := board.SAN(m)
+		if san != ts.SAN {
+			t.Fatalf("Test %d: expected SAN %s for move %s but got %s\n", i, ts.SAN, ts.Move, san)
+		}
+	}
+}
+
+func TestCastlingRookGivesCheck(t *testing.T) {
+	type set struct {
+		Fen    string
+		Move   string
+		SAN    string
+		RookSq Square
+	}
+
+	testset := []set{
+		set{"5k2/8/8/8/8/8/8/4K2R w K - 0 1", "e1g1", "O-O+", 0x05},
+		set{"3k4/8/8/8/8/8/8/R3K3 w Q - 0 1", "e1c1", "O-O-O+", 0x03},
+		set{"r3k3/8/8/8/8/8/8/3K4 b q - 0 1", "e8c8", "O-O-O+", 0x73},
+	}
+
+	board := NewBoard()
+	for i, ts := range testset {
+		if err := board.SetFEN(ts.Fen); err != nil {
+			t.Fatalf(err.Error())
+		}
+		m, err := ParseMiniNotation(ts.Move)
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		if !board.IsLegalMove(m) {
+			t.Fatalf("Test %d: castling %s should be legal\n", i, ts.Move)
+		}
+		if !board.GivesCheck(m) {
+			t.Fatalf("Test %d: castling %s should give check\n", i, ts.Move)
+		}
+		if san := board.SAN(m); san != ts.SAN {
+			t.Fatalf("Test %d: expected SAN %s but got %s\n", i, ts.SAN, san)
+		}
+
+		opp := board.Player.Flip()
+		board.MakeLegalMove(m)
+		if board.Squares[ts.RookSq]&PIECE_MASK != ROOK {
+			t.Fatalf("Test %d: expected rook on %s after castling\n", i, PrintBoardIndex[ts.RookSq])
+		}
+		if !board.InCheck(opp) {
+			t.Fatalf("Test %d: expected opponent to be in check after castling\n", i)
+		}
+		board.DetectChecksAndPins(board.Player)
+		if board.CheckInfo != ts.RookSq {
+			t.Fatalf("Test %d: expected the rook on %s to be detected as checker but got %d\n", i, PrintBoardIndex[ts.RookSq], board.CheckInfo)
+		}
+	}
+}
+
+func TestSANBatch(t *testing.T) {
+	fens := []string{
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+		"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1",
+		"7k/8/8/8/Q1Q5/8/Q7/4K3 w - - 0 1",
+		"n1n5/PPPk4/8/8/8/8/4Kppp/5N1N b - - 0 1",
+	}
+
+	board := NewBoard()
+	for _, fen := range fens {
+		if err := board.SetFEN(fen); err != nil {
+			t.Fatalf(err.Error())
+		}
+		mlist := MoveList{}
+		board.GenerateAllLegalMoves(&mlist)
+
+		sans := board.SANBatch(&mlist)
+		if uint32(len(sans)) != mlist.Size {
+			t.Fatalf("Expected %d moves but got %d for FEN %s\n", mlist.Size, len(sans), fen)
+		}
+		for i := uint32(0); i < mlist.Size; i++ {
+			san := board.SAN(mlist.Moves[i])
+			if sans[i] != san {
+				t.Fatalf("Move %s: batch SAN %s differs from SAN %s for FEN %s\n", mlist.Moves[i].MiniNotation(), sans[i], san, fen)
+			}
+		}
+	}
+}
+
+func TestParseSAN(t *testing.T) {
+	type set struct {
+		Fen  string
+		SAN  string
+		Move string
+	}
+
+	testset := []set{
+		set{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", "Nf3", "g1f3"},
+		set{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", "e4!", "e2e4"},
+		set{"r3k2r/pppq1ppp/2npbn2/2b1p3/2B1P3/2NPBN2/PPPQ1PPP/R3K2R w KQkq - 4 8", "0-0-0", "e1c1"},
+		set{"4k3/8/8/8/8/8/8/R4RK1 w - - 0 1", "Rad1", "a1d1"},
+		set{"k7/4P3/8/8/8/8/8/4K3 w - - 0 1", "e8R+", "e7e8r"},
+		set{"r1bqkb1r/pppp1ppp/2n2n2/4p2Q/2B1P3/8/PPPP1PPP/RNB1K1NR w KQkq - 4 4", "Qxf7#", "h5f7"},
+	}
+
+	board := NewBoard()
+	for i, ts := range testset {
+		if err := board.SetFEN(ts.Fen); err != nil {
+			t.Fatalf(err.Error())
+		}
+		m, err := board.ParseSAN(ts.SAN)
+		if err != nil {
+			t.Fatalf("Test %d: %s\n", i, err.Error())
+		}
+		if m.MiniNotation() != ts.Move {
+			t.Fatalf("Test %d: expected move %s for SAN %s but got %s\n", i, ts.Move, ts.SAN, m.MiniNotation())
+		}
+	}
+
+	// Ambiguous and illegal moves.
+	if err := board.SetFEN("4k3/8/8/8/8/8/8/R4RK1 w - - 0 1"); err != nil {
+		t.Fatalf(err.Error())
+	}
+	for _, san := range []string{"Rd1", "Ke3", "Xy9"} {
+		if _, err := board.ParseSAN(san); err != ErrSANInvalid {
+			t.Fatalf("Expected SAN %s to be invalid\n", san)
+		}
+	}
+}
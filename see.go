@@ -0,0 +1,237 @@
+package chesskimo
+
+// SmallestAttacker returns the square and piece of the least valuable piece of 'color'
+// which attacks 'sq'. The order is pawn < knight < bishop < rook < queen < king.
+// Sliders blocked by other pieces are not taken into account. If 'sq' is not attacked
+// at all, false is returned.
+func (b *Board) SmallestAttacker(sq Square, color Color) (Square, Piece, bool) {
+	// 1. Pawns are found by inspecting in reverse direction.
+	pawn := PAWN | color
+	for d := 0; d < 2; d++ {
+		from := sq.Add(PAWN_CAPTURE_DIRS[color.Flip()][d])
+		if from.OnBoard() && b.Squares[from] == pawn {
+			return from, pawn, true
+		}
+	}
+
+	// 2. Knights.
+	for i := uint8(0); i < b.Knights[color].Size; i++ {
+		from := b.Knights[color].Pieces[i]
+		if SQUARE_DIFFS[from.Diff(sq)].Contains(KNIGHT) {
+			return from, KNIGHT | color, true
+		}
+	}
+
+	// 3. Sliders ordered by value.
+	if from, ok := b.sliderAttacker(sq, &b.Bishops[color], BISHOP); ok {
+		return from, BISHOP | color, true
+	}
+	if from, ok := b.sliderAttacker(sq, &b.Rooks[color], ROOK); ok {
+		return from, ROOK | color, true
+	}
+	if from, ok := b.sliderAttacker(sq, &b.Queens[color], QUEEN); ok {
+		return from, QUEEN | color, true
+	}
+
+	// 4. King.
+	kingSq := b.Kings[color]
+	if kingSq.OnBoard() && SQUARE_DIFFS[kingSq.Diff(sq)].Contains(KING) {
+		return kingSq, KING | color, true
+	}
+
+	return OTB, EMPTY, false
+}
+
+// sliderAttacker returns the square of the first slider of type 'ptype' in the piece list,
+// which attacks 'sq' on an unblocked path.
+func (b *Board) sliderAttacker(sq Square, plist *PieceList, ptype Piece) (Square, bool) {
+	for i := uint8(0); i < plist.Size; i++ {
+		from := plist.Pieces[i]
+		diff := sq.Diff(from)
+		if !SQUARE_DIFFS[diff].Contains(ptype) {
+			continue
+		}
+		if b.isPathClear(sq, from, DIFF_DIRS[diff]) {
+			return from, true
+		}
+	}
+	return OTB, false
+}
+
+// isPathClear tests if all squares between 'from' and 'to' are empty,
+// when stepping from 'from' in direction 'dir'.
+func (b *Board) isPathClear(from, to Square, dir int8) bool {
+	for stepSq := from.Add(dir); stepSq != to; stepSq = stepSq.Add(dir) {
+		if !b.Squares[stepSq].IsEmpty() {
+			return false
+		}
+	}
+	return true
+}
+
+// SEE returns the static exchange evaluation of the move 'm' on the current board in centipawns,
+// using the piece values of 'cfg'. It assumes both sides alternately recapture on the target
+// square with their least valuable attacker and may stop the exchange whenever it is favorable.
+// Pins are not taken into account.
+func (b *Board) SEE(m BitMove, cfg *EvalConfig) int {
+	from, to, promo := m.All()
+	cpy := *b
+	mover := cpy.Squares[from]
+	side := mover.PieceColor()
+
+	gain := [32]int{}
+	if captured := cpy.Squares[to]; !captured.IsEmpty() {
+		gain[0] = cfg.PieceValue(captured)
+		cpy.removePiece(to)
+	} else if mover&PIECE_MASK == PAWN && to == cpy.EpSquare {
+		gain[0] = cfg.PieceValue(PAWN)
+		cpy.removePiece(to.Add(-PAWN_PUSH_DIRS[side]))
+	}
+
+	// The value of the piece standing on the target square.
+	onSquare := cfg.PieceValue(mover)
+	if promo != NONE {
+		gain[0] += cfg.PieceValue(promo) - cfg.PieceValue(PAWN)
+		onSquare = cfg.PieceValue(promo)
+	}
+	cpy.removeAttacker(from)
+
+	d := 0
+	for side = side.Flip(); d < len(gain)-1; side = side.Flip() {
+		sq, piece, ok := cpy.SmallestAttacker(to, side)
+		if !ok {
+			break
+		}
+		if piece&PIECE_MASK == KING {
+			// The king may only recapture if the square is not defended anymore.
+			if _, _, defended := cpy.SmallestAttacker(to, side.Flip()); defended {
+				break
+			}
+		}
+		d++
+		gain[d] = onSquare - gain[d-1]
+		onSquare = cfg.PieceValue(piece)
+		cpy.removeAttacker(sq)
+	}
+
+	// Each side chooses between stopping and continuing the exchange:
+	// gain[d-1] = -max(-gain[d-1], gain[d]).
+	for ; d > 0; d-- {
+		if gain[d] > -gain[d-1] {
+			gain[d-1] = -gain[d]
+		}
+	}
+
+	return gain[0]
+}
+
+// SEEGreaterEqual tests if SEE(m, cfg) >= threshold without computing the exact value.
+// The exchange is stopped as soon as the balance guarantees the result either way, which
+// makes it cheaper than SEE for pruning decisions.
+func (b *Board) SEEGreaterEqual(m BitMove, threshold int, cfg *EvalConfig) bool {
+	from, to, promo := m.All()
+	cpy := *b
+	mover := cpy.Squares[from]
+	side := mover.PieceColor()
+
+	// The balance is what the side to move gets beyond the threshold, if the exchange
+	// stops now.
+	balance := -threshold
+	if captured := cpy.Squares[to]; !captured.IsEmpty() {
+		balance += cfg.PieceValue(captured)
+		cpy.removePiece(to)
+	} else if mover&PIECE_MASK == PAWN && to == cpy.EpSquare {
+		balance += cfg.PieceValue(PAWN)
+		cpy.removePiece(to.Add(-PAWN_PUSH_DIRS[side]))
+	}
+	onSquare := cfg.PieceValue(mover)
+	if promo != NONE {
+		balance += cfg.PieceValue(promo) - cfg.PieceValue(PAWN)
+		onSquare = cfg.PieceValue(promo)
+	}
+	if balance < 0 {
+		// Even without recapture the threshold is not reached.
+		return false
+	}
+	// From now on the balance is given from the opponent's point of view, if he recaptures.
+	balance = onSquare - balance
+	if balance <= 0 {
+		// Even losing the moved piece keeps the threshold.
+		return true
+	}
+	cpy.removeAttacker(from)
+
+	// 'wins' tells if the moving side reaches the threshold, if the exchange stops now.
+	wins := true
+	for side = side.Flip(); ; side = side.Flip() {
+		sq, piece, ok := cpy.SmallestAttacker(to, side)
+		if !ok {
+			break
+		}
+		wins = !wins
+		if piece&PIECE_MASK == KING {
+			// The king may only recapture if the square is not defended anymore.
+			if _, _, defended := cpy.SmallestAttacker(to, side.Flip()); defended {
+				return !wins
+			}
+			return wins
+		}
+		balance = cfg.PieceValue(piece) - balance
+		// Ties count for the moving side, so it needs no margin to stop the
+		// exchange while the opponent needs a positive one.
+		margin := 0
+		if wins {
+			margin = 1
+		}
+		if balance < margin {
+			break
+		}
+		cpy.removeAttacker(sq)
+	}
+	return wins
+}
+
+// Threats returns the pseudo-legal captures of the opponent of 'color' which win material
+// according to SEE, i.e. the pieces of 'color' which are currently hanging. The side to
+// move is ignored and pins are not taken into account, but the king never captures a
+// defended piece.
+func (b *Board) Threats(color Color) []BitMove {
+	cpy := *b
+	// En passant is only possible for the side to move and never wins material anyway.
+	cpy.EpSquare = OTB
+	mlist := MoveList{}
+	cpy.generatePseudoLegal(&mlist, color.Flip())
+
+	threats := []BitMove{}
+	for i := uint32(0); i < mlist.Size; i++ {
+		m := mlist.Moves[i]
+		target := b.Squares[m.To()]
+		if !target.HasColor(color) || target&PIECE_MASK == KING {
+			continue
+		}
+		if b.Squares[m.From()]&PIECE_MASK == KING {
+			// The king leaves its square, so sliders behind it may defend the target, too.
+			after := *b
+			after.removeAttacker(m.From())
+			if _, _, defended := after.SmallestAttacker(m.To(), color); defended {
+				continue
+			}
+		}
+		if b.SEE(m, &DefaultEvalConfig) > 0 {
+			threats = append(threats, m)
+		}
+	}
+	return threats
+}
+
+// removeAttacker removes the piece on 'sq' from the board. Contrary to removePiece
+// kings are also removed, which is only valid for exchange evaluation.
+func (b *Board) removeAttacker(sq Square) {
+	piece := b.Squares[sq]
+	if piece&PIECE_MASK == KING {
+		b.Squares[sq] = EMPTY
+		b.Kings[piece.PieceColor()] = OTB
+		return
+	}
+	b.removePiece(sq)
+}
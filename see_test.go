@@ -0,0 +1,171 @@
+package chesskimo
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestSmallestAttacker(t *testing.T) {
+	type set struct {
+		Fen    string
+		Target Square
+		Color  Color
+		From   Square
+		Piece  Piece
+		Found  bool
+	}
+
+	testset := []set{
+		// d5 is attacked by the pawn on e4, the knight on c3 and the queen on d1.
+		set{"4k3/8/8/3p4/4P3/2N5/8/3QK3 w - - 0 1", 0x43, WHITE, 0x34, WPAWN, true},
+		// Without the pawn the knight is the smallest attacker.
+		set{"4k3/8/8/3p4/8/2N5/8/3QK3 w - - 0 1", 0x43, WHITE, 0x22, WKNIGHT, true},
+		// The bishop is preferred over the rook.
+		set{"4k3/8/8/3p4/8/1B6/8/3RK3 w - - 0 1", 0x43, WHITE, 0x21, WBISHOP, true},
+		// The rook is blocked by a pawn, so the queen attacks.
+		set{"4k3/8/8/3p4/8/3P4/6Q1/3RK3 w - - 0 1", 0x43, WHITE, 0x16, WQUEEN, true},
+		// Only the king attacks.
+		set{"8/8/8/3p4/4K3/8/8/7k w - - 0 1", 0x43, WHITE, 0x34, WKING, true},
+		// A black pawn attacking a white piece.
+		set{"4k3/8/2p5/3N4/8/8/8/4K3 w - - 0 1", 0x43, BLACK, 0x52, BPAWN, true},
+		// Not attacked at all.
+		set{"4k3/8/8/3p4/8/8/8/4K3 w - - 0 1", 0x43, WHITE, OTB, EMPTY, false},
+	}
+
+	board := NewBoard()
+	for i, ts := range testset {
+		if err := board.SetFEN(ts.Fen); err != nil {
+			t.Fatalf(err.Error())
+		}
+		from, piece, found := board.SmallestAttacker(ts.Target, ts.Color)
+		if from != ts.From || piece != ts.Piece || found != ts.Found {
+			t.Fatalf("Test %d: expected attacker %s %s (%v) but got %s %s (%v)\n", i, PrintBoardIndex[ts.From], PrintMap[ts.Piece], ts.Found, PrintBoardIndex[from], PrintMap[piece], found)
+		}
+	}
+}
+
+func TestSEE(t *testing.T) {
+	type set struct {
+		Fen  string
+		Move string
+		SEE  int
+	}
+
+	testset := []set{
+		// Pawn takes protected knight.
+		set{"4k3/8/2p5/3n4/4P3/8/8/4K3 w - - 0 1", "e4d5", VALUE_KNIGHT - VALUE_PAWN},
+		// Queen takes protected knight.
+		set{"4k3/8/2p5/3n4/8/8/3Q4/4K3 w - - 0 1", "d2d5", VALUE_KNIGHT - VALUE_QUEEN},
+		// Rook takes unprotected pawn.
+		set{"4k3/8/8/3p4/8/8/8/3RK3 w - - 0 1", "d1d5", VALUE_PAWN},
+		// Rook takes pawn protected by a knight.
+		set{"4k3/8/5n2/3p4/8/8/8/3RK3 w - - 0 1", "d1d5", VALUE_PAWN - VALUE_ROOK},
+		// Rook takes pawn protected by a rook, but the second rook x-rays through.
+		set{"3rk3/8/8/3p4/8/8/3R4/3RK3 w - - 0 1", "d2d5", VALUE_PAWN},
+		// The king recaptures an undefended rook but not a defended one.
+		set{"8/8/3k4/3p4/8/8/8/3RK3 w - - 0 1", "d1d5", VALUE_PAWN - VALUE_ROOK},
+		set{"8/8/3k4/3p4/8/8/3R4/3RK3 w - - 0 1", "d2d5", VALUE_PAWN},
+		// The rook would lose the exchange by recapturing, so the pawn is won.
+		set{"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1", "g2h3", VALUE_PAWN},
+		// En passant capture.
+		set{"4k3/8/8/3pP3/8/8/8/4K3 w - d6 0 1", "e5d6", VALUE_PAWN},
+	}
+
+	board := NewBoard()
+	for i, ts := range testset {
+		if err := board.SetFEN(ts.Fen); err != nil {
+			t.Fatalf(err.Error())
+		}
+		m, err := ParseMiniNotation(ts.Move)
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		if see := board.SEE(m, &DefaultEvalConfig); see != ts.SEE {
+			t.Fatalf("Test %d: expected SEE %d for move %s but got %d\n", i, ts.SEE, ts.Move, see)
+		}
+	}
+}
+
+func TestSEEGreaterEqual(t *testing.T) {
+	fens := []string{
+		"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1",
+		"r3k2r/Pppp1ppp/1b3nbN/nP6/BBP1P3/q4N2/Pp1P2PP/R2Q1RK1 w kq - 0 1",
+		"2r3k1/1q1nbppp/r3p3/3pP3/pPpP4/P1Q2N2/2RN1PPP/2R4K b - - 0 1",
+		"3rk3/8/8/3p4/8/8/3R4/3RK3 w - - 0 1",
+		"8/8/3k4/3p4/8/8/3R4/3RK3 w - - 0 1",
+		"n1n5/PPPk4/8/8/8/8/4Kppp/5N1N b - - 0 1",
+	}
+
+	// Compare with the full SEE for all captures and promotions of random games.
+	rnd := rand.New(rand.NewSource(1))
+	tested := 0
+	for _, fen := range fens {
+		for game := 0; game < 5; game++ {
+			board := NewBoard()
+			if err := board.SetFEN(fen); err != nil {
+				t.Fatalf(err.Error())
+			}
+			for ply := 0; ply < 40; ply++ {
+				mlist := MoveList{}
+				board.GenerateAllLegalMoves(&mlist)
+				if mlist.Size == 0 {
+					break
+				}
+				for i := uint32(0); i < mlist.Size; i++ {
+					m := mlist.Moves[i]
+					if board.isQuiet(m) {
+						continue
+					}
+					see := board.SEE(m, &DefaultEvalConfig)
+					for _, threshold := range []int{see - 1, see, see + 1, -VALUE_ROOK, -VALUE_PAWN, 0, VALUE_PAWN, VALUE_KNIGHT} {
+						if ge := board.SEEGreaterEqual(m, threshold, &DefaultEvalConfig); ge != (see >= threshold) {
+							t.Fatalf("Expected SEE %d >= %d to be %v for %s in %s\n", see, threshold, see >= threshold, m.MiniNotation(), board.FEN())
+						}
+					}
+					tested++
+				}
+				board.MakeLegalMove(mlist.Moves[rnd.Intn(int(mlist.Size))])
+			}
+		}
+	}
+	if tested < 100 {
+		t.Fatalf("Expected at least 100 tested captures but got %d\n", tested)
+	}
+}
+
+func TestThreats(t *testing.T) {
+	board := NewBoard()
+	// The black knight attacks the hanging rook and the knight, which is defended by a pawn.
+	if err := board.SetFEN("4k3/8/8/3n4/8/2N1R3/1P6/4K3 w - - 0 1"); err != nil {
+		t.Fatalf(err.Error())
+	}
+	before := MoveList{}
+	board.GenerateAllLegalMoves(&before)
+
+	threats := board.Threats(WHITE)
+	if len(threats) != 1 || threats[0] != parseTestMove(t, "d5e3") {
+		t.Fatalf("Expected the threat d5e3 but got %v\n", threats)
+	}
+	// The white knight attacks the black knight, which is undefended.
+	threats = board.Threats(BLACK)
+	if len(threats) != 1 || threats[0] != parseTestMove(t, "c3d5") {
+		t.Fatalf("Expected the threat c3d5 but got %v\n", threats)
+	}
+
+	// The board is unchanged.
+	after := MoveList{}
+	board.GenerateAllLegalMoves(&after)
+	if before.Size != after.Size {
+		t.Fatalf("Expected %d legal moves after computing threats but got %d\n", before.Size, after.Size)
+	}
+	// The black king cannot capture the rook, which is defended by the queen or by the
+	// bishop behind the king.
+	for _, fen := range []string{"8/8/8/8/8/3k4/4R3/3QK3 b - - 0 1", "8/8/8/1B6/8/3k4/4R3/4K3 b - - 0 1"} {
+		if err := board.SetFEN(fen); err != nil {
+			t.Fatalf(err.Error())
+		}
+		if threats := board.Threats(WHITE); len(threats) != 0 {
+			t.Fatalf("Expected no threats for %s but got %v\n", fen, threats)
+		}
+	}
+}
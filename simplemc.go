@@ -16,6 +16,10 @@ func SimpleMCSearch(engine *Engine, ss *SearchSettings, dostop *uint32) SearchRe
 	workMlist := MoveList{}
 	workBoard := *board
 	simcount := uint64(0)
+	// Every position reached in a simulation counts as a node. The longest
+	// simulation is reported as the selective depth.
+	nodes := uint64(0)
+	selDepth := 0
 
 	// Find all possible first moves.
 	board.GenerateAllLegalMoves(&mlist)
@@ -29,6 +33,8 @@ func SimpleMCSearch(engine *Engine, ss *SearchSettings, dostop *uint32) SearchRe
 			//			engine.logger.Println("Simulation for move ", move.MiniNotation())
 			workBoard = *board
 			workBoard.MakeLegalMove(move)
+			nodes++
+			plies := 1
 			for { // run sim until game ends
 				//				engine.logger.Println(workBoard.String())
 				// 1. Generate moves.
@@ -37,7 +43,7 @@ func SimpleMCSearch(engine *Engine, ss *SearchSettings, dostop *uint32) SearchRe
 				workBoard.GenerateAllLegalMoves(&workMlist)
 				//				engine.logger.Println("genall: ", workMlist.String())
 				// e2e4 h7h5 d2d4
-				if workBoard.MoveNumber > 150 {
+				if workBoard.MoveNumber > 75 {
 					// artificial limit -> draw
 					break
 				} else if workMlist.Size == 0 {
@@ -60,8 +66,13 @@ func SimpleMCSearch(engine *Engine, ss *SearchSettings, dostop *uint32) SearchRe
 				//				engine.logger.Printf("MAKE MOVE %s", workMlist.Moves[r].MiniNotation())
 				//				engine.logger.Print(workBoard.InfoBoardString())
 				workBoard.MakeLegalMove(workMlist.Moves[r])
+				nodes++
+				plies++
 			}
 			simcount++
+			if plies > selDepth {
+				selDepth = plies
+			}
 
 			if time.Since(startTime).Seconds() >= maxtime {
 				break
@@ -81,6 +92,9 @@ func SimpleMCSearch(engine *Engine, ss *SearchSettings, dostop *uint32) SearchRe
 		}
 		engine.logger.Printf("Move %s has score %d", mlist.Moves[i].MiniNotation(), score)
 	}
+	sr.Nodes = nodes
+	sr.SelDepth = selDepth
+	sr.Time = time.Since(startTime)
 
 	return sr
 }
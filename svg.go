@@ -0,0 +1,108 @@
+package chesskimo
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// Default edge length of a square in pixels.
+	SVG_SQUARE_SIZE = 45
+	// Width of the border holding the coordinates in pixels.
+	SVG_MARGIN = 20
+
+	SVG_LIGHT_SQUARE = "#f0d9b5"
+	SVG_DARK_SQUARE  = "#b58863"
+	SVG_LAST_MOVE    = "#cdd26a"
+	SVG_CHECK        = "#e02020"
+	SVG_COORDINATES  = "#666666"
+)
+
+// SVGOptions configure the rendering of Board.SVG.
+type SVGOptions struct {
+	// SquareSize is the edge length of a square in pixels. Defaults to SVG_SQUARE_SIZE.
+	SquareSize int
+	// Flip renders the board from black's point of view.
+	Flip bool
+	// Coordinates adds the file letters and rank numbers around the board.
+	Coordinates bool
+	// LastMove is highlighted if it is not the zero move.
+	LastMove BitMove
+	// Check marks the king of the side to move if it is in check.
+	Check bool
+}
+
+// SVG renders the position as a standalone SVG image. Every square is a <rect>
+// and every piece is a <text> element holding its Unicode glyph.
+func (b *Board) SVG(opts SVGOptions) string {
+	size := opts.SquareSize
+	if size <= 0 {
+		size = SVG_SQUARE_SIZE
+	}
+	margin := 0
+	if opts.Coordinates {
+		margin = SVG_MARGIN
+	}
+	total := 8*size + 2*margin
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`+"\n",
+		total, total, total, total)
+
+	// pos returns the top left corner of the square in the image.
+	pos := func(sq Square) (int, int) {
+		f, r := int(sq.File()), 7-int(sq.Rank())
+		if opts.Flip {
+			f, r = 7-f, 7-r
+		}
+		return margin + f*size, margin + r*size
+	}
+
+	var checkSq Square = OTB
+	if opts.Check && b.InCheck(b.Player) {
+		checkSq = b.Kings[b.Player]
+	}
+
+	for _, sq := range AllSquares() {
+		x, y := pos(sq)
+		fill := SVG_DARK_SQUARE
+		if sq.SquareColor() == WHITE {
+			fill = SVG_LIGHT_SQUARE
+		}
+		if opts.LastMove != 0 && (sq == opts.LastMove.From() || sq == opts.LastMove.To()) {
+			fill = SVG_LAST_MOVE
+		}
+		fmt.Fprintf(&sb, `<rect x="%d" y="%d" width="%d" height="%d" fill="%s"/>`+"\n", x, y, size, size, fill)
+		if sq == checkSq {
+			fmt.Fprintf(&sb, `<circle cx="%d" cy="%d" r="%d" fill="%s" fill-opacity="0.6"/>`+"\n",
+				x+size/2, y+size/2, size/2, SVG_CHECK)
+		}
+	}
+
+	for _, sq := range AllSquares() {
+		piece := b.Squares[sq]
+		if piece.IsEmpty() {
+			continue
+		}
+		x, y := pos(sq)
+		fmt.Fprintf(&sb, `<text class="piece" x="%d" y="%d" font-size="%d" text-anchor="middle" dominant-baseline="central">%s</text>`+"\n",
+			x+size/2, y+size/2, size*4/5, UnicodeMap[piece])
+	}
+
+	if opts.Coordinates {
+		for i := 0; i < 8; i++ {
+			file, rank := string(rune('a'+i)), string(rune('8'-i))
+			if opts.Flip {
+				file, rank = string(rune('h'-i)), string(rune('1'+i))
+			}
+			c := margin + i*size + size/2
+			fmt.Fprintf(&sb, `<text x="%d" y="%d" font-size="%d" fill="%s" text-anchor="middle" dominant-baseline="central">%s</text>`+"\n",
+				c, total-margin/2, margin*3/5, SVG_COORDINATES, file)
+			fmt.Fprintf(&sb, `<text x="%d" y="%d" font-size="%d" fill="%s" text-anchor="middle" dominant-baseline="central">%s</text>`+"\n",
+				margin/2, c, margin*3/5, SVG_COORDINATES, rank)
+		}
+	}
+
+	sb.WriteString("</svg>\n")
+	return sb.String()
+}
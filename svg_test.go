@@ -0,0 +1,46 @@
+package chesskimo
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSVG(t *testing.T) {
+	board := NewBoard()
+	board.SetStartingPosition()
+
+	svg := board.SVG(SVGOptions{Coordinates: true})
+	if !strings.HasPrefix(svg, "<svg ") || !strings.HasSuffix(svg, "</svg>\n") {
+		t.Fatalf("Expected a standalone SVG but got:\n%s\n", svg)
+	}
+	if n := strings.Count(svg, "<rect "); n != 64 {
+		t.Fatalf("Expected 64 squares but got %d\n", n)
+	}
+	if n := strings.Count(svg, `class="piece"`); n != 32 {
+		t.Fatalf("Expected 32 pieces but got %d\n", n)
+	}
+
+	// Highlight the last move and mark the check in a fool's mate.
+	if err := board.SetFEN("rnb1kbnr/pppp1ppp/8/4p3/6Pq/5P2/PPPPP2P/RNBQKBNR w KQkq - 1 3"); err != nil {
+		t.Fatalf(err.Error())
+	}
+	svg = board.SVG(SVGOptions{LastMove: parseTestMove(t, "d8h4"), Check: true})
+	if n := strings.Count(svg, SVG_LAST_MOVE); n != 2 {
+		t.Fatalf("Expected 2 highlighted squares but got %d\n", n)
+	}
+	if n := strings.Count(svg, "<circle "); n != 1 {
+		t.Fatalf("Expected one check marker but got %d\n", n)
+	}
+
+	// The white king on e1 is drawn in the bottom half, or the top half if flipped.
+	for _, flip := range []bool{false, true} {
+		svg = board.SVG(SVGOptions{Flip: flip, SquareSize: 10})
+		king := strings.Index(svg, "♔")
+		line := svg[strings.LastIndex(svg[:king], "\n")+1 : king]
+		bottom := strings.Contains(line, `y="75"`)
+		top := strings.Contains(line, `y="5"`)
+		if (!flip && !bottom) || (flip && !top) {
+			t.Fatalf("Expected white king in the correct half (flip=%v) but got %q\n", flip, line)
+		}
+	}
+}
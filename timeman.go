@@ -0,0 +1,77 @@
+package chesskimo
+
+import (
+	"time"
+)
+
+const (
+	// DEFAULT_MOVES_TO_GO is the assumed number of remaining moves if the time
+	// control does not define one (sudden death).
+	DEFAULT_MOVES_TO_GO = 30
+
+	// MOVE_OVERHEAD is kept in reserve on every move for the communication with the GUI.
+	MOVE_OVERHEAD = 50 * time.Millisecond
+	// A single move never uses more than 1/MAX_MOVE_TIME_DIVISOR of the usable time, even
+	// right before the clock is refilled at the end of a time control.
+	MAX_MOVE_TIME_DIVISOR = 3
+	// MIN_MOVE_TIME is allocated if almost no time is left.
+	MIN_MOVE_TIME = time.Millisecond
+
+	// The move time is scaled by the game phase between PHASE_TIME_MIN_PERCENT in the
+	// opening or endgame and PHASE_TIME_MAX_PERCENT in the middlegame, where
+	// positions are the most complex.
+	PHASE_TIME_MIN_PERCENT = 80
+	PHASE_TIME_MAX_PERCENT = 120
+)
+
+// ComputeMoveTime returns the time which should be spent on the next move of 'color'
+// for the clock settings in 'ss'. A fixed move time takes precedence. If no clock
+// information is available zero is returned, meaning no time limit.
+//
+// The remaining time is split over the moves to go plus one move kept in reserve, so the
+// share tapers off as 'movestogo' approaches 1. It is also capped, so the last moves
+// before the clock is refilled leave a safety buffer of at least two thirds of the usable
+// time plus MOVE_OVERHEAD.
+func ComputeMoveTime(ss *SearchSettings, color Color) time.Duration {
+	return computeMoveTime(ss, color, 100)
+}
+
+// ComputeMoveTimeForPhase works like ComputeMoveTime, but spends relatively more time
+// in the middlegame and less in the opening and endgame. 'phase' is the result of
+// Board.GamePhase. A fixed move time is not scaled.
+func ComputeMoveTimeForPhase(ss *SearchSettings, color Color, phase int) time.Duration {
+	// The percentage falls quadratically with the distance to the middlegame at PHASE_MAX/2.
+	mid := PHASE_MAX / 2
+	dist := (phase - mid) * (phase - mid)
+	percent := PHASE_TIME_MAX_PERCENT - (PHASE_TIME_MAX_PERCENT-PHASE_TIME_MIN_PERCENT)*dist/(mid*mid)
+	return computeMoveTime(ss, color, percent)
+}
+
+// computeMoveTime scales the time per move by 'percent' before the limits are applied.
+func computeMoveTime(ss *SearchSettings, color Color, percent int) time.Duration {
+	if ss.MoveTime > 0 {
+		return ss.MoveTime
+	}
+	remaining := ss.Time[color]
+	if remaining <= 0 {
+		return 0
+	}
+
+	movesToGo := ss.MovesToGo
+	if movesToGo <= 0 {
+		movesToGo = DEFAULT_MOVES_TO_GO
+	}
+
+	usable := remaining - MOVE_OVERHEAD
+	if usable < MIN_MOVE_TIME {
+		return MIN_MOVE_TIME
+	}
+	moveTime := (usable/time.Duration(movesToGo+1) + ss.Increment[color]) * time.Duration(percent) / 100
+	if limit := usable / MAX_MOVE_TIME_DIVISOR; moveTime > limit {
+		moveTime = limit
+	}
+	if moveTime < MIN_MOVE_TIME {
+		moveTime = MIN_MOVE_TIME
+	}
+	return moveTime
+}
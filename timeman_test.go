@@ -0,0 +1,82 @@
+package chesskimo
+
+import (
+	"testing"
+	"time"
+)
+
+func TestComputeMoveTime(t *testing.T) {
+	type set struct {
+		Time      time.Duration
+		Increment time.Duration
+		MovesToGo int
+		Expected  time.Duration
+	}
+
+	testset := []set{
+		// Sudden death splits the time over the default number of moves and one in reserve.
+		set{30 * time.Second, 0, 0, (30*time.Second - MOVE_OVERHEAD) / (DEFAULT_MOVES_TO_GO + 1)},
+		set{30 * time.Second, time.Second, 0, (30*time.Second-MOVE_OVERHEAD)/(DEFAULT_MOVES_TO_GO+1) + time.Second},
+		set{10 * time.Second, 0, 10, (10*time.Second - MOVE_OVERHEAD) / 11},
+		// The share tapers off and is capped right before the clock is refilled.
+		set{10 * time.Second, 0, 3, (10*time.Second - MOVE_OVERHEAD) / 4},
+		set{10 * time.Second, 0, 2, (10*time.Second - MOVE_OVERHEAD) / MAX_MOVE_TIME_DIVISOR},
+		set{10 * time.Second, 0, 1, (10*time.Second - MOVE_OVERHEAD) / MAX_MOVE_TIME_DIVISOR},
+		set{10 * time.Second, 5 * time.Second, 1, (10*time.Second - MOVE_OVERHEAD) / MAX_MOVE_TIME_DIVISOR},
+		// Almost no time is left.
+		set{60 * time.Millisecond, 0, 1, (60*time.Millisecond - MOVE_OVERHEAD) / MAX_MOVE_TIME_DIVISOR},
+		set{20 * time.Millisecond, 0, 1, MIN_MOVE_TIME},
+	}
+
+	for i, ts := range testset {
+		ss := SearchSettings{MovesToGo: ts.MovesToGo}
+		ss.Time[WHITE], ss.Increment[WHITE] = ts.Time, ts.Increment
+		if moveTime := ComputeMoveTime(&ss, WHITE); moveTime != ts.Expected {
+			t.Fatalf("Test %d: expected move time %s but got %s\n", i, ts.Expected, moveTime)
+		}
+	}
+
+	// With few moves to go and little time a safety buffer remains, even with increments.
+	for movesToGo := 1; movesToGo <= 3; movesToGo++ {
+		ss := SearchSettings{MovesToGo: movesToGo}
+		ss.Time[BLACK], ss.Increment[BLACK] = 500*time.Millisecond, time.Second
+		moveTime := ComputeMoveTime(&ss, BLACK)
+		if buffer := ss.Time[BLACK] - moveTime; buffer < ss.Time[BLACK]*2/3 {
+			t.Fatalf("Expected a buffer of at least two thirds of the time with movestogo %d but %s of %s were allocated\n",
+				movesToGo, moveTime, ss.Time[BLACK])
+		}
+	}
+
+	// A fixed move time takes precedence, no clock means no limit.
+	if moveTime := ComputeMoveTime(&SearchSettings{MoveTime: time.Second, MovesToGo: 1}, WHITE); moveTime != time.Second {
+		t.Fatalf("Expected fixed move time 1s but got %s\n", moveTime)
+	}
+	if moveTime := ComputeMoveTime(&SearchSettings{}, WHITE); moveTime != 0 {
+		t.Fatalf("Expected no limit without clock but got %s\n", moveTime)
+	}
+}
+
+func TestComputeMoveTimeForPhase(t *testing.T) {
+	ss := SearchSettings{}
+	ss.Time[WHITE] = 30 * time.Second
+	base := ComputeMoveTime(&ss, WHITE)
+
+	opening := ComputeMoveTimeForPhase(&ss, WHITE, PHASE_MAX)
+	middlegame := ComputeMoveTimeForPhase(&ss, WHITE, PHASE_MAX/2)
+	endgame := ComputeMoveTimeForPhase(&ss, WHITE, 0)
+	if opening != base*PHASE_TIME_MIN_PERCENT/100 || endgame != opening {
+		t.Fatalf("Expected %d%% of %s in the opening and endgame but got %s and %s\n", PHASE_TIME_MIN_PERCENT, base, opening, endgame)
+	}
+	if middlegame != base*PHASE_TIME_MAX_PERCENT/100 {
+		t.Fatalf("Expected %d%% of %s in the middlegame but got %s\n", PHASE_TIME_MAX_PERCENT, base, middlegame)
+	}
+
+	// The safety buffer and fixed move times are not affected.
+	ss.MovesToGo = 1
+	if moveTime := ComputeMoveTimeForPhase(&ss, WHITE, PHASE_MAX/2); moveTime != (ss.Time[WHITE]-MOVE_OVERHEAD)/MAX_MOVE_TIME_DIVISOR {
+		t.Fatalf("Expected the move time to be capped at a third of the usable time but got %s\n", moveTime)
+	}
+	if moveTime := ComputeMoveTimeForPhase(&SearchSettings{MoveTime: time.Second}, WHITE, PHASE_MAX/2); moveTime != time.Second {
+		t.Fatalf("Expected fixed move time 1s but got %s\n", moveTime)
+	}
+}
@@ -5,9 +5,16 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 )
 
+// UCI_DEFAULT_MOVE_TIME limits a "go" command without any depth or time limit.
+// The search runs synchronously and cannot be interrupted by "stop", so even
+// "go infinite" must terminate on its own.
+const UCI_DEFAULT_MOVE_TIME = 5 * time.Second
+
 type UCI struct {
 	newGame bool
 }
@@ -36,7 +43,7 @@ func (u *UCI) RunInputOutputLoop(engine *Engine) {
 				u.cmdUci(engine)
 			case "isready":
 				// Check if engine can receive commands/is active.
-				u.cmdIsready()
+				u.cmdIsready(engine)
 			case "ucinewgame":
 				u.cmdNewGame(engine)
 			case "position":
@@ -45,6 +52,9 @@ func (u *UCI) RunInputOutputLoop(engine *Engine) {
 				u.cmdGo(engine, input[1:])
 			case "stop":
 				u.cmdStop(engine)
+			case "eval":
+				// Not part of UCI: print the static evaluation for debugging.
+				u.cmdEval(engine)
 			}
 		}
 	}
@@ -58,6 +68,7 @@ func (u *UCI) cmdStop(engine *Engine) {
 }
 
 func (u *UCI) cmdGo(engine *Engine, args []string) {
+	ss := SearchSettings{}
 	for len(args) > 0 {
 		cmd := args[0]
 		args = args[1:]
@@ -65,28 +76,61 @@ func (u *UCI) cmdGo(engine *Engine, args []string) {
 		case "searchmoves":
 		case "ponder":
 		case "wtime":
+			ss.Time[WHITE] = parseMillis(&args)
 		case "btime":
+			ss.Time[BLACK] = parseMillis(&args)
 		case "winc":
+			ss.Increment[WHITE] = parseMillis(&args)
 		case "binc":
+			ss.Increment[BLACK] = parseMillis(&args)
 		case "movestogo":
+			ss.MovesToGo = parseInt(&args)
 		case "depth":
+			ss.MaxDepth = parseInt(&args)
 		case "nodes":
 		case "mate":
 		case "movetime":
+			ss.MoveTime = parseMillis(&args)
 		case "infinite":
 		}
 	}
+	ss.MoveTime = ComputeMoveTimeForPhase(&ss, engine.board.Player, engine.board.GamePhase())
+	if ss.MaxDepth <= 0 && ss.MoveTime <= 0 {
+		ss.MoveTime = UCI_DEFAULT_MOVE_TIME
+	}
 
 	//	moves := engine.GetLegalMoves()
 	//	r := rand.Intn(int(moves.Size))
 	//	bm := moves.Moves[r]
-	ts := SearchSettings{}
 	dostop := uint32(0)
-	sr := engine.search(engine, &ts, &dostop)
+	sr := engine.search(engine, &ss, &dostop)
 	engine.logger.Println("--> best move:", sr.Move.MiniNotation())
 	engine.board.MakeLegalMove(sr.Move)
 	engine.logger.Print(engine.board.String())
-	fmt.Println("bestmove", sr.Move.MiniNotation())
+	if sr.Ponder != 0 {
+		fmt.Fprintln(engine.Output, "bestmove", sr.Move.MiniNotation(), "ponder", sr.Ponder.MiniNotation())
+	} else {
+		fmt.Fprintln(engine.Output, "bestmove", sr.Move.MiniNotation())
+	}
+}
+
+// parseInt consumes the next argument and returns it as integer.
+// Missing or malformed arguments are treated as 0.
+func parseInt(args *[]string) int {
+	if len(*args) == 0 {
+		return 0
+	}
+	n, err := strconv.Atoi((*args)[0])
+	*args = (*args)[1:]
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+// parseMillis consumes the next argument and returns it as duration in milliseconds.
+func parseMillis(args *[]string) time.Duration {
+	return time.Duration(parseInt(args)) * time.Millisecond
 }
 
 func (u *UCI) cmdPosition(engine *Engine, args []string) {
@@ -132,7 +176,7 @@ func (u *UCI) cmdPosition(engine *Engine, args []string) {
 				}
 				err := engine.board.SetFEN(fen)
 				if err != nil {
-					fmt.Println("--> error: ", err.Error())
+					fmt.Fprintln(engine.Output, "--> error: ", err.Error())
 					return // UCI ignores bad commands.
 				}
 			}
@@ -140,13 +184,9 @@ func (u *UCI) cmdPosition(engine *Engine, args []string) {
 			// Last check for "moves" subcommand.
 			// Must at least have length 2 (including a move).
 			if len(args) > 1 && args[0] == "moves" {
-				moves := args[1:]
-				for _, m := range moves {
-					err := engine.MakeMove(m)
-					if err != nil {
-						// UCI is crap.
-						engine.logger.Print("*** move ", m, " impossible: ", err.Error())
-					}
+				if err := engine.board.ApplyUCIMoves(args[1:]); err != nil {
+					// UCI is crap.
+					engine.logger.Print("*** ", err.Error())
 				}
 			}
 		}
@@ -154,19 +194,26 @@ func (u *UCI) cmdPosition(engine *Engine, args []string) {
 	}
 }
 
+// cmdEval prints every term of the static evaluation of the current position
+// in centipawns from white's point of view.
+func (u *UCI) cmdEval(engine *Engine) {
+	e := engine.Evaluate()
+	fmt.Fprint(engine.Output, e.String())
+}
+
 func (u *UCI) cmdNewGame(engine *Engine) {
 	u.newGame = true
 	engine.NewGame()
 }
 
-func (u *UCI) cmdIsready() {
+func (u *UCI) cmdIsready(engine *Engine) {
 	// TODO wait for engine ?
-	fmt.Println("readyok")
+	fmt.Fprintln(engine.Output, "readyok")
 }
 
 func (u *UCI) cmdUci(engine *Engine) {
-	fmt.Println("id name", engine.name)
-	fmt.Println("id author", engine.author)
+	fmt.Fprintln(engine.Output, "id name", engine.FullName())
+	fmt.Fprintln(engine.Output, "id author", engine.author)
 	// TODO -> add all possible options here.
-	fmt.Println("uciok")
+	fmt.Fprintln(engine.Output, "uciok")
 }
@@ -0,0 +1,147 @@
+package chesskimo
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUciIdentification(t *testing.T) {
+	uci := &UCI{}
+	engine := NewEngine("chesskimo", "David Linus Briemann", "1.2.3", uci, AlphaBetaSearch)
+
+	out := bytes.Buffer{}
+	engine.Output = &out
+	uci.cmdUci(engine)
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+
+	expected := []string{
+		"id name chesskimo 1.2.3",
+		"id author David Linus Briemann",
+		"uciok",
+	}
+	if len(lines) != len(expected) {
+		t.Fatalf("Expected %d lines but got:\n%s\n", len(expected), out.String())
+	}
+	for i, line := range expected {
+		if lines[i] != line {
+			t.Fatalf("Expected line %q but got %q\n", line, lines[i])
+		}
+	}
+}
+
+func TestUciOutput(t *testing.T) {
+	uci := &UCI{}
+	engine := NewEngine("chesskimo", "David Linus Briemann", "", uci, AlphaBetaSearch)
+	out := bytes.Buffer{}
+	engine.Output = &out
+
+	uci.cmdUci(engine)
+	uci.cmdIsready(engine)
+	uci.cmdNewGame(engine)
+	uci.cmdPosition(engine, strings.Fields("startpos moves e2e4 e7e5 d1h5 b8c6 f1c4 g8f6"))
+	uci.cmdGo(engine, strings.Fields("depth 2"))
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	expected := []string{"id name chesskimo", "id author David Linus Briemann", "uciok", "readyok", "bestmove h5f7"}
+	if len(lines) != len(expected) {
+		t.Fatalf("Expected %d lines but got:\n%s\n", len(expected), out.String())
+	}
+	for i, line := range expected {
+		if lines[i] != line {
+			t.Fatalf("Expected line %q but got %q\n", line, lines[i])
+		}
+	}
+}
+
+func TestUciPonder(t *testing.T) {
+	uci := &UCI{}
+	engine := NewEngine("chesskimo", "David Linus Briemann", "", uci, AlphaBetaSearch)
+	out := bytes.Buffer{}
+	engine.Output = &out
+
+	uci.cmdNewGame(engine)
+	uci.cmdPosition(engine, strings.Fields("startpos moves e2e4 e7e5"))
+	uci.cmdGo(engine, strings.Fields("depth 3"))
+
+	fields := strings.Fields(out.String())
+	if len(fields) != 4 || fields[0] != "bestmove" || fields[2] != "ponder" {
+		t.Fatalf("Expected bestmove with ponder move but got %q\n", out.String())
+	}
+	// The engine board already holds the best move, so the ponder move must be legal now.
+	ponder, err := ParseMiniNotation(fields[3])
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if !engine.board.IsLegalMove(ponder) {
+		t.Fatalf("Expected a legal ponder move after %s but got %s\n", fields[1], fields[3])
+	}
+}
+
+func TestUciDefaultLimit(t *testing.T) {
+	var settings []SearchSettings
+	search := func(engine *Engine, ss *SearchSettings, dostop *uint32) SearchResult {
+		settings = append(settings, *ss)
+		return SearchResult{Move: engine.GetLegalMoves().Moves[0]}
+	}
+	uci := &UCI{}
+	engine := NewEngine("chesskimo", "David Linus Briemann", "", uci, search)
+	engine.Output = &bytes.Buffer{}
+
+	for _, args := range []string{"", "infinite", "depth 4", "movetime 100"} {
+		uci.cmdNewGame(engine)
+		uci.cmdPosition(engine, strings.Fields("startpos"))
+		uci.cmdGo(engine, strings.Fields(args))
+	}
+
+	type set struct {
+		maxDepth int
+		moveTime time.Duration
+	}
+	expected := []set{
+		{0, UCI_DEFAULT_MOVE_TIME},
+		{0, UCI_DEFAULT_MOVE_TIME},
+		{4, 0},
+		{0, 100 * time.Millisecond},
+	}
+	for i, e := range expected {
+		if settings[i].MaxDepth != e.maxDepth || settings[i].MoveTime != e.moveTime {
+			t.Fatalf("Expected depth %d and move time %v for go #%d but got %d and %v\n",
+				e.maxDepth, e.moveTime, i, settings[i].MaxDepth, settings[i].MoveTime)
+		}
+	}
+}
+
+func TestUciEval(t *testing.T) {
+	uci := &UCI{}
+	engine := NewEngine("chesskimo", "David Linus Briemann", "", uci, AlphaBetaSearch)
+	out := bytes.Buffer{}
+	engine.Output = &out
+
+	uci.cmdNewGame(engine)
+	uci.cmdPosition(engine, strings.Fields("startpos"))
+	uci.cmdEval(engine)
+
+	terms := map[string]int{}
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			t.Fatalf("Expected term and value but got %q\n", line)
+		}
+		value, err := strconv.Atoi(fields[1])
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		terms[fields[0]] = value
+	}
+
+	total, ok := terms["Total"]
+	if !ok || total < -TEMPO_BONUS || total > TEMPO_BONUS {
+		t.Fatalf("Expected a total near zero but got:\n%s\n", out.String())
+	}
+	if material, ok := terms["Material"]; !ok || material != 0 {
+		t.Fatalf("Expected material 0 but got:\n%s\n", out.String())
+	}
+}
@@ -0,0 +1,68 @@
+package chesskimo
+
+import (
+	"strconv"
+	"strings"
+)
+
+const (
+	// ANSI escape sequences for the square backgrounds of the Unicode board.
+	ANSI_LIGHT_SQUARE = "\x1b[48;5;180m"
+	ANSI_DARK_SQUARE  = "\x1b[48;5;137m"
+	ANSI_RESET        = "\x1b[0m"
+)
+
+var (
+	UnicodeMap = map[Piece]string{
+		BPAWN:   "♟",
+		BKNIGHT: "♞",
+		BBISHOP: "♝",
+		BROOK:   "♜",
+		BQUEEN:  "♛",
+		BKING:   "♚",
+		WPAWN:   "♙",
+		WKNIGHT: "♘",
+		WBISHOP: "♗",
+		WROOK:   "♖",
+		WQUEEN:  "♕",
+		WKING:   "♔",
+		EMPTY:   " ",
+	}
+)
+
+// ToUnicode renders the board with Unicode chess glyphs on colored squares for
+// terminals which support ANSI escape sequences. If 'flip' is true the board is
+// shown from black's point of view.
+func (b *Board) ToUnicode(flip bool) string {
+	var sb strings.Builder
+
+	for i := 0; i < 8; i++ {
+		r := 7 - i
+		if flip {
+			r = i
+		}
+		sb.WriteString(strconv.Itoa(r+1) + " ")
+		for j := 0; j < 8; j++ {
+			f := j
+			if flip {
+				f = 7 - j
+			}
+			// a1 is a dark square.
+			if (r+f)%2 == 1 {
+				sb.WriteString(ANSI_LIGHT_SQUARE)
+			} else {
+				sb.WriteString(ANSI_DARK_SQUARE)
+			}
+			sb.WriteString(" " + UnicodeMap[b.Squares[16*r+f]] + " ")
+		}
+		sb.WriteString(ANSI_RESET + "\n")
+	}
+
+	if flip {
+		sb.WriteString("   h  g  f  e  d  c  b  a\n")
+	} else {
+		sb.WriteString("   a  b  c  d  e  f  g  h\n")
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,39 @@
+package chesskimo
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestToUnicode(t *testing.T) {
+	board := NewBoard()
+	// White king on e1 (dark square), black queen on a8 (light square).
+	if err := board.SetFEN("q3k3/8/8/8/8/8/8/4K3 w - - 0 1"); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	for _, flip := range []bool{false, true} {
+		lines := strings.Split(board.ToUnicode(flip), "\n")
+		top, bottom := lines[0], lines[7]
+		if flip {
+			top, bottom = bottom, top
+		}
+
+		if !strings.HasPrefix(top, "8 ") || !strings.Contains(top, ANSI_LIGHT_SQUARE+" ♛ ") {
+			t.Fatalf("Expected black queen on light square a8 (flip=%v) in line %q\n", flip, top)
+		}
+		if !strings.HasPrefix(bottom, "1 ") || !strings.Contains(bottom, ANSI_DARK_SQUARE+" ♔ ") {
+			t.Fatalf("Expected white king on dark square e1 (flip=%v) in line %q\n", flip, bottom)
+		}
+	}
+
+	// The queen is the first piece of the top rank, or the last one if flipped.
+	normal := strings.Split(board.ToUnicode(false), "\n")[0]
+	flipped := strings.Split(board.ToUnicode(true), "\n")[7]
+	if strings.Index(normal, "♛") > strings.Index(normal, "♚") {
+		t.Fatalf("Expected queen left of the king in line %q\n", normal)
+	}
+	if strings.Index(flipped, "♛") < strings.Index(flipped, "♚") {
+		t.Fatalf("Expected queen right of the king in flipped line %q\n", flipped)
+	}
+}
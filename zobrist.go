@@ -0,0 +1,102 @@
+package chesskimo
+
+var (
+	// Random keys for Zobrist hashing. Pieces are indexed by Piece.TypeIndex and color,
+	// squares by their 0x88 index.
+	ZOBRIST_PIECES        [14][128]uint64
+	ZOBRIST_CASTLE_SHORT  [2]uint64
+	ZOBRIST_CASTLE_LONG   [2]uint64
+	ZOBRIST_EP_FILE       [8]uint64
+	ZOBRIST_WHITE_TO_MOVE uint64
+	// ZOBRIST_HAND is indexed like ZOBRIST_PIECES and by the number of pieces in the hand.
+	ZOBRIST_HAND [14][32]uint64
+)
+
+func init() {
+	populateZobristKeys()
+}
+
+// populateZobristKeys fills the key tables with a fixed pseudo random sequence (xorshift64*),
+// so hashes are reproducible between runs.
+func populateZobristKeys() {
+	state := uint64(0x9E3779B97F4A7C15)
+	next := func() uint64 {
+		state ^= state >> 12
+		state ^= state << 25
+		state ^= state >> 27
+		return state * 0x2545F4914F6CDD1D
+	}
+
+	for p := range ZOBRIST_PIECES {
+		for sq := range ZOBRIST_PIECES[p] {
+			ZOBRIST_PIECES[p][sq] = next()
+		}
+	}
+	for color := BLACK; color <= WHITE; color++ {
+		ZOBRIST_CASTLE_SHORT[color] = next()
+		ZOBRIST_CASTLE_LONG[color] = next()
+	}
+	for f := range ZOBRIST_EP_FILE {
+		ZOBRIST_EP_FILE[f] = next()
+	}
+	ZOBRIST_WHITE_TO_MOVE = next()
+	for p := range ZOBRIST_HAND {
+		for n := range ZOBRIST_HAND[p] {
+			ZOBRIST_HAND[p][n] = next()
+		}
+	}
+}
+
+func zobristPiece(piece Piece, sq Square) uint64 {
+	return ZOBRIST_PIECES[piece.TypeIndex()<<1|int(piece.PieceColor())][sq]
+}
+
+// ComputeHash calculates the Zobrist hash of the position from scratch. Board.Hash
+// is updated incrementally while moves are made and always equals this value.
+func (b *Board) ComputeHash() uint64 {
+	hash := b.stateHash()
+	for _, sq := range Lookup0x88 {
+		if piece := b.Squares[sq]; !piece.IsEmpty() {
+			hash ^= zobristPiece(piece, sq)
+		}
+	}
+	return hash
+}
+
+// stateHash returns the hash of the side to move, the castling rights, the
+// en passant square and the hands in Crazyhouse. The en passant square is only hashed if a pawn can actually
+// capture en passant, so otherwise equal positions are considered repetitions.
+func (b *Board) stateHash() uint64 {
+	hash := uint64(0)
+	if b.Player == WHITE {
+		hash ^= ZOBRIST_WHITE_TO_MOVE
+	}
+	for color := BLACK; color <= WHITE; color++ {
+		if b.CastleShort[color] {
+			hash ^= ZOBRIST_CASTLE_SHORT[color]
+		}
+		if b.CastleLong[color] {
+			hash ^= ZOBRIST_CASTLE_LONG[color]
+		}
+	}
+	if b.EpSquare != OTB {
+		pawn := PAWN | b.Player
+		for d := 0; d < 2; d++ {
+			from := b.EpSquare.Add(PAWN_CAPTURE_DIRS[b.Player.Flip()][d])
+			if from.OnBoard() && b.Squares[from] == pawn {
+				hash ^= ZOBRIST_EP_FILE[b.EpSquare.File()]
+				break
+			}
+		}
+	}
+	if b.Crazyhouse {
+		for color := BLACK; color <= WHITE; color++ {
+			for t, n := range b.Hand[color] {
+				if n > 0 {
+					hash ^= ZOBRIST_HAND[t<<1|int(color)][n]
+				}
+			}
+		}
+	}
+	return hash
+}
@@ -0,0 +1,72 @@
+package chesskimo
+
+import (
+	"testing"
+)
+
+func TestIncrementalHash(t *testing.T) {
+	fens := []string{
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+		"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1",
+		"n1n5/PPPk4/8/8/8/8/4Kppp/5N1N b - - 0 1",
+		"rnbqkbnr/ppp1p1pp/8/3pPp2/8/8/PPPP1PPP/RNBQKBNR w KQkq f6 0 3",
+	}
+
+	board := NewBoard()
+	for _, fen := range fens {
+		if err := board.SetFEN(fen); err != nil {
+			t.Fatalf(err.Error())
+		}
+		checkHashes(t, &board, 3)
+	}
+}
+
+// checkHashes compares the incremental hash with the computed one for all positions
+// of the move tree to the given depth.
+func checkHashes(t *testing.T, b *Board, depth int) {
+	if b.Hash != b.ComputeHash() {
+		t.Fatalf("Incremental hash %x differs from computed hash %x in position %s\n", b.Hash, b.ComputeHash(), b.FEN())
+	}
+	if depth == 0 {
+		return
+	}
+
+	mlist := MoveList{}
+	b.GenerateAllLegalMoves(&mlist)
+	cpy := *b
+	for i := uint32(0); i < mlist.Size; i++ {
+		b.MakeLegalMove(mlist.Moves[i])
+		checkHashes(t, b, depth-1)
+		*b = cpy
+	}
+}
+
+func TestHashTranspositions(t *testing.T) {
+	board := NewBoard()
+	start := board.Hash
+
+	// Moving the knights out and back reaches the starting position again.
+	for _, m := range []string{"g1f3", "g8f6", "f3g1", "f6g8"} {
+		board.MakeLegalMove(parseTestMove(t, m))
+	}
+	if board.Hash != start {
+		t.Fatalf("Expected hash %x of the starting position but got %x\n", start, board.Hash)
+	}
+
+	// The side to move is part of the hash.
+	if board.NullMovePosition().Hash == start {
+		t.Fatalf("Expected different hash with the other side to move\n")
+	}
+
+	// An e.p. square without a pawn to capture is ignored.
+	noEp := NewBoard()
+	if err := board.SetFEN("rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1"); err != nil {
+		t.Fatalf(err.Error())
+	}
+	if err := noEp.SetFEN("rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq - 0 1"); err != nil {
+		t.Fatalf(err.Error())
+	}
+	if board.Hash != noEp.Hash {
+		t.Fatalf("Expected e.p. square without capture to be ignored\n")
+	}
+}